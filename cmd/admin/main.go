@@ -0,0 +1,58 @@
+package main
+
+import (
+	"akira/internal/config/env"
+	"akira/internal/db"
+	"akira/internal/entity"
+	"akira/internal/usecase/logger"
+	"akira/internal/usecase/user"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+)
+
+const usage = "usage: admin grant|revoke <email>"
+
+// admin grants or revokes the admin pages to an existing account. Admins are
+// set here rather than in the app, as signing up doesn't prove the ownership
+// of the email.
+func main() {
+	if err := run(context.Background(), os.Args[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, args []string) error {
+	if len(args) != 2 || (args[0] != "grant" && args[0] != "revoke") {
+		return errors.New(usage)
+	}
+	if err := env.Load(); err != nil {
+		return fmt.Errorf("failed to load env: %w", err)
+	}
+	logger := logger.NewLogger()
+	defer logger.Close()
+	sqlite, err := db.NewSqliteConnection(db.SqliteConfig{
+		Path:         env.DATABASE_DSN,
+		MaxOpenConns: 1,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to connect to db: %w", err)
+	}
+	defer sqlite.Close()
+	users, _ := user.Make(ctx, sqlite, logger)
+	u, err := users.SetAdmin(args[1], args[0] == "grant")
+	if err != nil {
+		if err == entity.ErrNotFound {
+			return fmt.Errorf("no account with the email %s", args[1])
+		}
+		return err
+	}
+	if u.Admin {
+		fmt.Printf("%s is now an admin\n", u.Email)
+	} else {
+		fmt.Printf("%s is no longer an admin\n", u.Email)
+	}
+	return nil
+}
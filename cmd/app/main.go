@@ -3,15 +3,21 @@ package main
 import (
 	"akira/internal/config/env"
 	"akira/internal/db"
+	"akira/internal/entity"
 	"akira/internal/locale"
 	"akira/internal/server"
 	"akira/internal/usecase/auth"
 	"akira/internal/usecase/book"
+	"akira/internal/usecase/candidate"
 	"akira/internal/usecase/collection"
 	"akira/internal/usecase/crawler"
 	"akira/internal/usecase/event"
 	"akira/internal/usecase/i18n"
+	"akira/internal/usecase/scheduler"
 	"akira/internal/usecase/logger"
+	"akira/internal/usecase/notification"
+	"akira/internal/usecase/price"
+	"akira/internal/usecase/review"
 	"akira/internal/usecase/session"
 	"akira/internal/usecase/theme"
 	"akira/internal/usecase/user"
@@ -65,11 +71,36 @@ func run(ctx context.Context) error {
 	theme := theme.Make(ctx, logger)
 	auth := auth.Make(ctx, userService, logger)
 	event := event.Make(ctx, logger)
-	book := book.Make(ctx, logger)
+	book := book.Make(ctx, sqlite, logger)
 	collection := collection.Make(ctx, sqlite, event, logger)
-	_, consumer := crawler.Make(ctx, event, book, collection, logger)
+	price := price.Make(ctx, sqlite, event, logger)
+	review := review.Make(ctx, sqlite, logger)
+	candidate := candidate.Make(ctx, sqlite, event, logger)
+	notificationService, notificationConsumer := notification.Make(ctx, sqlite, event, collection, logger)
+	crawlerService, consumer := crawler.Make(ctx, sqlite, event, book, collection, price, review, candidate, logger, env.PROVIDERS_DIR, entity.CrawlPolicy{
+		DomainInterval:   env.CRAWL_DOMAIN_INTERVAL,
+		RespectRobotsTxt: env.CRAWL_RESPECT_ROBOTS,
+		DailyBudget:      env.CRAWL_DAILY_BUDGET,
+		MaxBackoff:       env.CRAWL_MAX_BACKOFF,
+		SearchPageTTL:    env.CRAWL_SEARCH_PAGE_TTL,
+		ProductPageTTL:   env.CRAWL_PRODUCT_PAGE_TTL,
+	})
+	if err := crawlerService.Start(); err != nil {
+		logger.Error(ctx, "failed to start crawler", err, nil)
+		return err
+	}
+	syncScheduler := scheduler.Make(ctx, collection, logger, entity.SchedulerOptions{
+		Interval:      env.SYNC_INTERVAL,
+		Jitter:        env.SYNC_JITTER,
+		MaxConcurrent: env.SYNC_MAX_CONCURRENT,
+		CheckInterval: env.SYNC_CHECK_INTERVAL,
+	})
+	if err := syncScheduler.Start(); err != nil {
+		logger.Error(ctx, "failed to start sync scheduler", err, nil)
+		return err
+	}
 	app := chi.NewRouter()
-	web := web.NewHandler(app, userService, sessionService, auth, logger, i18n, theme, collection, web.Options{
+	web := web.NewHandler(app, userService, sessionService, auth, logger, i18n, theme, collection, book, price, review, candidate, notificationService, crawlerService, event, web.Options{
 		AllowedOrigins: []string{"same-origin"},
 	})
 	s := server.NewServer(ctx, "", env.PORT, web, logger)
@@ -82,5 +113,14 @@ func run(ctx context.Context) error {
 	s.RegisterCleanup(func() error {
 		return consumer.Shutdown()
 	})
+	s.RegisterCleanup(func() error {
+		return crawlerService.Shutdown()
+	})
+	s.RegisterCleanup(func() error {
+		return syncScheduler.Shutdown()
+	})
+	s.RegisterCleanup(func() error {
+		return notificationConsumer.Shutdown()
+	})
 	return s.Run()
 }
@@ -0,0 +1,35 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS books (
+    id CHAR(26) PRIMARY KEY NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    edition VARCHAR(255) NULL,
+    description TEXT NULL,
+    slug VARCHAR(255) NOT NULL,
+    cover_image VARCHAR(2048) NULL,
+    page_count INT NULL,
+    volume INT NULL,
+    rating REAL NULL,
+    reviews TEXT NULL, -- JSON array
+    publisher VARCHAR(255) NULL,
+    authors TEXT NULL, -- JSON array
+    user_id CHAR(26) NOT NULL,
+    isbn VARCHAR(32) NULL,
+    tags TEXT NULL, -- JSON array
+    metadata TEXT NULL, -- JSON object
+    lang VARCHAR(255) NULL,
+    released_at DATETIME NULL,
+    last_sync_at DATETIME NULL,
+    created_at DATETIME NOT NULL,
+    updated_at DATETIME NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_book_user_slug ON books(user_id, slug);
+CREATE INDEX IF NOT EXISTS idx_book_user_id ON books(user_id);
+CREATE INDEX IF NOT EXISTS idx_book_isbn ON books(isbn);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS books;
+-- +goose StatementEnd
@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS collection_books (
+    collection_id CHAR(26) NOT NULL,
+    book_id CHAR(26) NOT NULL,
+    created_at DATETIME NOT NULL,
+    PRIMARY KEY (collection_id, book_id),
+    FOREIGN KEY (collection_id) REFERENCES collections(id) ON DELETE CASCADE,
+    FOREIGN KEY (book_id) REFERENCES books(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_collection_books_book_id ON collection_books(book_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS collection_books;
+-- +goose StatementEnd
@@ -0,0 +1,12 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE collections ADD COLUMN archived_at DATETIME NULL;
+
+CREATE INDEX IF NOT EXISTS idx_collection_archived_at ON collections(archived_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_collection_archived_at;
+ALTER TABLE collections DROP COLUMN archived_at;
+-- +goose StatementEnd
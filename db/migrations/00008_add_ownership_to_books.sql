@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE books ADD COLUMN ownership TEXT NOT NULL DEFAULT 'none';
+ALTER TABLE books ADD COLUMN reading_status TEXT NOT NULL DEFAULT 'unread';
+ALTER TABLE books ADD COLUMN purchased_at DATETIME NULL;
+ALTER TABLE books ADD COLUMN price_paid REAL NULL;
+ALTER TABLE books ADD COLUMN store TEXT NULL;
+ALTER TABLE books ADD COLUMN condition TEXT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_book_ownership ON books(ownership);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_book_ownership;
+ALTER TABLE books DROP COLUMN condition;
+ALTER TABLE books DROP COLUMN store;
+ALTER TABLE books DROP COLUMN price_paid;
+ALTER TABLE books DROP COLUMN purchased_at;
+ALTER TABLE books DROP COLUMN reading_status;
+ALTER TABLE books DROP COLUMN ownership;
+-- +goose StatementEnd
@@ -0,0 +1,30 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS crawl_runs (
+    id CHAR(26) PRIMARY KEY NOT NULL,
+    collection_id CHAR(26) NOT NULL,
+    user_id CHAR(26) NOT NULL,
+    search_terms TEXT NULL, -- JSON array
+    sites TEXT NULL, -- JSON array
+    options TEXT NULL, -- JSON object
+    status VARCHAR(255) NOT NULL,
+    providers TEXT NULL, -- JSON object, stats by provider
+    result_count INT NOT NULL DEFAULT 0,
+    error TEXT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    queued_at DATETIME NOT NULL,
+    started_at DATETIME NULL,
+    finished_at DATETIME NULL,
+    created_at DATETIME NOT NULL,
+    updated_at DATETIME NOT NULL,
+    FOREIGN KEY (collection_id) REFERENCES collections(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_crawl_run_collection_id ON crawl_runs(collection_id, queued_at);
+CREATE INDEX IF NOT EXISTS idx_crawl_run_status ON crawl_runs(status, queued_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS crawl_runs;
+-- +goose StatementEnd
@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS price_observations (
+    id CHAR(26) PRIMARY KEY NOT NULL,
+    book_id CHAR(26) NOT NULL,
+    collection_id CHAR(26) NOT NULL,
+    user_id CHAR(26) NOT NULL,
+    source VARCHAR(255) NOT NULL,
+    url TEXT NOT NULL,
+    price REAL NOT NULL,
+    observed_at DATETIME NOT NULL,
+    created_at DATETIME NOT NULL,
+    FOREIGN KEY (book_id) REFERENCES books(id) ON DELETE CASCADE,
+    FOREIGN KEY (collection_id) REFERENCES collections(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_price_observation_book ON price_observations(book_id, source, url, observed_at);
+CREATE INDEX IF NOT EXISTS idx_price_observation_collection ON price_observations(collection_id);
+
+ALTER TABLE books ADD COLUMN target_price REAL NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE books DROP COLUMN target_price;
+DROP TABLE IF EXISTS price_observations;
+-- +goose StatementEnd
@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS content_reviews (
+    id CHAR(26) PRIMARY KEY NOT NULL,
+    book_id CHAR(26) NOT NULL,
+    source VARCHAR(255) NOT NULL,
+    fingerprint CHAR(40) NOT NULL,
+    author VARCHAR(255) NULL,
+    title TEXT NULL,
+    content TEXT NULL,
+    rating REAL NULL,
+    reviewed_at DATETIME NULL,
+    created_at DATETIME NOT NULL,
+    updated_at DATETIME NOT NULL,
+    FOREIGN KEY (book_id) REFERENCES books(id) ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_content_review_fingerprint ON content_reviews(book_id, fingerprint);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS content_reviews;
+-- +goose StatementEnd
@@ -0,0 +1,34 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS crawl_candidates (
+    id CHAR(26) PRIMARY KEY NOT NULL,
+    collection_id CHAR(26) NOT NULL,
+    user_id CHAR(26) NOT NULL,
+    run_id CHAR(26) NULL,
+    url TEXT NOT NULL,
+    isbn VARCHAR(32) NULL,
+    volume INT NULL,
+    confidence REAL NOT NULL,
+    status VARCHAR(32) NOT NULL,
+    result TEXT NOT NULL, -- JSON object
+    created_at DATETIME NOT NULL,
+    updated_at DATETIME NOT NULL,
+    FOREIGN KEY (collection_id) REFERENCES collections(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_crawl_candidate_collection ON crawl_candidates(collection_id, status);
+
+CREATE TABLE IF NOT EXISTS crawl_rejections (
+    collection_id CHAR(26) NOT NULL,
+    key TEXT NOT NULL, -- "url:<listing URL>" or "isbn:<ISBN-13>"
+    created_at DATETIME NOT NULL,
+    PRIMARY KEY (collection_id, key),
+    FOREIGN KEY (collection_id) REFERENCES collections(id) ON DELETE CASCADE
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS crawl_rejections;
+DROP TABLE IF EXISTS crawl_candidates;
+-- +goose StatementEnd
@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS crawl_pages (
+    url TEXT PRIMARY KEY NOT NULL,
+    status INT NOT NULL,
+    header TEXT NOT NULL, -- JSON object
+    body BLOB NOT NULL,
+    etag TEXT NULL,
+    last_modified TEXT NULL,
+    fetched_at DATETIME NOT NULL,
+    expires_at DATETIME NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_crawl_page_fetched_at ON crawl_pages(fetched_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_crawl_page_fetched_at;
+DROP TABLE IF EXISTS crawl_pages;
+-- +goose StatementEnd
@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS crawl_logs (
+    id CHAR(26) PRIMARY KEY NOT NULL,
+    run_id CHAR(26) NOT NULL,
+    collection_id CHAR(26) NOT NULL,
+    site VARCHAR(64) NULL,
+    level VARCHAR(16) NOT NULL,
+    message TEXT NOT NULL,
+    error TEXT NULL,
+    fields TEXT NULL, -- JSON object
+    created_at DATETIME NOT NULL,
+    FOREIGN KEY (run_id) REFERENCES crawl_runs(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_crawl_log_run ON crawl_logs(run_id, created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_crawl_log_run;
+DROP TABLE IF EXISTS crawl_logs;
+-- +goose StatementEnd
@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE collections ADD COLUMN last_sync_attempt_at DATETIME NULL;
+
+UPDATE collections SET last_sync_attempt_at = last_sync_at;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE collections DROP COLUMN last_sync_attempt_at;
+-- +goose StatementEnd
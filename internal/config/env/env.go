@@ -3,6 +3,7 @@ package env
 import (
 	"os"
 	"strconv"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -25,6 +26,17 @@ var (
 	LOGGER_SENTRY_DEBUG              bool
 	TURNSTILE_SITE_KEY               string
 	TURNSTILE_SECRET_KEY             string
+	SYNC_INTERVAL                    time.Duration
+	SYNC_JITTER                      time.Duration
+	SYNC_MAX_CONCURRENT              int
+	SYNC_CHECK_INTERVAL              time.Duration
+	PROVIDERS_DIR                    string
+	CRAWL_DOMAIN_INTERVAL            time.Duration
+	CRAWL_RESPECT_ROBOTS             bool
+	CRAWL_DAILY_BUDGET               int
+	CRAWL_MAX_BACKOFF                time.Duration
+	CRAWL_SEARCH_PAGE_TTL            time.Duration
+	CRAWL_PRODUCT_PAGE_TTL           time.Duration
 )
 
 func Load() error {
@@ -55,6 +67,11 @@ func boolean(s string) bool {
 	return b
 }
 
+func duration(s string) time.Duration {
+	d, _ := time.ParseDuration(s)
+	return d
+}
+
 func environment(s string) string {
 	if s == DEV || s == PROD {
 		return s
@@ -74,6 +91,17 @@ func setenvs() {
 	LOGGER_SENTRY_DEBUG = getenv("LOGGER_SENTRY_DEBUG", false, boolean)
 	TURNSTILE_SITE_KEY = getenv("TURNSTILE_SITE_KEY", "", str)
 	TURNSTILE_SECRET_KEY = getenv("TURNSTILE_SECRET_KEY", "", str)
+	SYNC_INTERVAL = getenv("SYNC_INTERVAL", 24*time.Hour, duration)
+	SYNC_JITTER = getenv("SYNC_JITTER", time.Hour, duration)
+	SYNC_MAX_CONCURRENT = getenv("SYNC_MAX_CONCURRENT", 2, num)
+	SYNC_CHECK_INTERVAL = getenv("SYNC_CHECK_INTERVAL", time.Minute, duration)
+	PROVIDERS_DIR = getenv("PROVIDERS_DIR", "providers", str)
+	CRAWL_DOMAIN_INTERVAL = getenv("CRAWL_DOMAIN_INTERVAL", 3*time.Second, duration)
+	CRAWL_RESPECT_ROBOTS = getenv("CRAWL_RESPECT_ROBOTS", false, boolean)
+	CRAWL_DAILY_BUDGET = getenv("CRAWL_DAILY_BUDGET", 2000, num)
+	CRAWL_MAX_BACKOFF = getenv("CRAWL_MAX_BACKOFF", 30*time.Minute, duration)
+	CRAWL_SEARCH_PAGE_TTL = getenv("CRAWL_SEARCH_PAGE_TTL", 6*time.Hour, duration)
+	CRAWL_PRODUCT_PAGE_TTL = getenv("CRAWL_PRODUCT_PAGE_TTL", 72*time.Hour, duration)
 	SESSION_SECRET = getenv("SESSION_SECRET", "Uy@!DNv3@8iikzWNBqb24bFCWgi!FaBY", str)
 }
 
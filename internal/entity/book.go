@@ -1,10 +1,14 @@
 package entity
 
-import "time"
+import (
+	"net/url"
+	"time"
+)
 
 type ContentReview struct {
 	ID        string
 	VolumeID  string
+	Source    string
 	Author    string
 	Title     string
 	Content   string
@@ -14,6 +18,91 @@ type ContentReview struct {
 	UpdatedAt time.Time
 }
 
+type OwnershipStatus string
+
+const (
+	OwnershipNone     OwnershipStatus = "none"
+	OwnershipOwned    OwnershipStatus = "owned"
+	OwnershipWishlist OwnershipStatus = "wishlist"
+	OwnershipOrdered  OwnershipStatus = "ordered"
+)
+
+func GetOwnershipStatuses() []OwnershipStatus {
+	return []OwnershipStatus{
+		OwnershipNone,
+		OwnershipOwned,
+		OwnershipWishlist,
+		OwnershipOrdered,
+	}
+}
+
+func IsValidOwnershipStatus(status string) bool {
+	for _, s := range GetOwnershipStatuses() {
+		if string(s) == status {
+			return true
+		}
+	}
+	return false
+}
+
+type ReadingStatus string
+
+const (
+	ReadingStatusUnread  ReadingStatus = "unread"
+	ReadingStatusReading ReadingStatus = "reading"
+	ReadingStatusRead    ReadingStatus = "read"
+)
+
+func GetReadingStatuses() []ReadingStatus {
+	return []ReadingStatus{
+		ReadingStatusUnread,
+		ReadingStatusReading,
+		ReadingStatusRead,
+	}
+}
+
+func IsValidReadingStatus(status string) bool {
+	for _, s := range GetReadingStatuses() {
+		if string(s) == status {
+			return true
+		}
+	}
+	return false
+}
+
+type BookCondition string
+
+const (
+	BookConditionNone    BookCondition = ""
+	BookConditionNew     BookCondition = "new"
+	BookConditionLikeNew BookCondition = "like_new"
+	BookConditionGood    BookCondition = "good"
+	BookConditionFair    BookCondition = "fair"
+	BookConditionPoor    BookCondition = "poor"
+)
+
+func GetBookConditions() []BookCondition {
+	return []BookCondition{
+		BookConditionNew,
+		BookConditionLikeNew,
+		BookConditionGood,
+		BookConditionFair,
+		BookConditionPoor,
+	}
+}
+
+func IsValidBookCondition(condition string) bool {
+	if condition == string(BookConditionNone) {
+		return true
+	}
+	for _, c := range GetBookConditions() {
+		if string(c) == condition {
+			return true
+		}
+	}
+	return false
+}
+
 type Book struct {
 	ID          string
 	Name        string
@@ -32,6 +121,14 @@ type Book struct {
 	Tags        []string
 	Metadata    map[string]string
 	Language    string
+	ReleasedAt  time.Time
+	Ownership   OwnershipStatus
+	Reading     ReadingStatus
+	PurchasedAt time.Time
+	PricePaid   float64
+	TargetPrice float64
+	Store       string
+	Condition   BookCondition
 	LastSync    time.Time
 	CreatedAt   time.Time
 	UpdatedAt   time.Time
@@ -42,8 +139,10 @@ func NewBook(
 	pageCount int, volume *int, rating float64,
 	publisher string, author []string, isbn string,
 	tags []string, metadata map[string]string, language string,
+	releasedAt time.Time,
 ) *Book {
 	return &Book{
+		ID:          NewID(),
 		UserID:      userID,
 		Name:        name,
 		Edition:     edition,
@@ -59,7 +158,31 @@ func NewBook(
 		Tags:        tags,
 		Metadata:    metadata,
 		Language:    language,
+		ReleasedAt:  releasedAt,
+		Ownership:   OwnershipNone,
+		Reading:     ReadingStatusUnread,
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}
+}
+
+// IsReleased reports whether the book is already on sale, based on its release
+// date and the presale flag set by the crawlers.
+func (b *Book) IsReleased(now time.Time) bool {
+	if b.Metadata != nil && b.Metadata["presale"] == "true" {
+		return false
 	}
+	return b.ReleasedAt.IsZero() || !b.ReleasedAt.After(now)
+}
+
+func (b *Book) IsOwned() bool {
+	return b.Ownership == OwnershipOwned
+}
+
+// IsMissing reports whether the user still has to get hold of the book,
+// which is when price drops are worth telling them about.
+func (b *Book) IsMissing() bool {
+	return b.Ownership == OwnershipNone || b.Ownership == OwnershipWishlist
 }
 
 type CreateBookRequest struct {
@@ -77,6 +200,7 @@ type CreateBookRequest struct {
 	Tags        []string
 	Metadata    map[string]string
 	Language    string
+	ReleasedAt  time.Time
 	ColletionID *string
 }
 
@@ -88,16 +212,129 @@ func (r *CreateBookRequest) Validate() error {
 	if len(r.Name) > 255 {
 		e = e.Add("name", ErrBookNameTooLong.Error())
 	}
-	return e
+	if r.Volume != nil && *r.Volume <= 0 {
+		e = e.Add("volume", ErrBookVolumeInvalid.Error())
+	}
+	if r.PageCount < 0 {
+		e = e.Add("page_count", ErrBookPageCountInvalid.Error())
+	}
+	if r.CoverImage != "" && !isValidImageURL(r.CoverImage) {
+		e = e.Add("cover_image", ErrBookCoverImageInvalid.Error())
+	}
+	if len(r.Publisher) > 255 {
+		e = e.Add("publisher", ErrBookPublisherTooLong.Error())
+	}
+	if len(r.Language) > 255 {
+		e = e.Add("language", ErrBookLanguageTooLong.Error())
+	}
+	// the ISBN is kept as ISBN-13, whatever form it was typed in
+	if r.ISBN != "" {
+		isbn, err := ParseISBN(r.ISBN)
+		if err != nil {
+			e = e.Add("isbn", err.Error())
+		}
+		r.ISBN = isbn
+	}
+	if e.HasError() {
+		return e
+	}
+	return nil
+}
+
+// isValidImageURL accepts the absolute http(s) URLs a cover can be loaded
+// from, as the stored column holds up to 2048 characters.
+func isValidImageURL(value string) bool {
+	if len(value) > 2048 {
+		return false
+	}
+	u, err := url.Parse(value)
+	if err != nil {
+		return false
+	}
+	return (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+type UpdateBookStateRequest struct {
+	Ownership   OwnershipStatus
+	Reading     ReadingStatus
+	PurchasedAt time.Time
+	PricePaid   float64
+	TargetPrice float64
+	Store       string
+	Condition   BookCondition
+}
+
+func (r *UpdateBookStateRequest) Validate() error {
+	var e RequestError
+	if !IsValidOwnershipStatus(string(r.Ownership)) {
+		e = e.Add("ownership", ErrBookOwnershipInvalid.Error())
+	}
+	if !IsValidReadingStatus(string(r.Reading)) {
+		e = e.Add("reading_status", ErrBookReadingStatusInvalid.Error())
+	}
+	if !IsValidBookCondition(string(r.Condition)) {
+		e = e.Add("condition", ErrBookConditionInvalid.Error())
+	}
+	if r.PricePaid < 0 {
+		e = e.Add("price_paid", ErrBookPricePaidInvalid.Error())
+	}
+	if r.TargetPrice < 0 {
+		e = e.Add("target_price", ErrBookTargetPriceInvalid.Error())
+	}
+	if len(r.Store) > 255 {
+		e = e.Add("store", ErrBookStoreTooLong.Error())
+	}
+	if e.HasError() {
+		return e
+	}
+	return nil
+}
+
+// BulkUpdateBooksRequest changes the state of several books of a collection at
+// once. Empty statuses leave the current value untouched.
+type BulkUpdateBooksRequest struct {
+	BookIDs   []string
+	Ownership OwnershipStatus
+	Reading   ReadingStatus
+}
+
+func (r *BulkUpdateBooksRequest) Validate() error {
+	var e RequestError
+	if len(r.BookIDs) == 0 {
+		e = e.Add("general", ErrBookSelectionEmpty.Error())
+	}
+	if r.Ownership != "" && !IsValidOwnershipStatus(string(r.Ownership)) {
+		e = e.Add("ownership", ErrBookOwnershipInvalid.Error())
+	}
+	if r.Reading != "" && !IsValidReadingStatus(string(r.Reading)) {
+		e = e.Add("reading_status", ErrBookReadingStatusInvalid.Error())
+	}
+	if e.HasError() {
+		return e
+	}
+	return nil
 }
 
 type BookService interface {
 	CreateBook(userID string, req CreateBookRequest) (*Book, error)
 	CreateCollectionBook(userID, collectionID string, req CreateBookRequest) (*Book, error)
+	FindCollectionBooks(userID, collectionID string) ([]Book, error)
+	FindBookByID(userID, bookID string) (*Book, error)
+	UpdateCollectionBook(userID, collectionID, bookID string, req CreateBookRequest) (*Book, error)
+	DeleteBook(userID, bookID string) error
+	UpdateBookState(userID, bookID string, req UpdateBookStateRequest) (*Book, error)
+	BulkUpdateBooks(userID, collectionID string, req BulkUpdateBooksRequest) error
+	UpdateBookRelease(bookID string, releasedAt time.Time, presale bool) (*Book, error)
 }
 
 type BookRepository interface {
 	CreateBook(book *Book) error
 	CreateCollectionBook(collectionID string, book *Book) error
 	FindBookBySlug(userID, slug string) (*Book, error)
+	FindCollectionBooks(collectionID string) ([]Book, error)
+	FindBookByID(id string) (*Book, error)
+	UpdateBook(book *Book) error
+	UpdateBooks(books []*Book) error
+	UpdateBookRelease(book *Book) error
+	DeleteBook(id string) error
 }
@@ -5,3 +5,37 @@ import "errors"
 var ErrBookNameInvalid = errors.New("error.book.invalid-name")
 
 var ErrBookNameTooLong = errors.New("error.book.name-too-long")
+
+var ErrBookNotFound = errors.New("error.book.not-found")
+
+var ErrBookForbidden = errors.New("error.book.forbidden")
+
+var ErrBookOwnershipInvalid = errors.New("error.book.invalid-ownership")
+
+var ErrBookReadingStatusInvalid = errors.New("error.book.invalid-reading-status")
+
+var ErrBookConditionInvalid = errors.New("error.book.invalid-condition")
+
+var ErrBookPricePaidInvalid = errors.New("error.book.invalid-price-paid")
+
+var ErrBookTargetPriceInvalid = errors.New("error.book.invalid-target-price")
+
+var ErrBookStoreTooLong = errors.New("error.book.store-too-long")
+
+var ErrBookSelectionEmpty = errors.New("error.book.empty-selection")
+
+var ErrBookPurchasedAtInvalid = errors.New("error.book.invalid-purchased-at")
+
+var ErrBookISBNInvalid = errors.New("error.book.invalid-isbn")
+
+var ErrBookVolumeInvalid = errors.New("error.book.invalid-volume")
+
+var ErrBookVolumeTaken = errors.New("error.book.volume-taken")
+
+var ErrBookPageCountInvalid = errors.New("error.book.invalid-page-count")
+
+var ErrBookCoverImageInvalid = errors.New("error.book.invalid-cover-image")
+
+var ErrBookPublisherTooLong = errors.New("error.book.publisher-too-long")
+
+var ErrBookLanguageTooLong = errors.New("error.book.language-too-long")
@@ -0,0 +1,161 @@
+package entity
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type CandidateStatus string
+
+const (
+	CandidatePending  CandidateStatus = "pending"
+	CandidateAccepted CandidateStatus = "accepted"
+	CandidateRejected CandidateStatus = "rejected"
+)
+
+// CandidateConfidenceHigh is the confidence from which candidates are
+// accepted together by the "accept all" action of the review screen.
+const CandidateConfidenceHigh = 0.7
+
+var (
+	candidateTitleNoise = regexp.MustCompile(`[^\p{L}\p{N}]+`)
+	// box sets, companion books and merchandise share the series name
+	candidateSpinOff = regexp.MustCompile(`(?i)\b(box|kit|boxset|artbook|art book|guia|guide|databook|fanbook|light novel|novel|romance|spin-?off|antologia|anthology|caderno|calend[aá]rio|p[oô]ster)\b`)
+)
+
+// CrawlCandidate is a crawled volume waiting for the user to accept it into
+// the collection or reject it.
+type CrawlCandidate struct {
+	ID           string
+	UserID       string
+	CollectionID string
+	RunID        string
+	Result       CrawledResult
+	Confidence   float64
+	Status       CandidateStatus
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+func NewCrawlCandidate(userID, collectionID, runID string, result CrawledResult, confidence float64) *CrawlCandidate {
+	now := time.Now()
+	return &CrawlCandidate{
+		ID:           NewID(),
+		UserID:       userID,
+		CollectionID: collectionID,
+		RunID:        runID,
+		Result:       result,
+		Confidence:   confidence,
+		Status:       CandidatePending,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+}
+
+func (c *CrawlCandidate) IsPending() bool {
+	return c.Status == CandidatePending
+}
+
+// IsConfident tells whether the candidate is likely a volume of the series.
+func (c *CrawlCandidate) IsConfident() bool {
+	return c.Confidence >= CandidateConfidenceHigh
+}
+
+// RejectionKeys identifies a crawled volume by the URL of each store listing
+// and its ISBN, so a rejected one isn't suggested again by any store.
+func RejectionKeys(result CrawledResult) []string {
+	keys := make([]string, 0, len(result.Offers)+1)
+	for _, listing := range result.Listings() {
+		if listing.URL != "" {
+			keys = append(keys, "url:"+listing.URL)
+		}
+	}
+	if isbn := CanonicalISBN(result.ISBN); isbn != "" {
+		keys = append(keys, "isbn:"+isbn)
+	}
+	return keys
+}
+
+// ScoreCandidate estimates from 0 to 1 how likely the crawled volume belongs
+// to the collection: the series title must match the collection, a volume
+// number and an ISBN must be known, and the publisher and the other stores
+// must agree. Box sets and companion books are pushed down.
+func ScoreCandidate(collection *Collection, result CrawledResult) float64 {
+	series := normalizeCandidateTitle(ExtractSeriesTitle(strings.ToLower(result.Title)))
+	score := 0.0
+	match := 0.0
+	for _, name := range append([]string{collection.Name}, collection.SearchTerms()...) {
+		name = normalizeCandidateTitle(name)
+		switch {
+		case name == "" || series == "":
+		case series == name:
+			match = 0.45
+		case strings.Contains(series, name) || strings.Contains(name, series):
+			match = max(match, 0.2)
+		}
+	}
+	score += match
+	if result.Volume > 0 {
+		score += 0.2
+		if collection.TotalVolumes > 0 && result.Volume > collection.TotalVolumes {
+			score -= 0.1
+		}
+	}
+	if CanonicalISBN(result.ISBN) != "" {
+		score += 0.1
+	}
+	if collection.Publisher != "" && result.Publisher != "" {
+		if firstWord(collection.Publisher) == firstWord(result.Publisher) {
+			score += 0.1
+		} else {
+			score -= 0.2
+		}
+	}
+	if sources, _ := strconv.Atoi(result.Metadata["sources"]); sources > 1 {
+		score += 0.15
+	}
+	if candidateSpinOff.MatchString(result.Title) && !candidateSpinOff.MatchString(collection.Name) {
+		score -= 0.4
+	}
+	return min(max(score, 0), 1)
+}
+
+func normalizeCandidateTitle(title string) string {
+	return strings.TrimSpace(candidateTitleNoise.ReplaceAllString(strings.ToLower(title), " "))
+}
+
+func firstWord(s string) string {
+	fields := strings.Fields(normalizeCandidateTitle(s))
+	if len(fields) == 0 {
+		return ""
+	}
+	return fields[0]
+}
+
+type CandidateService interface {
+	// SubmitCandidate queues the crawled volume for review, refreshing the
+	// pending candidate already queued for the same listing or ISBN.
+	SubmitCandidate(collection *Collection, runID string, result CrawledResult) (*CrawlCandidate, error)
+	IsRejected(collectionID string, result CrawledResult) (bool, error)
+	FindPendingCandidates(userID, collectionID string) ([]CrawlCandidate, error)
+	CountPendingCandidates(collectionID string) (int, error)
+	AcceptCandidate(userID, candidateID string) (*CrawlCandidate, error)
+	AcceptConfidentCandidates(userID, collectionID string) (int, error)
+	RejectCandidate(userID, candidateID string) (*CrawlCandidate, error)
+	AssignVolume(userID, candidateID string, volume int) (*CrawlCandidate, error)
+}
+
+type CandidateRepository interface {
+	CreateCandidate(candidate *CrawlCandidate) error
+	UpdateCandidate(candidate *CrawlCandidate) error
+	FindCandidateByID(id string) (*CrawlCandidate, error)
+	// FindPendingCandidate finds the pending candidate of the collection with
+	// the URL or, when given, the ISBN.
+	FindPendingCandidate(collectionID, url, isbn string) (*CrawlCandidate, error)
+	ListCandidates(collectionID string, status CandidateStatus) ([]CrawlCandidate, error)
+	CountCandidates(collectionID string, status CandidateStatus) (int, error)
+	CreateRejections(collectionID string, keys []string, at time.Time) error
+	HasRejection(collectionID string, keys []string) (bool, error)
+}
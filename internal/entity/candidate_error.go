@@ -0,0 +1,11 @@
+package entity
+
+import "errors"
+
+var ErrCandidateNotFound = errors.New("error.candidate.not-found")
+
+var ErrCandidateForbidden = errors.New("error.candidate.forbidden")
+
+var ErrCandidateNotPending = errors.New("error.candidate.not-pending")
+
+var ErrCandidateVolumeInvalid = errors.New("error.candidate.invalid-volume")
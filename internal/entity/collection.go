@@ -1,6 +1,9 @@
 package entity
 
-import "time"
+import (
+	"strings"
+	"time"
+)
 
 type ReleaseStatus string
 
@@ -30,6 +33,13 @@ type SyncOptions struct {
 	TrackReviews    bool
 }
 
+// SyncRequest is the data of EventCollectionSyncFetching. ForceRefresh asks
+// the crawlers to fetch every page again instead of using the page cache.
+type SyncRequest struct {
+	Collection   *Collection
+	ForceRefresh bool
+}
+
 type Collection struct {
 	ID             string
 	Name           string
@@ -47,8 +57,61 @@ type Collection struct {
 	CrawlerOptions SyncOptions
 	Language       string
 	LastSync       time.Time
-	CreatedAt      time.Time
-	UpdatedAt      time.Time
+	// LastSyncAttempt is when the last sync ended, failed ones included
+	LastSyncAttempt time.Time
+	ArchivedAt      time.Time
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+}
+
+func (c *Collection) IsArchived() bool {
+	return !c.ArchivedAt.IsZero()
+}
+
+func (c *Collection) IsSyncing() bool {
+	return c.SyncStatus == SyncStatusPending || c.SyncStatus == SyncStatusFetching
+}
+
+// NextSyncFrom is the time the interval to the next scheduled sync counts
+// from, so a failed sync waits as long as a successful one before it is
+// tried again.
+func (c *Collection) NextSyncFrom() time.Time {
+	if c.LastSyncAttempt.After(c.LastSync) {
+		return c.LastSyncAttempt
+	}
+	return c.LastSync
+}
+
+const collectionSearchTermsKey = "search_terms"
+
+// SearchTerms returns the extra terms the crawlers use to find the collection,
+// stored one per line in the metadata.
+func (c *Collection) SearchTerms() []string {
+	if c.Metadata == nil || c.Metadata[collectionSearchTermsKey] == "" {
+		return nil
+	}
+	return strings.Split(c.Metadata[collectionSearchTermsKey], "\n")
+}
+
+func (c *Collection) SetSearchTerms(terms []string) {
+	unique := make([]string, 0, len(terms))
+	seen := make(map[string]bool, len(terms))
+	for _, term := range terms {
+		term = strings.TrimSpace(term)
+		if term == "" || seen[strings.ToLower(term)] {
+			continue
+		}
+		seen[strings.ToLower(term)] = true
+		unique = append(unique, term)
+	}
+	if len(unique) == 0 {
+		delete(c.Metadata, collectionSearchTermsKey)
+		return
+	}
+	if c.Metadata == nil {
+		c.Metadata = make(map[string]string)
+	}
+	c.Metadata[collectionSearchTermsKey] = strings.Join(unique, "\n")
 }
 
 type CollectionBook struct {
@@ -65,6 +128,7 @@ type CreateCollectionRequest struct {
 	Publisher      string
 	Tags           []string
 	Metadata       map[string]string
+	SearchTerms    []string
 	SyncSources    SyncSources
 	CrawlerOptions SyncOptions
 	Language       string
@@ -78,6 +142,57 @@ func (r *CreateCollectionRequest) Validate() error {
 	if len(r.Name) > 255 {
 		e = e.Add("name", ErrCollectionNameTooLong.Error())
 	}
+	if r.TotalVolumes < 0 {
+		e = e.Add("total_volumes", ErrCollectionTotalVolumesInvalid.Error())
+	}
+	for _, term := range r.SearchTerms {
+		if len(term) > 255 {
+			e = e.Add("search_terms", ErrCollectionSearchTermTooLong.Error())
+			break
+		}
+	}
+	if r.CrawlerOptions.AutoSync && len(r.SyncSources) == 0 {
+		e = e.Add("sync_sources", ErrCollectionAutoSyncWithoutSources.Error())
+	}
+	if e.HasError() {
+		return e
+	}
+	return nil
+}
+
+type UpdateCollectionRequest struct {
+	Name           string
+	TotalVolumes   int
+	Edition        string
+	Author         []string
+	Publisher      string
+	Tags           []string
+	SearchTerms    []string
+	SyncSources    SyncSources
+	CrawlerOptions SyncOptions
+	Language       string
+}
+
+func (r *UpdateCollectionRequest) Validate() error {
+	var e RequestError
+	if r.Name == "" {
+		e = e.Add("name", ErrCollectionNameInvalid.Error())
+	}
+	if len(r.Name) > 255 {
+		e = e.Add("name", ErrCollectionNameTooLong.Error())
+	}
+	if r.TotalVolumes < 0 {
+		e = e.Add("total_volumes", ErrCollectionTotalVolumesInvalid.Error())
+	}
+	for _, term := range r.SearchTerms {
+		if len(term) > 255 {
+			e = e.Add("search_terms", ErrCollectionSearchTermTooLong.Error())
+			break
+		}
+	}
+	if r.CrawlerOptions.AutoSync && len(r.SyncSources) == 0 {
+		e = e.Add("sync_sources", ErrCollectionAutoSyncWithoutSources.Error())
+	}
 	if e.HasError() {
 		return e
 	}
@@ -99,33 +214,329 @@ func NewCollection(
 	opts SyncOptions,
 ) *Collection {
 	return &Collection{
-		ID:             NewID(),
-		Name:           name,
-		Edition:        edition,
-		Slug:           slug,
-		UserID:         userID,
-		Author:         author,
-		Publisher:      publisher,
-		Tags:           tags,
-		Metadata:       metadata,
-		ReleaseStatus:  ReleaseStatusOnGoing,
-		SyncStatus:     SyncStatusPending,
-		SyncSources:    syncSources,
-		TotalVolumes:   totalVolumes,
-		CrawlerOptions: opts,
-		Language:       language,
-		LastSync:       time.Now(),
-		CreatedAt:      time.Now(),
-		UpdatedAt:      time.Now(),
+		ID:              NewID(),
+		Name:            name,
+		Edition:         edition,
+		Slug:            slug,
+		UserID:          userID,
+		Author:          author,
+		Publisher:       publisher,
+		Tags:            tags,
+		Metadata:        metadata,
+		ReleaseStatus:   ReleaseStatusOnGoing,
+		SyncStatus:      SyncStatusPending,
+		SyncSources:     syncSources,
+		TotalVolumes:    totalVolumes,
+		CrawlerOptions:  opts,
+		Language:        language,
+		LastSync:        time.Now(),
+		LastSyncAttempt: time.Now(),
+		CreatedAt:       time.Now(),
+		UpdatedAt:       time.Now(),
+	}
+}
+
+type VolumeState string
+
+const (
+	VolumeStateOwned    VolumeState = "owned"
+	VolumeStateOrdered  VolumeState = "ordered"
+	VolumeStateWishlist VolumeState = "wishlist"
+	VolumeStateMissing  VolumeState = "missing"
+	VolumeStateUpcoming VolumeState = "upcoming"
+)
+
+type CollectionVolume struct {
+	Number int
+	State  VolumeState
+	Book   *Book
+}
+
+// Volumes lays out the numbered volumes of the collection, from 1 up to the
+// highest of TotalVolumes and the volumes already known, matching each slot
+// with its book when there is one.
+func (c *Collection) Volumes(books []Book, now time.Time) []CollectionVolume {
+	total := c.TotalVolumes
+	byNumber := make(map[int]*Book)
+	for i := range books {
+		if books[i].Volume == nil || *books[i].Volume <= 0 {
+			continue
+		}
+		number := *books[i].Volume
+		if _, exists := byNumber[number]; !exists {
+			byNumber[number] = &books[i]
+		}
+		if number > total {
+			total = number
+		}
+	}
+	volumes := make([]CollectionVolume, 0, total)
+	for number := 1; number <= total; number++ {
+		volume := CollectionVolume{Number: number, State: VolumeStateMissing}
+		if book, ok := byNumber[number]; ok {
+			volume.Book = book
+			volume.State = volumeState(book, now)
+		}
+		volumes = append(volumes, volume)
+	}
+	return volumes
+}
+
+func volumeState(book *Book, now time.Time) VolumeState {
+	switch book.Ownership {
+	case OwnershipOwned:
+		return VolumeStateOwned
+	case OwnershipOrdered:
+		return VolumeStateOrdered
+	case OwnershipWishlist:
+		return VolumeStateWishlist
+	}
+	if !book.IsReleased(now) {
+		return VolumeStateUpcoming
+	}
+	return VolumeStateMissing
+}
+
+// OwnedProgress counts the owned volumes against the expected size of the
+// collection, which is the highest of TotalVolumes and the volumes laid out.
+func (c *Collection) OwnedProgress(volumes []CollectionVolume) (owned, total int) {
+	total = c.TotalVolumes
+	if len(volumes) > total {
+		total = len(volumes)
+	}
+	for _, v := range volumes {
+		if v.State == VolumeStateOwned {
+			owned++
+		}
+	}
+	return owned, total
+}
+
+// ApplyBooks updates the expected size and release status of the collection
+// from its books: a volume past TotalVolumes raises it, and an unreleased
+// volume after the last released one means the series is still going on.
+// It reports whether anything changed.
+func (c *Collection) ApplyBooks(books []Book, now time.Time) bool {
+	changed := false
+	lastReleased, lastUpcoming := 0, 0
+	for i := range books {
+		if books[i].Volume == nil || *books[i].Volume <= 0 {
+			continue
+		}
+		number := *books[i].Volume
+		if number > c.TotalVolumes {
+			c.TotalVolumes = number
+			changed = true
+		}
+		if books[i].IsReleased(now) {
+			lastReleased = max(lastReleased, number)
+		} else {
+			lastUpcoming = max(lastUpcoming, number)
+		}
+	}
+	if lastUpcoming > lastReleased && c.ReleaseStatus != ReleaseStatusOnGoing {
+		c.ReleaseStatus = ReleaseStatusOnGoing
+		changed = true
+	}
+	return changed
+}
+
+type VolumeChangeKind string
+
+const (
+	VolumeChangeAnnounced   VolumeChangeKind = "new-volume"
+	VolumeChangePresale     VolumeChangeKind = "presale"
+	VolumeChangeReleaseDate VolumeChangeKind = "release-date"
+)
+
+// VolumeChange is what a crawl found out about a volume compared with the
+// book stored for it.
+type VolumeChange struct {
+	Kind               VolumeChangeKind
+	CollectionID       string
+	BookID             string
+	Volume             int
+	Title              string
+	ReleasedAt         time.Time
+	PreviousReleasedAt time.Time
+	Source             string
+	URL                string
+}
+
+// DetectVolumeChange compares a crawled result with the stored book of the
+// same volume, which is nil when the volume is not in the collection yet.
+func DetectVolumeChange(existing *Book, result CrawledResult) (VolumeChange, bool) {
+	change := VolumeChange{
+		Volume:     result.Volume,
+		Title:      result.Title,
+		ReleasedAt: result.ReleaseDate(),
+		Source:     result.Source,
+		URL:        result.URL,
+	}
+	if existing == nil {
+		if result.Volume <= 0 {
+			return change, false
+		}
+		change.Kind = VolumeChangeAnnounced
+		if result.IsPresale() {
+			change.Kind = VolumeChangePresale
+		}
+		return change, true
+	}
+	change.BookID = existing.ID
+	if result.IsPresale() && existing.Metadata["presale"] != "true" {
+		change.Kind = VolumeChangePresale
+		return change, true
+	}
+	// stores disagree on dates, so only the source of the book can move it
+	source := existing.Metadata["source"]
+	if source != "" && source != result.SourceOf("released_at") {
+		return change, false
+	}
+	if !change.ReleasedAt.IsZero() && !change.ReleasedAt.Equal(existing.ReleasedAt) {
+		change.Kind = VolumeChangeReleaseDate
+		change.PreviousReleasedAt = existing.ReleasedAt
+		return change, true
+	}
+	return change, false
+}
+
+type CollectionFilter string
+
+const (
+	CollectionFilterAll        CollectionFilter = ""
+	CollectionFilterManga      CollectionFilter = "manga"
+	CollectionFilterBooks      CollectionFilter = "books"
+	CollectionFilterComplete   CollectionFilter = "complete"
+	CollectionFilterInProgress CollectionFilter = "in-progress"
+	CollectionFilterArchived   CollectionFilter = "archived"
+)
+
+func GetCollectionFilters() []CollectionFilter {
+	return []CollectionFilter{
+		CollectionFilterAll,
+		CollectionFilterManga,
+		CollectionFilterBooks,
+		CollectionFilterComplete,
+		CollectionFilterInProgress,
+		CollectionFilterArchived,
+	}
+}
+
+func IsValidCollectionFilter(filter string) bool {
+	for _, f := range GetCollectionFilters() {
+		if string(f) == filter {
+			return true
+		}
+	}
+	return false
+}
+
+type CollectionSort string
+
+const (
+	CollectionSortRecent   CollectionSort = "recent"
+	CollectionSortTitle    CollectionSort = "title"
+	CollectionSortLastSync CollectionSort = "last-sync"
+)
+
+func GetCollectionSorts() []CollectionSort {
+	return []CollectionSort{
+		CollectionSortRecent,
+		CollectionSortTitle,
+		CollectionSortLastSync,
+	}
+}
+
+func IsValidCollectionSort(sort string) bool {
+	for _, s := range GetCollectionSorts() {
+		if string(s) == sort {
+			return true
+		}
+	}
+	return false
+}
+
+const CollectionsPerPage = 15
+
+type CollectionSummary struct {
+	Collection
+	CoverImage   string
+	VolumesCount int
+	OwnedCount   int
+}
+
+// ExpectedVolumes is the size of the collection used for progress, falling
+// back to the volumes found when TotalVolumes is unknown or outdated.
+func (c CollectionSummary) ExpectedVolumes() int {
+	if c.VolumesCount > c.TotalVolumes {
+		return c.VolumesCount
+	}
+	return c.TotalVolumes
+}
+
+func (c CollectionSummary) Progress() int {
+	total := c.ExpectedVolumes()
+	if total <= 0 {
+		return 0
+	}
+	progress := c.OwnedCount * 100 / total
+	if progress > 100 {
+		return 100
+	}
+	return progress
+}
+
+type CollectionPage struct {
+	Items      []CollectionSummary
+	Filter     CollectionFilter
+	Sort       CollectionSort
+	Page       int
+	TotalPages int
+	Total      int
+}
+
+func NewCollectionPage(items []CollectionSummary, filter CollectionFilter, sort CollectionSort, page, total int) *CollectionPage {
+	totalPages := (total + CollectionsPerPage - 1) / CollectionsPerPage
+	if totalPages == 0 {
+		totalPages = 1
+	}
+	return &CollectionPage{
+		Items:      items,
+		Filter:     filter,
+		Sort:       sort,
+		Page:       page,
+		TotalPages: totalPages,
+		Total:      total,
 	}
 }
 
 type CollectionService interface {
 	CreateCollection(userID string, req CreateCollectionRequest) (*Collection, error)
-	// SyncCollection(collectionID string, opts CrawlerOptions) error
+	FindCollectionBySlug(userID, slug string) (*Collection, error)
+	FindCollectionByID(collectionID string) (*Collection, error)
+	RefreshReleaseInfo(collectionID string, books []Book) (*Collection, error)
+	SyncCollection(userID, slug string, force bool) (*Collection, error)
+	UpdateCollectionSettings(userID, slug string, opts SyncOptions) (*Collection, error)
+	UpdateSyncStatus(collectionID string, status SyncStatus) error
+	RestoreSyncStatus(collectionID string, status SyncStatus) error
+	ListCollections(userID string, filter CollectionFilter, sort CollectionSort, page int) (*CollectionPage, error)
+	UpdateCollection(userID, collectionID string, req UpdateCollectionRequest) (*Collection, error)
+	ArchiveCollection(userID, collectionID string) (*Collection, error)
+	UnarchiveCollection(userID, collectionID string) (*Collection, error)
+	DeleteCollection(userID, collectionID string) error
+	ScheduleSync(collectionID string) (*Collection, error)
+	FindCollectionsDueForSync(before time.Time, limit int) ([]Collection, error)
+	CountSyncingCollections() (int, error)
 }
 
 type CollectionRepository interface {
 	CreateCollection(collection *Collection) error
+	UpdateCollection(collection *Collection) error
+	FindCollectionByID(id string) (*Collection, error)
 	FindCollectionBySlug(userID, slug string) (*Collection, error)
+	ListCollections(userID string, filter CollectionFilter, sort CollectionSort, page int) (*CollectionPage, error)
+	DeleteCollection(id string) error
+	FindCollectionsDueForSync(before time.Time, limit int) ([]Collection, error)
+	CountSyncingCollections() (int, error)
 }
@@ -5,3 +5,21 @@ import "errors"
 var ErrCollectionNameInvalid = errors.New("error.collection.invalid-name")
 
 var ErrCollectionNameTooLong = errors.New("error.collection.name-too-long")
+
+var ErrCollectionNotFound = errors.New("error.collection.not-found")
+
+var ErrCollectionWithoutSources = errors.New("error.collection.without-sources")
+
+var ErrCollectionAlreadySyncing = errors.New("error.collection.already-syncing")
+
+var ErrCollectionTotalVolumesInvalid = errors.New("error.collection.invalid-total-volumes")
+
+var ErrCollectionForbidden = errors.New("error.collection.forbidden")
+
+var ErrCollectionArchived = errors.New("error.collection.archived")
+
+var ErrCollectionSearchTermTooLong = errors.New("error.collection.search-term-too-long")
+
+var ErrCollectionAutoSyncWithoutSources = errors.New("error.collection.auto-sync-without-sources")
+
+var ErrCollectionSyncSourceInvalid = errors.New("error.collection.invalid-sync-source")
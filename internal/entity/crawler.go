@@ -2,6 +2,7 @@ package entity
 
 import (
 	"context"
+	"net/http"
 	"regexp"
 	"strconv"
 	"strings"
@@ -13,6 +14,9 @@ const (
 	EventCrawlerCompleted   EventType = "crawler:completed"
 	EventCrawlerFailed      EventType = "crawler:failed"
 	EventCrawlerItemFounded EventType = "crawler:item-founded"
+	// EventCrawlerCandidateAccepted carries a crawled volume the user
+	// accepted from the review queue into the collection.
+	EventCrawlerCandidateAccepted EventType = "crawler:candidate-accepted"
 )
 
 type CrawledReview struct {
@@ -40,6 +44,62 @@ type CrawledResult struct {
 	ReleasedAt  string            `json:"released_at"`
 	Metadata    map[string]string `json:"metadata"`
 	Language    string            `json:"language"`
+	// Offers lists the store listings of a volume merged across sources,
+	// and Provenance the source each field was taken from.
+	Offers     []CrawledOffer    `json:"offers,omitempty"`
+	Provenance map[string]string `json:"provenance,omitempty"`
+}
+
+// CrawledOffer is the listing of a merged volume in one store.
+type CrawledOffer struct {
+	Source  string          `json:"source"`
+	URL     string          `json:"url"`
+	Price   float64         `json:"price"`
+	Stock   string          `json:"stock"`
+	Presale bool            `json:"presale"`
+	Reviews []CrawledReview `json:"reviews"`
+}
+
+// ReleaseDate parses the release date reported by the provider, which is
+// zero when it is missing or malformed.
+func (r CrawledResult) ReleaseDate() time.Time {
+	if r.ReleasedAt == "" {
+		return time.Time{}
+	}
+	releasedAt, _ := time.Parse("2006-01-02", r.ReleasedAt)
+	return releasedAt
+}
+
+func (r CrawledResult) IsPresale() bool {
+	return r.Metadata["presale"] == "true"
+}
+
+// SourceOf tells which source the field, named as in JSON, was taken from.
+func (r CrawledResult) SourceOf(field string) string {
+	if source, ok := r.Provenance[field]; ok {
+		return source
+	}
+	return r.Source
+}
+
+// Listings splits a merged result into one result per store offer, each
+// with the price, URL and reviews of that store. Results that weren't
+// merged are their own listing.
+func (r CrawledResult) Listings() []CrawledResult {
+	if len(r.Offers) == 0 {
+		return []CrawledResult{r}
+	}
+	listings := make([]CrawledResult, 0, len(r.Offers))
+	for _, offer := range r.Offers {
+		listing := r
+		listing.Source = offer.Source
+		listing.URL = offer.URL
+		listing.Price = offer.Price
+		listing.Reviews = offer.Reviews
+		listing.Offers = nil
+		listings = append(listings, listing)
+	}
+	return listings
 }
 
 type CrawlerOptions struct {
@@ -47,7 +107,60 @@ type CrawlerOptions struct {
 	Timeout         time.Duration
 	MaxConcurrency  int
 	RequestInterval time.Duration
+	ImportReviews   bool
 	SiteOptions     map[string]map[string]any
+	// ForceRefresh fetches every page from the store again, skipping the
+	// page cache.
+	ForceRefresh bool
+	// Logger receives the events of the provider, tagged with the crawl run
+	// it belongs to. It is not persisted with the crawl run.
+	Logger Logger `json:"-"`
+	// Transport replaces the HTTP transport of the providers, e.g. to
+	// replay recorded pages. It is not persisted with the crawl run.
+	Transport http.RoundTripper `json:"-"`
+}
+
+// CrawlPolicy limits how hard the crawlers hit the stores. It applies to all
+// runs together, so collections syncing at once share the same limits.
+type CrawlPolicy struct {
+	// DomainInterval is the least time between two requests to a domain.
+	DomainInterval time.Duration
+	// RespectRobotsTxt skips the pages robots.txt disallows.
+	RespectRobotsTxt bool
+	// DailyBudget caps the requests made for each source per day, 0 for no
+	// cap.
+	DailyBudget int
+	// MaxBackoff caps the pause of a domain that answered 429 or 503 or
+	// showed a CAPTCHA.
+	MaxBackoff time.Duration
+	// SearchPageTTL and ProductPageTTL keep the pages fetched in the page
+	// cache before they are revalidated with the store. Search results
+	// change more often than the pages of a product. 0 disables the cache.
+	SearchPageTTL  time.Duration
+	ProductPageTTL time.Duration
+}
+
+// CachedPage is a page the crawlers fetched, kept to answer the same request
+// again until it expires and to revalidate it with ETag and Last-Modified.
+type CachedPage struct {
+	URL          string
+	Status       int
+	Header       http.Header
+	Body         []byte
+	ETag         string
+	LastModified string
+	FetchedAt    time.Time
+	ExpiresAt    time.Time
+}
+
+func (p *CachedPage) IsFresh(now time.Time) bool {
+	return now.Before(p.ExpiresAt)
+}
+
+type PageCacheRepository interface {
+	FindPage(url string) (*CachedPage, error)
+	SavePage(page *CachedPage) error
+	DeletePagesFetchedBefore(before time.Time) (int64, error)
 }
 
 type SiteProvider interface {
@@ -56,17 +169,216 @@ type SiteProvider interface {
 	Fetch(ctx context.Context, searchTerms []string) ([]CrawledResult, error)
 }
 
+// SiteProviderFactory builds a provider for a single crawl. Providers keep
+// the state of the crawl they run, so concurrent crawls never share one.
+type SiteProviderFactory func() SiteProvider
+
 type CrawlerRequest struct {
+	UserID       string
 	CollectionID string
 	SearchTerms  []string
 	Sites        []string
 	Opts         CrawlerOptions
 }
 
+type CrawlRunStatus string
+
+const (
+	CrawlRunQueued    CrawlRunStatus = "queued"
+	CrawlRunRunning   CrawlRunStatus = "running"
+	CrawlRunCompleted CrawlRunStatus = "completed"
+	CrawlRunFailed    CrawlRunStatus = "failed"
+	CrawlRunCancelled CrawlRunStatus = "cancelled"
+)
+
+// SyncStatus maps the state of a run to the status shown on its collection.
+func (s CrawlRunStatus) SyncStatus() SyncStatus {
+	switch s {
+	case CrawlRunQueued:
+		return SyncStatusPending
+	case CrawlRunRunning:
+		return SyncStatusFetching
+	case CrawlRunCompleted:
+		return SyncStatusSynced
+	default:
+		return SyncStatusFailed
+	}
+}
+
+func (s CrawlRunStatus) IsActive() bool {
+	return s == CrawlRunQueued || s == CrawlRunRunning
+}
+
+type CrawlRunProviderStats struct {
+	Results int    `json:"results"`
+	Errors  int    `json:"errors"`
+	Error   string `json:"error,omitempty"`
+}
+
+// CrawlRun is the stored record of a crawler request, from the moment it is
+// queued until it finishes, so runs survive restarts.
+type CrawlRun struct {
+	ID           string
+	CollectionID string
+	UserID       string
+	SearchTerms  []string
+	Sites        []string
+	Options      CrawlerOptions
+	Status       CrawlRunStatus
+	Providers    map[string]CrawlRunProviderStats
+	ResultCount  int
+	Error        string
+	Attempts     int
+	QueuedAt     time.Time
+	StartedAt    time.Time
+	FinishedAt   time.Time
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+func NewCrawlRun(req CrawlerRequest) *CrawlRun {
+	now := time.Now()
+	return &CrawlRun{
+		ID:           NewID(),
+		CollectionID: req.CollectionID,
+		UserID:       req.UserID,
+		SearchTerms:  req.SearchTerms,
+		Sites:        req.Sites,
+		Options:      req.Opts,
+		Status:       CrawlRunQueued,
+		Providers:    make(map[string]CrawlRunProviderStats),
+		QueuedAt:     now,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+}
+
+func (r *CrawlRun) Request() CrawlerRequest {
+	return CrawlerRequest{
+		UserID:       r.UserID,
+		CollectionID: r.CollectionID,
+		SearchTerms:  r.SearchTerms,
+		Sites:        r.Sites,
+		Opts:         r.Options,
+	}
+}
+
+func (r *CrawlRun) Duration() time.Duration {
+	if r.StartedAt.IsZero() || r.FinishedAt.IsZero() {
+		return 0
+	}
+	return r.FinishedAt.Sub(r.StartedAt)
+}
+
+type CrawlLogLevel string
+
+const (
+	CrawlLogDebug CrawlLogLevel = "debug"
+	CrawlLogInfo  CrawlLogLevel = "info"
+	CrawlLogWarn  CrawlLogLevel = "warn"
+	CrawlLogError CrawlLogLevel = "error"
+)
+
+// CrawlLogEntry is an event logged by a crawl run or its providers, kept so
+// the run can be looked into from its collection.
+type CrawlLogEntry struct {
+	ID           string
+	RunID        string
+	CollectionID string
+	Site         string
+	Level        CrawlLogLevel
+	Message      string
+	Error        string
+	Fields       map[string]any
+	CreatedAt    time.Time
+}
+
+// CrawlDebugRequest asks for a fetch of one provider for ad-hoc terms,
+// outside of any collection, to look into what it visits and extracts.
+type CrawlDebugRequest struct {
+	Site         string
+	SearchTerms  []string
+	MaxPages     int
+	ForceRefresh bool
+}
+
+type CrawlDebugVisit struct {
+	URL      string
+	Status   int
+	Duration time.Duration
+	Error    string
+}
+
+type CrawlMergeReason string
+
+const (
+	// CrawlMergeNew starts a volume of its own
+	CrawlMergeNew CrawlMergeReason = "new"
+	// CrawlMergeISBN joins the volume of an earlier result with its ISBN
+	CrawlMergeISBN CrawlMergeReason = "isbn"
+	// CrawlMergeSeries joins the volume of an earlier result with the same
+	// series and volume number
+	CrawlMergeSeries CrawlMergeReason = "series"
+)
+
+// CrawlDebugResult is a result of the provider with how the crawler reads it
+// and how it is merged with the others.
+type CrawlDebugResult struct {
+	Result CrawledResult
+	// ExtractedVolume is the volume number read from the title
+	ExtractedVolume int
+	// SeriesKey is the series and volume key results are merged by
+	SeriesKey string
+	// Merged is the index of the volume the result is merged into
+	Merged      int
+	MergeReason CrawlMergeReason
+}
+
+type CrawlDebugReport struct {
+	Site        string
+	SearchTerms []string
+	Visits      []CrawlDebugVisit
+	Log         []CrawlLogEntry
+	Results     []CrawlDebugResult
+	// Volumes are the records the results are merged into, as a sync would
+	// publish them
+	Volumes  []CrawledResult
+	Error    string
+	Duration time.Duration
+}
+
 type CrawlerService interface {
+	Providers() []string
 	FetchCollection(ctx context.Context, req CrawlerRequest) error
 	GetStatus(collectionID string) (SyncStatus, error)
 	CancelFetch(collectionID string) error
+	RetryFetch(ctx context.Context, collectionID string) error
+	// LatestRun returns the last run of the collection, or ErrNotFound.
+	LatestRun(collectionID string) (*CrawlRun, error)
+	ListRuns(collectionID string, limit int) ([]CrawlRun, error)
+	// RunLog returns the log of a run of the collection, oldest first.
+	RunLog(collectionID, runID string) ([]CrawlLogEntry, error)
+	// DebugFetch runs the provider of a site for a CrawlDebugRequest and
+	// reports what it did. A fetch failing partway still returns a report.
+	DebugFetch(ctx context.Context, req CrawlDebugRequest) (*CrawlDebugReport, error)
+	Start() error
+	Shutdown() error
+}
+
+type CrawlRunRepository interface {
+	CreateCrawlRun(run *CrawlRun) error
+	UpdateCrawlRun(run *CrawlRun) error
+	// ClaimNextCrawlRun moves the oldest queued run to running and returns it,
+	// or ErrNotFound when the queue is empty.
+	ClaimNextCrawlRun(now time.Time) (*CrawlRun, error)
+	// CancelQueuedCrawlRun stores the cancelled run only while it is still
+	// queued, and returns ErrNotFound once a worker has claimed it.
+	CancelQueuedCrawlRun(run *CrawlRun) error
+	FindLatestCrawlRun(collectionID string) (*CrawlRun, error)
+	FindCrawlRunsByStatus(status CrawlRunStatus) ([]CrawlRun, error)
+	ListCrawlRuns(collectionID string, limit int) ([]CrawlRun, error)
+	AddCrawlLogEntry(entry *CrawlLogEntry) error
+	ListCrawlLogEntries(collectionID, runID string, limit int) ([]CrawlLogEntry, error)
 }
 
 type CrawlerConsumer interface {
@@ -7,3 +7,23 @@ var ErrCrawlerAlreadyRunning = errors.New("crawler is already running")
 var ErrCrawlerNotRunning = errors.New("crawler is not running")
 
 var ErrCrawlerCannotBeCancelled = errors.New("crawler cannot be cancelled")
+
+var ErrCrawlerNotRetryable = errors.New("only failed or cancelled crawl runs can be retried")
+
+var ErrCrawlRunInterrupted = errors.New("crawl run interrupted by shutdown")
+
+var ErrCrawlRunCancelled = errors.New("crawl run cancelled by user")
+
+var ErrCrawlRunTimeout = errors.New("crawl run timed out")
+
+var ErrCrawlerProviderNotFound = errors.New("crawler provider not found")
+
+var ErrCrawlerProviderExists = errors.New("crawler provider already registered")
+
+var ErrCrawlerNoSearchTerms = errors.New("no search terms to crawl")
+
+var ErrCrawlerBlocked = errors.New("store is rate limiting or asking for a CAPTCHA")
+
+var ErrCrawlerBudgetExceeded = errors.New("daily request budget of the source exceeded")
+
+var ErrCrawlerDisallowed = errors.New("page disallowed by robots.txt")
@@ -14,6 +14,8 @@ const (
 	EventCollectionSyncFetching  EventType = "collection:sync-fetching"
 	EventCollectionSyncCompleted EventType = "collection:sync-completed"
 	EventCollectionSyncFailed    EventType = "collection:sync-failed"
+	EventCollectionPriceDropped  EventType = "collection:price-dropped"
+	EventCollectionNewVolume     EventType = "collection:new-volume"
 	EventSystemStarted           EventType = "system:started"
 	EventSystemShutdown          EventType = "system:shutdown"
 	EventSystemError             EventType = "system:error"
@@ -0,0 +1,159 @@
+package entity
+
+import (
+	"regexp"
+	"strings"
+)
+
+// ISBNs are stored as ISBN-13 without separators. Stores print them with
+// hyphens or spaces, in either form, and Amazon uses its own ASIN for
+// listings that have no ISBN-10.
+
+// isbnLabel matches the label stores put before an ISBN: "ISBN", "ISBN-13",
+// "ISBN13:" or "ISBN 10 ". A spaced 10 or 13 is a label only when a colon or
+// a space follows, as no ISBN-10 starts with those groups.
+var isbnLabel = regexp.MustCompile(`^ISBN(-?1[03]|\s+1[03](\s*:|\s))?`)
+
+// NormalizeISBN removes the separators and labels around an ISBN, e.g.
+// "ISBN 978-65-5512-100-1" becomes "9786555121001". It doesn't validate it.
+func NormalizeISBN(isbn string) string {
+	isbn = strings.ToUpper(strings.TrimSpace(isbn))
+	isbn = isbnLabel.ReplaceAllString(isbn, "")
+	var b strings.Builder
+	for _, r := range isbn {
+		switch {
+		case r >= '0' && r <= '9', r >= 'A' && r <= 'Z':
+			b.WriteRune(r)
+		case r == '-', r == ' ', r == ':', r == '\u00a0', r == '\u200e', r == '\n', r == '\t':
+		default:
+			// anything else isn't part of an identifier
+			return ""
+		}
+	}
+	return b.String()
+}
+
+// IsValidISBN10 reports whether the normalized ISBN is an ISBN-10 with a
+// valid check digit.
+func IsValidISBN10(isbn string) bool {
+	if len(isbn) != 10 {
+		return false
+	}
+	sum := 0
+	for i := 0; i < 10; i++ {
+		digit := int(isbn[i] - '0')
+		switch {
+		case isbn[i] == 'X' && i == 9:
+			digit = 10
+		case isbn[i] < '0' || isbn[i] > '9':
+			return false
+		}
+		sum += digit * (10 - i)
+	}
+	return sum%11 == 0
+}
+
+// IsValidISBN13 reports whether the normalized ISBN is an ISBN-13 with a
+// valid check digit. Only the 978 and 979 prefixes are books.
+func IsValidISBN13(isbn string) bool {
+	if len(isbn) != 13 || !isDigits(isbn) {
+		return false
+	}
+	if !strings.HasPrefix(isbn, "978") && !strings.HasPrefix(isbn, "979") {
+		return false
+	}
+	return isbn13CheckDigit(isbn[:12]) == isbn[12]
+}
+
+// IsASIN reports whether the identifier is an Amazon ASIN that isn't also an
+// ISBN-10, as Amazon uses the ISBN-10 as the ASIN of books that have one.
+func IsASIN(id string) bool {
+	id = strings.ToUpper(strings.TrimSpace(id))
+	if len(id) != 10 || IsValidISBN10(id) {
+		return false
+	}
+	for _, r := range id {
+		if (r < '0' || r > '9') && (r < 'A' || r > 'Z') {
+			return false
+		}
+	}
+	return true
+}
+
+// ISBN10To13 converts a valid ISBN-10 to its ISBN-13.
+func ISBN10To13(isbn string) (string, bool) {
+	if !IsValidISBN10(isbn) {
+		return "", false
+	}
+	prefixed := "978" + isbn[:9]
+	return prefixed + string(isbn13CheckDigit(prefixed)), true
+}
+
+// ISBN13To10 converts a valid ISBN-13 to its ISBN-10, which only exists for
+// the 978 prefix.
+func ISBN13To10(isbn string) (string, bool) {
+	if !IsValidISBN13(isbn) || !strings.HasPrefix(isbn, "978") {
+		return "", false
+	}
+	digits := isbn[3:12]
+	sum := 0
+	for i := 0; i < 9; i++ {
+		sum += int(digits[i]-'0') * (10 - i)
+	}
+	check := (11 - sum%11) % 11
+	if check == 10 {
+		return digits + "X", true
+	}
+	return digits + string(byte('0'+check)), true
+}
+
+// ParseISBN normalizes an ISBN-10 or ISBN-13 and returns it as ISBN-13.
+// ASINs and numbers with a wrong check digit are ErrBookISBNInvalid.
+func ParseISBN(isbn string) (string, error) {
+	isbn = NormalizeISBN(isbn)
+	if IsValidISBN13(isbn) {
+		return isbn, nil
+	}
+	if isbn13, ok := ISBN10To13(isbn); ok {
+		return isbn13, nil
+	}
+	return "", ErrBookISBNInvalid
+}
+
+// CanonicalISBN is ParseISBN for the places where an invalid ISBN is just
+// left out, such as crawled results. It returns "" when there's none.
+func CanonicalISBN(isbn string) string {
+	isbn, err := ParseISBN(isbn)
+	if err != nil {
+		return ""
+	}
+	return isbn
+}
+
+// SameISBN reports whether both identify the same book, whatever form each
+// was written in. Invalid ISBNs never match.
+func SameISBN(a, b string) bool {
+	a = CanonicalISBN(a)
+	return a != "" && a == CanonicalISBN(b)
+}
+
+func isbn13CheckDigit(digits string) byte {
+	sum := 0
+	for i := 0; i < 12; i++ {
+		weight := 1
+		if i%2 == 1 {
+			weight = 3
+		}
+		sum += int(digits[i]-'0') * weight
+	}
+	return byte('0' + (10-sum%10)%10)
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
@@ -0,0 +1,131 @@
+package entity
+
+import "testing"
+
+func TestNormalizeISBN(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"9786555123456", "9786555123456"},
+		{"978-65-5512-345-6", "9786555123456"},
+		{"978 65 5512 345 6", "9786555123456"},
+		{" 65-5512-346-x ", "655512346X"},
+		{"ISBN 978-65-5512-345-6", "9786555123456"},
+		{"ISBN-13: 978-65-5512-345-6", "9786555123456"},
+		{"ISBN13: 9786555123456", "9786555123456"},
+		{"ISBN13 9786555123456", "9786555123456"},
+		{"ISBN 13: 9786555123456", "9786555123456"},
+		{"ISBN-10: 6555123451", "6555123451"},
+		{"ISBN10 6555123451", "6555123451"},
+		{"ISBN 10 6555123451", "6555123451"},
+		{"isbn 6555123451", "6555123451"},
+		{"ISBN 1-4028-9462-7", "1402894627"},
+		{"\u200e978-65-5512-345-6", "9786555123456"},
+		{"978/65/5512", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := NormalizeISBN(tt.in); got != tt.want {
+			t.Errorf("NormalizeISBN(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseISBN(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+		err  error
+	}{
+		{"ISBN-13", "9786555123456", "9786555123456", nil},
+		{"hyphenated ISBN-13", "978-65-5512-345-6", "9786555123456", nil},
+		{"spaced ISBN-13", "978 65 5512 345 6", "9786555123456", nil},
+		{"labeled ISBN-13", "ISBN13: 978-65-5512-345-6", "9786555123456", nil},
+		{"979 prefix", "979-10-00-01234-6", "9791000012346", nil},
+		{"other 979 prefix", "9798655512108", "9798655512108", nil},
+		{"ISBN-10", "6555123451", "9786555123456", nil},
+		{"hyphenated ISBN-10", "65-5512-345-1", "9786555123456", nil},
+		{"ISBN-10 with X check digit", "655512346X", "9786555123463", nil},
+		{"ISBN-10 with lowercase x check digit", "65-5512-346-x", "9786555123463", nil},
+		{"wrong ISBN-13 check digit", "9786555123457", "", ErrBookISBNInvalid},
+		{"wrong ISBN-10 check digit", "6555123452", "", ErrBookISBNInvalid},
+		{"X out of place", "65551234X6", "", ErrBookISBNInvalid},
+		{"prefix other than 978 and 979", "9776555123457", "", ErrBookISBNInvalid},
+		{"ASIN", "B0CXYZ1234", "", ErrBookISBNInvalid},
+		{"too short", "978655512", "", ErrBookISBNInvalid},
+		{"empty", "", "", ErrBookISBNInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseISBN(tt.in)
+			if got != tt.want || err != tt.err {
+				t.Errorf("ParseISBN(%q) = %q, %v, want %q, %v", tt.in, got, err, tt.want, tt.err)
+			}
+		})
+	}
+}
+
+func TestISBN13To10(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+		ok   bool
+	}{
+		{"9786555123456", "6555123451", true},
+		{"9786555123463", "655512346X", true},
+		{"9791000012346", "", false},
+		{"9786555123457", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := ISBN13To10(tt.in)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("ISBN13To10(%q) = %q, %v, want %q, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+		if !ok {
+			continue
+		}
+		if back, _ := ISBN10To13(got); back != tt.in {
+			t.Errorf("ISBN10To13(%q) = %q, want %q", got, back, tt.in)
+		}
+	}
+}
+
+func TestIsASIN(t *testing.T) {
+	tests := []struct {
+		in   string
+		want bool
+	}{
+		{"B0CXYZ1234", true},
+		{" b0cxyz1234 ", true},
+		{"6555123451", false},
+		{"655512346X", false},
+		{"B0CXYZ123", false},
+		{"B0CXYZ-123", false},
+		{"9786555123456", false},
+	}
+	for _, tt := range tests {
+		if got := IsASIN(tt.in); got != tt.want {
+			t.Errorf("IsASIN(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestSameISBN(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"978-65-5512-345-6", "6555123451", true},
+		{"ISBN-10: 655512346X", "9786555123463", true},
+		{"9786555123456", "9786555123463", false},
+		{"B0CXYZ1234", "B0CXYZ1234", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		if got := SameISBN(tt.a, tt.b); got != tt.want {
+			t.Errorf("SameISBN(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package entity
+
+import "time"
+
+type NotificationType string
+
+const (
+	NotificationNewVolume    NotificationType = "new-volume"
+	NotificationPresale      NotificationType = "presale"
+	NotificationReleaseDate  NotificationType = "release-date"
+	NotificationPriceDropped NotificationType = "price-dropped"
+)
+
+const NotificationsPerPage = 50
+
+// Notification is an inbox entry. Data keeps what the message needs to be
+// rendered, already formatted, so it does not depend on rows that may change.
+type Notification struct {
+	ID           string
+	UserID       string
+	CollectionID string
+	Type         NotificationType
+	Data         map[string]string
+	ReadAt       time.Time
+	CreatedAt    time.Time
+}
+
+func NewNotification(userID, collectionID string, notificationType NotificationType, data map[string]string) *Notification {
+	return &Notification{
+		ID:           NewID(),
+		UserID:       userID,
+		CollectionID: collectionID,
+		Type:         notificationType,
+		Data:         data,
+		CreatedAt:    time.Now(),
+	}
+}
+
+func (n *Notification) IsRead() bool {
+	return !n.ReadAt.IsZero()
+}
+
+// Link is the page the notification is about.
+func (n *Notification) Link() string {
+	if slug := n.Data["slug"]; slug != "" {
+		return "/collection/" + slug
+	}
+	return "/notifications"
+}
+
+type NotificationService interface {
+	Notify(notification *Notification) error
+	ListNotifications(userID string) ([]Notification, error)
+	CountUnread(userID string) (int, error)
+	MarkRead(userID, notificationID string) (*Notification, error)
+	MarkAllRead(userID string) error
+}
+
+type NotificationRepository interface {
+	CreateNotification(notification *Notification) error
+	FindNotificationByID(id string) (*Notification, error)
+	ListNotifications(userID string, limit int) ([]Notification, error)
+	CountUnread(userID string) (int, error)
+	MarkRead(id string, readAt time.Time) error
+	MarkAllRead(userID string, readAt time.Time) error
+}
+
+type NotificationConsumer interface {
+	ConsumeEvents()
+	Shutdown() error
+}
@@ -0,0 +1,7 @@
+package entity
+
+import "errors"
+
+var ErrNotificationNotFound = errors.New("error.notification.not-found")
+
+var ErrNotificationForbidden = errors.New("error.notification.forbidden")
@@ -0,0 +1,126 @@
+package entity
+
+import (
+	"sort"
+	"time"
+)
+
+// PriceObservation is a price seen for a book at one store listing, keyed by
+// the book, the source that crawled it and the URL of the listing.
+type PriceObservation struct {
+	ID           string
+	BookID       string
+	CollectionID string
+	UserID       string
+	Source       string
+	URL          string
+	Price        float64
+	ObservedAt   time.Time
+	CreatedAt    time.Time
+}
+
+func NewPriceObservation(userID, collectionID, bookID, source, url string, price float64, observedAt time.Time) *PriceObservation {
+	return &PriceObservation{
+		ID:           NewID(),
+		BookID:       bookID,
+		CollectionID: collectionID,
+		UserID:       userID,
+		Source:       source,
+		URL:          url,
+		Price:        price,
+		ObservedAt:   observedAt,
+		CreatedAt:    time.Now(),
+	}
+}
+
+// StorePrice compares the latest price of a store listing with the lowest
+// one it has ever had.
+type StorePrice struct {
+	Source     string
+	URL        string
+	Current    float64
+	Lowest     float64
+	ObservedAt time.Time
+	LowestAt   time.Time
+}
+
+type PriceHistory struct {
+	BookID       string
+	Observations []PriceObservation
+	Stores       []StorePrice
+}
+
+// NewPriceHistory sorts the observations by time and summarizes them by
+// store listing, cheapest current price first.
+func NewPriceHistory(bookID string, observations []PriceObservation) *PriceHistory {
+	sort.SliceStable(observations, func(i, j int) bool {
+		return observations[i].ObservedAt.Before(observations[j].ObservedAt)
+	})
+	stores := make(map[string]*StorePrice)
+	keys := make([]string, 0)
+	for _, o := range observations {
+		key := o.Source + "\n" + o.URL
+		store, ok := stores[key]
+		if !ok {
+			store = &StorePrice{Source: o.Source, URL: o.URL, Lowest: o.Price, LowestAt: o.ObservedAt}
+			stores[key] = store
+			keys = append(keys, key)
+		}
+		store.Current = o.Price
+		store.ObservedAt = o.ObservedAt
+		if o.Price < store.Lowest {
+			store.Lowest = o.Price
+			store.LowestAt = o.ObservedAt
+		}
+	}
+	history := &PriceHistory{
+		BookID:       bookID,
+		Observations: observations,
+		Stores:       make([]StorePrice, 0, len(keys)),
+	}
+	for _, key := range keys {
+		history.Stores = append(history.Stores, *stores[key])
+	}
+	sort.SliceStable(history.Stores, func(i, j int) bool {
+		return history.Stores[i].Current < history.Stores[j].Current
+	})
+	return history
+}
+
+func (h *PriceHistory) IsEmpty() bool {
+	return len(h.Observations) == 0
+}
+
+// Current returns the cheapest price the book has right now across stores.
+func (h *PriceHistory) Current() (StorePrice, bool) {
+	if len(h.Stores) == 0 {
+		return StorePrice{}, false
+	}
+	return h.Stores[0], true
+}
+
+// Lowest returns the cheapest observation ever recorded for the book.
+func (h *PriceHistory) Lowest() (PriceObservation, bool) {
+	if len(h.Observations) == 0 {
+		return PriceObservation{}, false
+	}
+	lowest := h.Observations[0]
+	for _, o := range h.Observations[1:] {
+		if o.Price < lowest.Price {
+			lowest = o
+		}
+	}
+	return lowest, true
+}
+
+type PriceService interface {
+	RecordPrice(collection *Collection, book *Book, result CrawledResult) error
+	FindBookPriceHistory(bookID string) (*PriceHistory, error)
+}
+
+type PriceRepository interface {
+	CreatePriceObservation(observation *PriceObservation) error
+	FindLatestPriceObservation(bookID, source, url string) (*PriceObservation, error)
+	FindLowestPrice(bookID string) (float64, error)
+	FindBookPriceObservations(bookID string) ([]PriceObservation, error)
+}
@@ -0,0 +1,93 @@
+package entity
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"sort"
+	"strings"
+	"time"
+)
+
+func NewContentReview(bookID, source string, review CrawledReview) *ContentReview {
+	now := time.Now()
+	date, _ := time.Parse("2006-01-02", review.Date)
+	return &ContentReview{
+		ID:        NewID(),
+		VolumeID:  bookID,
+		Source:    source,
+		Author:    strings.TrimSpace(review.Author),
+		Title:     strings.TrimSpace(review.Title),
+		Content:   strings.TrimSpace(review.Content),
+		Rating:    review.Rating,
+		Date:      date,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+// Fingerprint identifies the review within its book, so the same review
+// found again by a later sync is not stored twice.
+func (r *ContentReview) Fingerprint() string {
+	h := sha1.New()
+	for _, part := range []string{r.Source, r.Author, r.Title, r.Date.Format("2006-01-02"), r.Content} {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+type ReviewSourceSummary struct {
+	Source  string
+	Count   int
+	Rated   int
+	Average float64
+}
+
+type BookReviews struct {
+	Reviews []ContentReview
+	Sources []ReviewSourceSummary
+}
+
+// NewBookReviews sorts the reviews newest first and averages the ratings
+// of each source, ignoring reviews without a rating.
+func NewBookReviews(reviews []ContentReview) *BookReviews {
+	sort.SliceStable(reviews, func(i, j int) bool {
+		return reviews[i].Date.After(reviews[j].Date)
+	})
+	summaries := make(map[string]*ReviewSourceSummary)
+	for _, r := range reviews {
+		summary, ok := summaries[r.Source]
+		if !ok {
+			summary = &ReviewSourceSummary{Source: r.Source}
+			summaries[r.Source] = summary
+		}
+		summary.Count++
+		if r.Rating > 0 {
+			summary.Average += r.Rating
+			summary.Rated++
+		}
+	}
+	sources := make([]ReviewSourceSummary, 0, len(summaries))
+	for _, summary := range summaries {
+		if summary.Rated > 0 {
+			summary.Average /= float64(summary.Rated)
+		}
+		sources = append(sources, *summary)
+	}
+	sort.Slice(sources, func(i, j int) bool {
+		return sources[i].Source < sources[j].Source
+	})
+	return &BookReviews{Reviews: reviews, Sources: sources}
+}
+
+type ReviewService interface {
+	SaveReviews(book *Book, result CrawledResult) (int, error)
+	FindBookReviews(bookID string) (*BookReviews, error)
+}
+
+type ReviewRepository interface {
+	// CreateReviews stores the reviews that are not stored yet and returns
+	// how many were new.
+	CreateReviews(reviews []*ContentReview) (int, error)
+	FindBookReviews(bookID string) ([]ContentReview, error)
+}
@@ -0,0 +1,33 @@
+package entity
+
+import (
+	"hash/fnv"
+	"time"
+)
+
+type SchedulerOptions struct {
+	// Interval is how long after its last sync a collection is synced again.
+	Interval time.Duration
+	// Jitter spreads collections synced together over this extra window.
+	Jitter time.Duration
+	// MaxConcurrent caps the collections syncing at once, across all users.
+	MaxConcurrent int
+	// CheckInterval is how often the scheduler looks for due collections.
+	CheckInterval time.Duration
+}
+
+// SyncJitter returns a stable offset in [0, jitter) for the collection, so
+// each one keeps its place in the window between scheduler checks.
+func SyncJitter(collectionID string, jitter time.Duration) time.Duration {
+	if jitter <= 0 {
+		return 0
+	}
+	h := fnv.New64a()
+	h.Write([]byte(collectionID))
+	return time.Duration(h.Sum64() % uint64(jitter))
+}
+
+type SyncScheduler interface {
+	Start() error
+	Shutdown() error
+}
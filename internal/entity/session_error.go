@@ -8,4 +8,6 @@ var ErrSessionExpired = errors.New("session expired")
 
 var ErrUserUnauthorized = errors.New("error.user.unauthorized")
 
+var ErrUserForbidden = errors.New("error.user.forbidden")
+
 var ErrInvalidSession = errors.New("invalid session")
@@ -3,12 +3,15 @@ package entity
 import "time"
 
 type User struct {
-	ID        string    `json:"id"`
-	Name      string    `json:"name"`
-	Avatar    string    `json:"avatar"`
-	Email     string    `json:"email"`
-	Password  string    `json:"password"`
-	Verified  bool      `json:"verified"`
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Avatar   string `json:"avatar"`
+	Email    string `json:"email"`
+	Password string `json:"password"`
+	Verified bool   `json:"verified"`
+	// Admin grants the admin pages. It is only set from the command line, as
+	// signing up doesn't prove the ownership of the email.
+	Admin     bool      `json:"admin"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdateAt  time.Time `json:"update_at"`
 }
@@ -38,10 +41,12 @@ type UserService interface {
 	CreateUser(name, email, password string) (*User, error)
 	FindUserByID(id string) (*User, error)
 	FindUserByEmail(email string) (*User, error)
+	SetAdmin(email string, admin bool) (*User, error)
 }
 
 type UserRepository interface {
 	CreateUser(user *User) error
 	FindUserByID(id string) (*User, error)
 	FindUserByEmail(email string) (*User, error)
+	UpdateUserAdmin(id string, admin bool) error
 }
@@ -3,9 +3,10 @@ package book
 import (
 	"akira/internal/entity"
 	"context"
+	"database/sql"
 )
 
-func Make(ctx context.Context, logger entity.Logger) entity.BookService {
-	repo := NewMemoRepository()
+func Make(ctx context.Context, db *sql.DB, logger entity.Logger) entity.BookService {
+	repo := NewBookSqliteRepository(db)
 	return NewService(ctx, repo, logger)
 }
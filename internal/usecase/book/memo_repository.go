@@ -1,33 +1,119 @@
 package book
 
-import "akira/internal/entity"
+import (
+	"akira/internal/entity"
+	"slices"
+	"sync"
+)
 
 var _ entity.BookRepository = (*MemoRepository)(nil)
 
 type MemoRepository struct {
-	books map[string]*entity.Book
+	mu              sync.RWMutex
+	books           map[string]*entity.Book
+	collectionBooks map[string][]string
 }
 
 func NewMemoRepository() *MemoRepository {
 	return &MemoRepository{
-		books: make(map[string]*entity.Book),
+		books:           make(map[string]*entity.Book),
+		collectionBooks: make(map[string][]string),
 	}
 }
 
 func (r *MemoRepository) CreateBook(book *entity.Book) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	r.books[book.ID] = book
 	return nil
 }
 
 func (r *MemoRepository) CreateCollectionBook(collectionID string, book *entity.Book) error {
-	// This method is not implemented in the memo repository.
-	// In a real implementation, you would associate the book with the collection.
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.books[book.ID] = book
+	r.collectionBooks[collectionID] = append(r.collectionBooks[collectionID], book.ID)
 	return nil
 }
 
 func (r *MemoRepository) FindBookBySlug(userID, slug string) (*entity.Book, error) {
-	if book, ok := r.books[slug]; ok {
-		return book, nil
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, book := range r.books {
+		if book.UserID == userID && book.Slug == slug {
+			return book, nil
+		}
 	}
 	return nil, entity.ErrNotFound
 }
+
+func (r *MemoRepository) FindCollectionBooks(collectionID string) ([]entity.Book, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	books := make([]entity.Book, 0, len(r.collectionBooks[collectionID]))
+	for _, id := range r.collectionBooks[collectionID] {
+		if book, ok := r.books[id]; ok {
+			books = append(books, *book)
+		}
+	}
+	return books, nil
+}
+
+func (r *MemoRepository) FindBookByID(id string) (*entity.Book, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	book, ok := r.books[id]
+	if !ok {
+		return nil, entity.ErrNotFound
+	}
+	return book, nil
+}
+
+func (r *MemoRepository) UpdateBookRelease(book *entity.Book) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.books[book.ID]; !ok {
+		return entity.ErrNotFound
+	}
+	r.books[book.ID] = book
+	return nil
+}
+
+func (r *MemoRepository) UpdateBooks(books []*entity.Book) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, book := range books {
+		if _, ok := r.books[book.ID]; !ok {
+			return entity.ErrNotFound
+		}
+	}
+	for _, book := range books {
+		r.books[book.ID] = book
+	}
+	return nil
+}
+
+func (r *MemoRepository) UpdateBook(book *entity.Book) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.books[book.ID]; !ok {
+		return entity.ErrNotFound
+	}
+	r.books[book.ID] = book
+	return nil
+}
+
+func (r *MemoRepository) DeleteBook(id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.books[id]; !ok {
+		return entity.ErrNotFound
+	}
+	delete(r.books, id)
+	for collectionID, ids := range r.collectionBooks {
+		r.collectionBooks[collectionID] = slices.DeleteFunc(ids, func(bookID string) bool {
+			return bookID == id
+		})
+	}
+	return nil
+}
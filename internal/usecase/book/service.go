@@ -4,6 +4,7 @@ import (
 	"akira/internal/entity"
 	"context"
 	"fmt"
+	"time"
 )
 
 var _ entity.BookService = (*Service)(nil)
@@ -50,6 +51,7 @@ func (s *Service) CreateBook(userID string, req entity.CreateBookRequest) (*enti
 		req.Tags,
 		req.Metadata,
 		req.Language,
+		req.ReleasedAt,
 	)
 	if err := s.repo.CreateBook(book); err != nil {
 		s.logger.Error(s.ctx, "failed to create book", err, map[string]any{
@@ -65,6 +67,9 @@ func (s *Service) CreateCollectionBook(userID, collectionID string, req entity.C
 	if err := req.Validate(); err != nil {
 		return nil, err
 	}
+	if err := s.ensureVolumeAvailable(userID, collectionID, "", req.Volume); err != nil {
+		return nil, err
+	}
 	slug, err := s.ensureUniqueSlug(userID, req.Name)
 	if err != nil {
 		s.logger.Error(s.ctx, "failed to ensure unique slug", err, map[string]any{
@@ -89,6 +94,7 @@ func (s *Service) CreateCollectionBook(userID, collectionID string, req entity.C
 		req.Tags,
 		req.Metadata,
 		req.Language,
+		req.ReleasedAt,
 	)
 	if err := s.repo.CreateCollectionBook(collectionID, book); err != nil {
 		s.logger.Error(s.ctx, "failed to create collection book", err, map[string]any{
@@ -101,6 +107,216 @@ func (s *Service) CreateCollectionBook(userID, collectionID string, req entity.C
 	return book, nil
 }
 
+func (s *Service) FindCollectionBooks(userID, collectionID string) ([]entity.Book, error) {
+	books, err := s.repo.FindCollectionBooks(collectionID)
+	if err != nil {
+		s.logger.Error(s.ctx, "failed to find collection books", err, map[string]any{
+			"user_id":       userID,
+			"collection_id": collectionID,
+		})
+		return nil, err
+	}
+	owned := make([]entity.Book, 0, len(books))
+	for _, book := range books {
+		if book.UserID == userID {
+			owned = append(owned, book)
+		}
+	}
+	return owned, nil
+}
+
+func (s *Service) FindBookByID(userID, bookID string) (*entity.Book, error) {
+	book, err := s.repo.FindBookByID(bookID)
+	if err != nil {
+		if err == entity.ErrNotFound {
+			return nil, entity.ErrBookNotFound
+		}
+		s.logger.Error(s.ctx, "failed to find book", err, map[string]any{
+			"user_id": userID,
+			"book_id": bookID,
+		})
+		return nil, err
+	}
+	if book.UserID != userID {
+		return nil, entity.ErrBookForbidden
+	}
+	return book, nil
+}
+
+// UpdateCollectionBook changes the catalog details of a book of the
+// collection, such as the ones entered by hand for volumes no store sells.
+func (s *Service) UpdateCollectionBook(userID, collectionID, bookID string, req entity.CreateBookRequest) (*entity.Book, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	book, err := s.FindBookByID(userID, bookID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.ensureVolumeAvailable(userID, collectionID, book.ID, req.Volume); err != nil {
+		return nil, err
+	}
+	book.Name = req.Name
+	book.Edition = req.Edition
+	book.Description = req.Description
+	book.CoverImage = req.CoverImage
+	book.PageCount = req.PageCount
+	book.Volume = req.Volume
+	book.Publisher = req.Publisher
+	book.Author = req.Author
+	book.ISBN = req.ISBN
+	book.Tags = req.Tags
+	book.Language = req.Language
+	book.UpdatedAt = time.Now()
+	if err := s.repo.UpdateBook(book); err != nil {
+		s.logger.Error(s.ctx, "failed to update book", err, map[string]any{
+			"user_id":       userID,
+			"collection_id": collectionID,
+			"book_id":       bookID,
+		})
+		return nil, err
+	}
+	return book, nil
+}
+
+// DeleteBook removes the book with its prices and reviews.
+func (s *Service) DeleteBook(userID, bookID string) error {
+	book, err := s.FindBookByID(userID, bookID)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.DeleteBook(book.ID); err != nil {
+		if err == entity.ErrNotFound {
+			return entity.ErrBookNotFound
+		}
+		s.logger.Error(s.ctx, "failed to delete book", err, map[string]any{
+			"user_id": userID,
+			"book_id": bookID,
+		})
+		return err
+	}
+	return nil
+}
+
+func (s *Service) UpdateBookState(userID, bookID string, req entity.UpdateBookStateRequest) (*entity.Book, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	book, err := s.FindBookByID(userID, bookID)
+	if err != nil {
+		return nil, err
+	}
+	book.Ownership = req.Ownership
+	book.Reading = req.Reading
+	book.PurchasedAt = req.PurchasedAt
+	book.PricePaid = req.PricePaid
+	book.TargetPrice = req.TargetPrice
+	book.Store = req.Store
+	book.Condition = req.Condition
+	book.UpdatedAt = time.Now()
+	if err := s.repo.UpdateBooks([]*entity.Book{book}); err != nil {
+		s.logger.Error(s.ctx, "failed to update book state", err, map[string]any{
+			"user_id": userID,
+			"book_id": bookID,
+		})
+		return nil, err
+	}
+	return book, nil
+}
+
+func (s *Service) BulkUpdateBooks(userID, collectionID string, req entity.BulkUpdateBooksRequest) error {
+	if err := req.Validate(); err != nil {
+		return err
+	}
+	books, err := s.FindCollectionBooks(userID, collectionID)
+	if err != nil {
+		return err
+	}
+	byID := make(map[string]*entity.Book, len(books))
+	for i := range books {
+		byID[books[i].ID] = &books[i]
+	}
+	now := time.Now()
+	selected := make([]*entity.Book, 0, len(req.BookIDs))
+	for _, id := range req.BookIDs {
+		book, ok := byID[id]
+		if !ok {
+			return entity.ErrBookNotFound
+		}
+		if req.Ownership != "" {
+			book.Ownership = req.Ownership
+		}
+		if req.Reading != "" {
+			book.Reading = req.Reading
+		}
+		book.UpdatedAt = now
+		selected = append(selected, book)
+	}
+	if err := s.repo.UpdateBooks(selected); err != nil {
+		s.logger.Error(s.ctx, "failed to bulk update books", err, map[string]any{
+			"user_id":       userID,
+			"collection_id": collectionID,
+			"books":         len(selected),
+		})
+		return err
+	}
+	return nil
+}
+
+// UpdateBookRelease stores the release date and presale flag reported by the
+// crawlers for the book; a zero date keeps the one already known.
+func (s *Service) UpdateBookRelease(bookID string, releasedAt time.Time, presale bool) (*entity.Book, error) {
+	book, err := s.repo.FindBookByID(bookID)
+	if err != nil {
+		if err == entity.ErrNotFound {
+			return nil, entity.ErrBookNotFound
+		}
+		s.logger.Error(s.ctx, "failed to find book", err, map[string]any{
+			"book_id": bookID,
+		})
+		return nil, err
+	}
+	if !releasedAt.IsZero() {
+		book.ReleasedAt = releasedAt
+	}
+	if book.Metadata == nil {
+		book.Metadata = make(map[string]string)
+	}
+	if presale {
+		book.Metadata["presale"] = "true"
+	} else {
+		delete(book.Metadata, "presale")
+	}
+	now := time.Now()
+	book.LastSync = now
+	book.UpdatedAt = now
+	if err := s.repo.UpdateBookRelease(book); err != nil {
+		s.logger.Error(s.ctx, "failed to update book release", err, map[string]any{
+			"book_id": bookID,
+		})
+		return nil, err
+	}
+	return book, nil
+}
+
+// ensureVolumeAvailable rejects a volume number already used by another book
+// of the collection.
+func (s *Service) ensureVolumeAvailable(userID, collectionID, bookID string, volume *int) error {
+	if volume == nil {
+		return nil
+	}
+	books, err := s.FindCollectionBooks(userID, collectionID)
+	if err != nil {
+		return err
+	}
+	for _, book := range books {
+		if book.ID != bookID && book.Volume != nil && *book.Volume == *volume {
+			return entity.RequestError{}.Add("volume", entity.ErrBookVolumeTaken.Error())
+		}
+	}
+	return nil
+}
+
 func (s *Service) ensureUniqueSlug(userID, name string) (string, error) {
 	base := entity.GenerateSlug(name)
 	slug := base
@@ -0,0 +1,401 @@
+package book
+
+import (
+	"akira/internal/entity"
+	"database/sql"
+	"encoding/json"
+	"time"
+)
+
+var _ entity.BookRepository = (*BookSqliteRepository)(nil)
+
+const bookColumns = `
+	b.id, b.name, b.edition, b.description, b.slug, b.cover_image,
+	b.page_count, b.volume, b.rating, b.reviews, b.publisher, b.authors,
+	b.user_id, b.isbn, b.tags, b.metadata, b.lang, b.released_at,
+	b.ownership, b.reading_status, b.purchased_at, b.price_paid, b.store,
+	b.condition, b.target_price, b.last_sync_at, b.created_at, b.updated_at
+`
+
+type BookSqliteRepository struct {
+	db *sql.DB
+}
+
+func NewBookSqliteRepository(db *sql.DB) entity.BookRepository {
+	return &BookSqliteRepository{db: db}
+}
+
+func (r *BookSqliteRepository) scanBookRow(row entity.Rowscan) (*entity.Book, error) {
+	var book entity.Book
+	var nullableEdition, nullableDescription, nullableCoverImage, nullablePublisher sql.NullString
+	var nullableReviews, nullableAuthor, nullableTags, nullableMetadata sql.NullString
+	var nullableISBN, nullableLang, nullableStore, nullableCondition sql.NullString
+	var nullablePageCount, nullableVolume sql.NullInt32
+	var nullableRating, nullablePricePaid, nullableTargetPrice sql.NullFloat64
+	var nullableReleasedAt, nullablePurchasedAt, nullableLastSync sql.NullTime
+	err := row.Scan(
+		&book.ID,
+		&book.Name,
+		&nullableEdition,
+		&nullableDescription,
+		&book.Slug,
+		&nullableCoverImage,
+		&nullablePageCount,
+		&nullableVolume,
+		&nullableRating,
+		&nullableReviews,
+		&nullablePublisher,
+		&nullableAuthor,
+		&book.UserID,
+		&nullableISBN,
+		&nullableTags,
+		&nullableMetadata,
+		&nullableLang,
+		&nullableReleasedAt,
+		&book.Ownership,
+		&book.Reading,
+		&nullablePurchasedAt,
+		&nullablePricePaid,
+		&nullableStore,
+		&nullableCondition,
+		&nullableTargetPrice,
+		&nullableLastSync,
+		&book.CreatedAt,
+		&book.UpdatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, entity.ErrNotFound
+		}
+		return nil, err
+	}
+	if nullableReviews.Valid {
+		err = json.Unmarshal([]byte(nullableReviews.String), &book.Reviews)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if nullableAuthor.Valid {
+		err = json.Unmarshal([]byte(nullableAuthor.String), &book.Author)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if nullableTags.Valid {
+		err = json.Unmarshal([]byte(nullableTags.String), &book.Tags)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if nullableMetadata.Valid {
+		err = json.Unmarshal([]byte(nullableMetadata.String), &book.Metadata)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if nullableVolume.Valid {
+		volume := int(nullableVolume.Int32)
+		book.Volume = &volume
+	}
+	book.Edition = nullableEdition.String
+	book.Description = nullableDescription.String
+	book.CoverImage = nullableCoverImage.String
+	book.PageCount = int(nullablePageCount.Int32)
+	book.Rating = nullableRating.Float64
+	book.Publisher = nullablePublisher.String
+	book.ISBN = nullableISBN.String
+	book.Language = nullableLang.String
+	book.ReleasedAt = nullableReleasedAt.Time
+	book.PurchasedAt = nullablePurchasedAt.Time
+	book.PricePaid = nullablePricePaid.Float64
+	book.TargetPrice = nullableTargetPrice.Float64
+	book.Store = nullableStore.String
+	book.Condition = entity.BookCondition(nullableCondition.String)
+	book.LastSync = nullableLastSync.Time
+	return &book, nil
+}
+
+func (r *BookSqliteRepository) insertBook(tx *sql.Tx, book *entity.Book) error {
+	stmt, err := tx.Prepare(`
+		INSERT INTO books (
+			id, name, edition, description, slug, cover_image,
+			page_count, volume, rating, reviews, publisher, authors,
+			user_id, isbn, tags, metadata, lang, released_at,
+			ownership, reading_status, purchased_at, price_paid, store,
+			condition, target_price, last_sync_at, created_at, updated_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	reviews, err := json.Marshal(book.Reviews)
+	if err != nil {
+		return err
+	}
+	authors, err := json.Marshal(book.Author)
+	if err != nil {
+		return err
+	}
+	tags, err := json.Marshal(book.Tags)
+	if err != nil {
+		return err
+	}
+	metadata, err := json.Marshal(book.Metadata)
+	if err != nil {
+		return err
+	}
+	_, err = stmt.Exec(
+		book.ID,
+		book.Name,
+		book.Edition,
+		book.Description,
+		book.Slug,
+		book.CoverImage,
+		book.PageCount,
+		book.Volume,
+		book.Rating,
+		reviews, // marshal to JSON
+		book.Publisher,
+		authors, // marshal to JSON
+		book.UserID,
+		book.ISBN,
+		tags,     // marshal to JSON
+		metadata, // marshal to JSON
+		book.Language,
+		nullTime(book.ReleasedAt),
+		book.Ownership,
+		book.Reading,
+		nullTime(book.PurchasedAt),
+		book.PricePaid,
+		book.Store,
+		book.Condition,
+		book.TargetPrice,
+		nullTime(book.LastSync),
+		book.CreatedAt,
+		book.UpdatedAt,
+	)
+	return err
+}
+
+func (r *BookSqliteRepository) CreateBook(book *entity.Book) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err := r.insertBook(tx, book); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *BookSqliteRepository) CreateCollectionBook(collectionID string, book *entity.Book) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err := r.insertBook(tx, book); err != nil {
+		return err
+	}
+	_, err = tx.Exec(
+		"INSERT INTO collection_books (collection_id, book_id, created_at) VALUES (?, ?, ?)",
+		collectionID,
+		book.ID,
+		time.Now(),
+	)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *BookSqliteRepository) FindBookBySlug(userID, slug string) (*entity.Book, error) {
+	stmt, err := r.db.Prepare("SELECT " + bookColumns + " FROM books b WHERE b.user_id = ? AND b.slug = ?")
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+	row := stmt.QueryRow(userID, slug)
+	return r.scanBookRow(row)
+}
+
+func (r *BookSqliteRepository) FindBookByID(id string) (*entity.Book, error) {
+	stmt, err := r.db.Prepare("SELECT " + bookColumns + " FROM books b WHERE b.id = ?")
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+	row := stmt.QueryRow(id)
+	return r.scanBookRow(row)
+}
+
+// UpdateBook saves the catalog details of the book, leaving the per-user
+// state and the crawler fields untouched.
+func (r *BookSqliteRepository) UpdateBook(book *entity.Book) error {
+	authors, err := json.Marshal(book.Author)
+	if err != nil {
+		return err
+	}
+	tags, err := json.Marshal(book.Tags)
+	if err != nil {
+		return err
+	}
+	result, err := r.db.Exec(`
+		UPDATE books SET
+			name = ?, edition = ?, description = ?, cover_image = ?, page_count = ?,
+			volume = ?, publisher = ?, authors = ?, isbn = ?, tags = ?, lang = ?,
+			updated_at = ?
+		WHERE id = ?
+	`,
+		book.Name,
+		book.Edition,
+		book.Description,
+		book.CoverImage,
+		book.PageCount,
+		book.Volume,
+		book.Publisher,
+		authors, // marshal to JSON
+		book.ISBN,
+		tags, // marshal to JSON
+		book.Language,
+		book.UpdatedAt,
+		book.ID,
+	)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return entity.ErrNotFound
+	}
+	return nil
+}
+
+// UpdateBooks saves the per-user state of the given books in a single
+// transaction, so bulk changes are applied all at once or not at all.
+func (r *BookSqliteRepository) UpdateBooks(books []*entity.Book) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	stmt, err := tx.Prepare(`
+		UPDATE books SET
+			ownership = ?, reading_status = ?, purchased_at = ?, price_paid = ?,
+			target_price = ?, store = ?, condition = ?, updated_at = ?
+		WHERE id = ?
+	`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	for _, book := range books {
+		result, err := stmt.Exec(
+			book.Ownership,
+			book.Reading,
+			nullTime(book.PurchasedAt),
+			book.PricePaid,
+			book.TargetPrice,
+			book.Store,
+			book.Condition,
+			book.UpdatedAt,
+			book.ID,
+		)
+		if err != nil {
+			return err
+		}
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if affected == 0 {
+			return entity.ErrNotFound
+		}
+	}
+	return tx.Commit()
+}
+
+func (r *BookSqliteRepository) UpdateBookRelease(book *entity.Book) error {
+	metadata, err := json.Marshal(book.Metadata)
+	if err != nil {
+		return err
+	}
+	result, err := r.db.Exec(`
+		UPDATE books SET released_at = ?, metadata = ?, last_sync_at = ?, updated_at = ?
+		WHERE id = ?
+	`,
+		nullTime(book.ReleasedAt),
+		metadata, // marshal to JSON
+		nullTime(book.LastSync),
+		book.UpdatedAt,
+		book.ID,
+	)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return entity.ErrNotFound
+	}
+	return nil
+}
+
+// DeleteBook removes the book; its collection entry, prices and reviews go
+// with it through the foreign keys.
+func (r *BookSqliteRepository) DeleteBook(id string) error {
+	result, err := r.db.Exec("DELETE FROM books WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return entity.ErrNotFound
+	}
+	return nil
+}
+
+func (r *BookSqliteRepository) FindCollectionBooks(collectionID string) ([]entity.Book, error) {
+	stmt, err := r.db.Prepare(`
+		SELECT ` + bookColumns + `
+		FROM books b
+		INNER JOIN collection_books cb ON cb.book_id = b.id
+		WHERE cb.collection_id = ?
+		ORDER BY b.volume IS NULL, b.volume, b.name
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+	rows, err := stmt.Query(collectionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	books := make([]entity.Book, 0)
+	for rows.Next() {
+		book, err := r.scanBookRow(rows)
+		if err != nil {
+			return nil, err
+		}
+		books = append(books, *book)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return books, nil
+}
+
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: !t.IsZero()}
+}
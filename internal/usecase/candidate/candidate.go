@@ -0,0 +1,12 @@
+package candidate
+
+import (
+	"akira/internal/entity"
+	"context"
+	"database/sql"
+)
+
+func Make(ctx context.Context, db *sql.DB, event entity.EventService, logger entity.Logger) entity.CandidateService {
+	repo := NewCandidateSqliteRepository(db)
+	return NewService(ctx, repo, event, logger)
+}
@@ -0,0 +1,111 @@
+package candidate
+
+import (
+	"akira/internal/entity"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+)
+
+var _ entity.CandidateRepository = (*MemoRepository)(nil)
+
+type MemoRepository struct {
+	mu         sync.RWMutex
+	candidates map[string]*entity.CrawlCandidate
+	rejections map[string][]string
+}
+
+func NewMemoRepository() *MemoRepository {
+	return &MemoRepository{
+		candidates: make(map[string]*entity.CrawlCandidate),
+		rejections: make(map[string][]string),
+	}
+}
+
+func (r *MemoRepository) CreateCandidate(candidate *entity.CrawlCandidate) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored := *candidate
+	r.candidates[candidate.ID] = &stored
+	return nil
+}
+
+func (r *MemoRepository) UpdateCandidate(candidate *entity.CrawlCandidate) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.candidates[candidate.ID]; !ok {
+		return entity.ErrNotFound
+	}
+	stored := *candidate
+	r.candidates[candidate.ID] = &stored
+	return nil
+}
+
+func (r *MemoRepository) FindCandidateByID(id string) (*entity.CrawlCandidate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	candidate, ok := r.candidates[id]
+	if !ok {
+		return nil, entity.ErrNotFound
+	}
+	found := *candidate
+	return &found, nil
+}
+
+func (r *MemoRepository) FindPendingCandidate(collectionID, url, isbn string) (*entity.CrawlCandidate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, candidate := range r.candidates {
+		if candidate.CollectionID != collectionID || !candidate.IsPending() {
+			continue
+		}
+		if candidate.Result.URL == url || (isbn != "" && entity.CanonicalISBN(candidate.Result.ISBN) == isbn) {
+			found := *candidate
+			return &found, nil
+		}
+	}
+	return nil, entity.ErrNotFound
+}
+
+func (r *MemoRepository) ListCandidates(collectionID string, status entity.CandidateStatus) ([]entity.CrawlCandidate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	candidates := make([]entity.CrawlCandidate, 0)
+	for _, candidate := range r.candidates {
+		if candidate.CollectionID == collectionID && candidate.Status == status {
+			candidates = append(candidates, *candidate)
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].CreatedAt.Before(candidates[j].CreatedAt)
+	})
+	return candidates, nil
+}
+
+func (r *MemoRepository) CountCandidates(collectionID string, status entity.CandidateStatus) (int, error) {
+	candidates, err := r.ListCandidates(collectionID, status)
+	return len(candidates), err
+}
+
+func (r *MemoRepository) CreateRejections(collectionID string, keys []string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, key := range keys {
+		if !slices.Contains(r.rejections[collectionID], key) {
+			r.rejections[collectionID] = append(r.rejections[collectionID], key)
+		}
+	}
+	return nil
+}
+
+func (r *MemoRepository) HasRejection(collectionID string, keys []string) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, key := range keys {
+		if slices.Contains(r.rejections[collectionID], key) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
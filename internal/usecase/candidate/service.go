@@ -0,0 +1,243 @@
+package candidate
+
+import (
+	"akira/internal/entity"
+	"context"
+	"sort"
+	"time"
+)
+
+var _ entity.CandidateService = (*Service)(nil)
+
+type Service struct {
+	ctx    context.Context
+	repo   entity.CandidateRepository
+	event  entity.EventService
+	logger entity.Logger
+}
+
+func NewService(ctx context.Context, repo entity.CandidateRepository, event entity.EventService, logger entity.Logger) *Service {
+	return &Service{
+		ctx:    ctx,
+		repo:   repo,
+		event:  event,
+		logger: logger,
+	}
+}
+
+func (s *Service) SubmitCandidate(collection *entity.Collection, runID string, result entity.CrawledResult) (*entity.CrawlCandidate, error) {
+	confidence := entity.ScoreCandidate(collection, result)
+	candidate, err := s.repo.FindPendingCandidate(collection.ID, result.URL, entity.CanonicalISBN(result.ISBN))
+	if err != nil && err != entity.ErrNotFound {
+		s.logger.Error(s.ctx, "failed to find pending candidate", err, map[string]any{
+			"collection_id": collection.ID,
+			"url":           result.URL,
+		})
+		return nil, err
+	}
+	if candidate == nil {
+		candidate = entity.NewCrawlCandidate(collection.UserID, collection.ID, runID, result, confidence)
+		if err := s.repo.CreateCandidate(candidate); err != nil {
+			s.logger.Error(s.ctx, "failed to create candidate", err, map[string]any{
+				"collection_id": collection.ID,
+				"url":           result.URL,
+			})
+			return nil, err
+		}
+		return candidate, nil
+	}
+	// a later sync brings fresher prices, but keeps the volume set by hand
+	if candidate.Result.Volume != result.Volume && candidate.Result.Provenance["volume"] == "manual" {
+		result.Volume = candidate.Result.Volume
+		if result.Provenance == nil {
+			result.Provenance = make(map[string]string)
+		}
+		result.Provenance["volume"] = "manual"
+	}
+	candidate.Result = result
+	candidate.RunID = runID
+	candidate.Confidence = confidence
+	candidate.UpdatedAt = time.Now()
+	if err := s.repo.UpdateCandidate(candidate); err != nil {
+		s.logger.Error(s.ctx, "failed to update candidate", err, map[string]any{
+			"candidate_id": candidate.ID,
+		})
+		return nil, err
+	}
+	return candidate, nil
+}
+
+func (s *Service) IsRejected(collectionID string, result entity.CrawledResult) (bool, error) {
+	rejected, err := s.repo.HasRejection(collectionID, entity.RejectionKeys(result))
+	if err != nil {
+		s.logger.Error(s.ctx, "failed to check candidate rejections", err, map[string]any{
+			"collection_id": collectionID,
+			"url":           result.URL,
+		})
+		return false, err
+	}
+	return rejected, nil
+}
+
+// FindPendingCandidates lists the candidates waiting for review by volume,
+// the most confident first within the same volume.
+func (s *Service) FindPendingCandidates(userID, collectionID string) ([]entity.CrawlCandidate, error) {
+	candidates, err := s.repo.ListCandidates(collectionID, entity.CandidatePending)
+	if err != nil {
+		s.logger.Error(s.ctx, "failed to list candidates", err, map[string]any{
+			"collection_id": collectionID,
+		})
+		return nil, err
+	}
+	owned := make([]entity.CrawlCandidate, 0, len(candidates))
+	for _, candidate := range candidates {
+		if candidate.UserID == userID {
+			owned = append(owned, candidate)
+		}
+	}
+	sort.SliceStable(owned, func(i, j int) bool {
+		vi, vj := owned[i].Result.Volume, owned[j].Result.Volume
+		if vi != vj {
+			// candidates without a volume number go last
+			return vj == 0 || (vi != 0 && vi < vj)
+		}
+		return owned[i].Confidence > owned[j].Confidence
+	})
+	return owned, nil
+}
+
+func (s *Service) CountPendingCandidates(collectionID string) (int, error) {
+	count, err := s.repo.CountCandidates(collectionID, entity.CandidatePending)
+	if err != nil {
+		s.logger.Error(s.ctx, "failed to count candidates", err, map[string]any{
+			"collection_id": collectionID,
+		})
+		return 0, err
+	}
+	return count, nil
+}
+
+// AcceptCandidate takes the candidate into the collection. The book is
+// created by the crawler consumer, as for any other crawled volume.
+func (s *Service) AcceptCandidate(userID, candidateID string) (*entity.CrawlCandidate, error) {
+	candidate, err := s.findPendingCandidate(userID, candidateID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.accept(candidate); err != nil {
+		return nil, err
+	}
+	return candidate, nil
+}
+
+// AcceptConfidentCandidates accepts every pending candidate of the
+// collection scored at least entity.CandidateConfidenceHigh.
+func (s *Service) AcceptConfidentCandidates(userID, collectionID string) (int, error) {
+	candidates, err := s.FindPendingCandidates(userID, collectionID)
+	if err != nil {
+		return 0, err
+	}
+	accepted := 0
+	for i := range candidates {
+		if !candidates[i].IsConfident() {
+			continue
+		}
+		if err := s.accept(&candidates[i]); err != nil {
+			return accepted, err
+		}
+		accepted++
+	}
+	return accepted, nil
+}
+
+// RejectCandidate drops the candidate and remembers its listings and ISBN,
+// so later syncs don't suggest it again.
+func (s *Service) RejectCandidate(userID, candidateID string) (*entity.CrawlCandidate, error) {
+	candidate, err := s.findPendingCandidate(userID, candidateID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if err := s.repo.CreateRejections(candidate.CollectionID, entity.RejectionKeys(candidate.Result), now); err != nil {
+		s.logger.Error(s.ctx, "failed to save candidate rejections", err, map[string]any{
+			"candidate_id": candidate.ID,
+		})
+		return nil, err
+	}
+	candidate.Status = entity.CandidateRejected
+	candidate.UpdatedAt = now
+	if err := s.repo.UpdateCandidate(candidate); err != nil {
+		s.logger.Error(s.ctx, "failed to reject candidate", err, map[string]any{
+			"candidate_id": candidate.ID,
+		})
+		return nil, err
+	}
+	return candidate, nil
+}
+
+// AssignVolume corrects the volume number read from the listing title.
+func (s *Service) AssignVolume(userID, candidateID string, volume int) (*entity.CrawlCandidate, error) {
+	if volume <= 0 {
+		return nil, entity.ErrCandidateVolumeInvalid
+	}
+	candidate, err := s.findPendingCandidate(userID, candidateID)
+	if err != nil {
+		return nil, err
+	}
+	candidate.Result.Volume = volume
+	if candidate.Result.Provenance == nil {
+		candidate.Result.Provenance = make(map[string]string)
+	}
+	candidate.Result.Provenance["volume"] = "manual"
+	candidate.UpdatedAt = time.Now()
+	if err := s.repo.UpdateCandidate(candidate); err != nil {
+		s.logger.Error(s.ctx, "failed to assign candidate volume", err, map[string]any{
+			"candidate_id": candidate.ID,
+			"volume":       volume,
+		})
+		return nil, err
+	}
+	return candidate, nil
+}
+
+func (s *Service) accept(candidate *entity.CrawlCandidate) error {
+	candidate.Status = entity.CandidateAccepted
+	candidate.UpdatedAt = time.Now()
+	if err := s.repo.UpdateCandidate(candidate); err != nil {
+		s.logger.Error(s.ctx, "failed to accept candidate", err, map[string]any{
+			"candidate_id": candidate.ID,
+		})
+		return err
+	}
+	s.event.Publish(entity.NewEvent(
+		entity.EventCrawlerCandidateAccepted,
+		candidate.UserID,
+		map[string]any{
+			"run_id":        candidate.RunID,
+			"collection_id": candidate.CollectionID,
+			"candidate_id":  candidate.ID,
+			"result":        candidate.Result,
+		},
+	))
+	return nil
+}
+
+func (s *Service) findPendingCandidate(userID, candidateID string) (*entity.CrawlCandidate, error) {
+	candidate, err := s.repo.FindCandidateByID(candidateID)
+	if err != nil {
+		if err == entity.ErrNotFound {
+			return nil, entity.ErrCandidateNotFound
+		}
+		s.logger.Error(s.ctx, "failed to find candidate", err, map[string]any{
+			"candidate_id": candidateID,
+		})
+		return nil, err
+	}
+	if candidate.UserID != userID {
+		return nil, entity.ErrCandidateForbidden
+	}
+	if !candidate.IsPending() {
+		return nil, entity.ErrCandidateNotPending
+	}
+	return candidate, nil
+}
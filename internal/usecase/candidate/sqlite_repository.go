@@ -0,0 +1,211 @@
+package candidate
+
+import (
+	"akira/internal/entity"
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+var _ entity.CandidateRepository = (*CandidateSqliteRepository)(nil)
+
+const candidateColumns = `
+	id, collection_id, user_id, run_id, confidence, status, result, created_at, updated_at
+`
+
+type CandidateSqliteRepository struct {
+	db *sql.DB
+}
+
+func NewCandidateSqliteRepository(db *sql.DB) entity.CandidateRepository {
+	return &CandidateSqliteRepository{db: db}
+}
+
+func (r *CandidateSqliteRepository) scanCandidateRow(row entity.Rowscan) (*entity.CrawlCandidate, error) {
+	var candidate entity.CrawlCandidate
+	var nullableRunID sql.NullString
+	var result string
+	err := row.Scan(
+		&candidate.ID,
+		&candidate.CollectionID,
+		&candidate.UserID,
+		&nullableRunID,
+		&candidate.Confidence,
+		&candidate.Status,
+		&result,
+		&candidate.CreatedAt,
+		&candidate.UpdatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, entity.ErrNotFound
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(result), &candidate.Result); err != nil {
+		return nil, err
+	}
+	candidate.RunID = nullableRunID.String
+	return &candidate, nil
+}
+
+func (r *CandidateSqliteRepository) CreateCandidate(candidate *entity.CrawlCandidate) error {
+	result, err := json.Marshal(candidate.Result)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.Exec(`
+		INSERT INTO crawl_candidates (
+			id, collection_id, user_id, run_id, url, isbn, volume,
+			confidence, status, result, created_at, updated_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`,
+		candidate.ID,
+		candidate.CollectionID,
+		candidate.UserID,
+		nullString(candidate.RunID),
+		candidate.Result.URL,
+		nullString(entity.CanonicalISBN(candidate.Result.ISBN)),
+		nullVolume(candidate.Result.Volume),
+		candidate.Confidence,
+		candidate.Status,
+		result, // marshal to JSON
+		candidate.CreatedAt,
+		candidate.UpdatedAt,
+	)
+	return err
+}
+
+func (r *CandidateSqliteRepository) UpdateCandidate(candidate *entity.CrawlCandidate) error {
+	result, err := json.Marshal(candidate.Result)
+	if err != nil {
+		return err
+	}
+	res, err := r.db.Exec(`
+		UPDATE crawl_candidates SET
+			run_id = ?, url = ?, isbn = ?, volume = ?, confidence = ?,
+			status = ?, result = ?, updated_at = ?
+		WHERE id = ?
+	`,
+		nullString(candidate.RunID),
+		candidate.Result.URL,
+		nullString(entity.CanonicalISBN(candidate.Result.ISBN)),
+		nullVolume(candidate.Result.Volume),
+		candidate.Confidence,
+		candidate.Status,
+		result, // marshal to JSON
+		candidate.UpdatedAt,
+		candidate.ID,
+	)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return entity.ErrNotFound
+	}
+	return nil
+}
+
+func (r *CandidateSqliteRepository) FindCandidateByID(id string) (*entity.CrawlCandidate, error) {
+	row := r.db.QueryRow("SELECT "+candidateColumns+" FROM crawl_candidates WHERE id = ?", id)
+	return r.scanCandidateRow(row)
+}
+
+func (r *CandidateSqliteRepository) FindPendingCandidate(collectionID, url, isbn string) (*entity.CrawlCandidate, error) {
+	row := r.db.QueryRow(`
+		SELECT `+candidateColumns+`
+		FROM crawl_candidates
+		WHERE collection_id = ? AND status = ? AND (url = ? OR (? != '' AND isbn = ?))
+		ORDER BY created_at
+		LIMIT 1
+	`, collectionID, entity.CandidatePending, url, isbn, isbn)
+	return r.scanCandidateRow(row)
+}
+
+func (r *CandidateSqliteRepository) ListCandidates(collectionID string, status entity.CandidateStatus) ([]entity.CrawlCandidate, error) {
+	rows, err := r.db.Query(`
+		SELECT `+candidateColumns+`
+		FROM crawl_candidates
+		WHERE collection_id = ? AND status = ?
+		ORDER BY volume IS NULL, volume, confidence DESC
+	`, collectionID, status)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	candidates := make([]entity.CrawlCandidate, 0)
+	for rows.Next() {
+		candidate, err := r.scanCandidateRow(rows)
+		if err != nil {
+			return nil, err
+		}
+		candidates = append(candidates, *candidate)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return candidates, nil
+}
+
+func (r *CandidateSqliteRepository) CountCandidates(collectionID string, status entity.CandidateStatus) (int, error) {
+	var count int
+	err := r.db.QueryRow(
+		"SELECT COUNT(*) FROM crawl_candidates WHERE collection_id = ? AND status = ?",
+		collectionID,
+		status,
+	).Scan(&count)
+	return count, err
+}
+
+func (r *CandidateSqliteRepository) CreateRejections(collectionID string, keys []string, at time.Time) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	stmt, err := tx.Prepare("INSERT OR IGNORE INTO crawl_rejections (collection_id, key, created_at) VALUES (?, ?, ?)")
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	for _, key := range keys {
+		if _, err := stmt.Exec(collectionID, key, at); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (r *CandidateSqliteRepository) HasRejection(collectionID string, keys []string) (bool, error) {
+	if len(keys) == 0 {
+		return false, nil
+	}
+	args := make([]any, 0, len(keys)+1)
+	args = append(args, collectionID)
+	for _, key := range keys {
+		args = append(args, key)
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(keys)), ", ")
+	var count int
+	err := r.db.QueryRow(
+		"SELECT COUNT(*) FROM crawl_rejections WHERE collection_id = ? AND key IN ("+placeholders+")",
+		args...,
+	).Scan(&count)
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+func nullVolume(volume int) sql.NullInt32 {
+	return sql.NullInt32{Int32: int32(volume), Valid: volume > 0}
+}
@@ -1,6 +1,12 @@
 package collection
 
-import "akira/internal/entity"
+import (
+	"akira/internal/entity"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
 
 var _ entity.CollectionRepository = (*MemoRepository)(nil)
 
@@ -19,9 +25,123 @@ func (r *MemoRepository) CreateCollection(collection *entity.Collection) error {
 	return nil
 }
 
-func (r *MemoRepository) FindCollectionBySlug(userID, slug string) (*entity.Collection, error) {
-	if collection, ok := r.collections[slug]; ok {
+func (r *MemoRepository) UpdateCollection(collection *entity.Collection) error {
+	if _, ok := r.collections[collection.ID]; !ok {
+		return entity.ErrNotFound
+	}
+	r.collections[collection.ID] = collection
+	return nil
+}
+
+func (r *MemoRepository) FindCollectionByID(id string) (*entity.Collection, error) {
+	if collection, ok := r.collections[id]; ok {
 		return collection, nil
 	}
 	return nil, entity.ErrNotFound
 }
+
+func (r *MemoRepository) FindCollectionBySlug(userID, slug string) (*entity.Collection, error) {
+	for _, collection := range r.collections {
+		if collection.UserID == userID && collection.Slug == slug {
+			return collection, nil
+		}
+	}
+	return nil, entity.ErrNotFound
+}
+
+func (r *MemoRepository) ListCollections(
+	userID string,
+	filter entity.CollectionFilter,
+	sortBy entity.CollectionSort,
+	page int,
+) (*entity.CollectionPage, error) {
+	items := make([]entity.CollectionSummary, 0)
+	for _, collection := range r.collections {
+		if collection.UserID != userID {
+			continue
+		}
+		if collection.IsArchived() != (filter == entity.CollectionFilterArchived) {
+			continue
+		}
+		isManga := slices.ContainsFunc(collection.Tags, func(tag string) bool {
+			return strings.EqualFold(tag, "manga")
+		})
+		switch filter {
+		case entity.CollectionFilterManga:
+			if !isManga {
+				continue
+			}
+		case entity.CollectionFilterBooks:
+			if isManga {
+				continue
+			}
+		case entity.CollectionFilterComplete:
+			if collection.ReleaseStatus != entity.ReleaseStatusCompleted {
+				continue
+			}
+		case entity.CollectionFilterInProgress:
+			if collection.ReleaseStatus != entity.ReleaseStatusOnGoing && collection.ReleaseStatus != entity.ReleaseStatusHiatus {
+				continue
+			}
+		}
+		items = append(items, entity.CollectionSummary{Collection: *collection})
+	}
+	sort.Slice(items, func(i, j int) bool {
+		switch sortBy {
+		case entity.CollectionSortTitle:
+			return strings.ToLower(items[i].Name) < strings.ToLower(items[j].Name)
+		case entity.CollectionSortLastSync:
+			return items[i].LastSync.After(items[j].LastSync)
+		default:
+			return items[i].CreatedAt.After(items[j].CreatedAt)
+		}
+	})
+	if page < 1 {
+		page = 1
+	}
+	total := len(items)
+	start := min((page-1)*entity.CollectionsPerPage, total)
+	end := min(start+entity.CollectionsPerPage, total)
+	return entity.NewCollectionPage(items[start:end], filter, sortBy, page, total), nil
+}
+
+func (r *MemoRepository) DeleteCollection(id string) error {
+	if _, ok := r.collections[id]; !ok {
+		return entity.ErrNotFound
+	}
+	delete(r.collections, id)
+	return nil
+}
+
+func (r *MemoRepository) FindCollectionsDueForSync(before time.Time, limit int) ([]entity.Collection, error) {
+	collections := make([]entity.Collection, 0)
+	for _, collection := range r.collections {
+		if collection.IsArchived() || collection.IsSyncing() {
+			continue
+		}
+		if !collection.CrawlerOptions.AutoSync || len(collection.SyncSources) == 0 {
+			continue
+		}
+		if !collection.LastSync.Before(before) || !collection.LastSyncAttempt.Before(before) {
+			continue
+		}
+		collections = append(collections, *collection)
+	}
+	sort.Slice(collections, func(i, j int) bool {
+		return collections[i].LastSync.Before(collections[j].LastSync)
+	})
+	if len(collections) > limit {
+		collections = collections[:limit]
+	}
+	return collections, nil
+}
+
+func (r *MemoRepository) CountSyncingCollections() (int, error) {
+	count := 0
+	for _, collection := range r.collections {
+		if !collection.IsArchived() && collection.IsSyncing() {
+			count++
+		}
+	}
+	return count, nil
+}
@@ -4,6 +4,7 @@ import (
 	"akira/internal/entity"
 	"context"
 	"fmt"
+	"time"
 )
 
 var _ entity.CollectionService = (*Service)(nil)
@@ -32,7 +33,7 @@ func (s *Service) CreateCollection(userID string, req entity.CreateCollectionReq
 		})
 		return nil, err
 	}
-	slug, err := s.ensureUniqueSlug(userID, req.Name)
+	slug, err := s.ensureUniqueSlug(userID, req.Name, "")
 	if err != nil {
 		s.logger.Error(s.ctx, "CreateCollection: ensureUniqueSlug failed", err, map[string]any{
 			"userID": userID,
@@ -54,6 +55,7 @@ func (s *Service) CreateCollection(userID string, req entity.CreateCollectionReq
 		req.SyncSources,
 		req.CrawlerOptions,
 	)
+	collection.SetSearchTerms(req.SearchTerms)
 	if err := s.repo.CreateCollection(collection); err != nil {
 		s.logger.Error(s.ctx, "CreateCollection: CreateCollection failed", err, map[string]any{
 			"userID": userID,
@@ -69,18 +71,396 @@ func (s *Service) CreateCollection(userID string, req entity.CreateCollectionReq
 	return collection, nil
 }
 
-func (s *Service) ensureUniqueSlug(userID, name string) (string, error) {
+func (s *Service) FindCollectionBySlug(userID, slug string) (*entity.Collection, error) {
+	collection, err := s.repo.FindCollectionBySlug(userID, slug)
+	if err != nil {
+		if err == entity.ErrNotFound {
+			return nil, entity.ErrCollectionNotFound
+		}
+		s.logger.Error(s.ctx, "FindCollectionBySlug: FindCollectionBySlug failed", err, map[string]any{
+			"userID": userID,
+			"slug":   slug,
+		})
+		return nil, err
+	}
+	return collection, nil
+}
+
+func (s *Service) FindCollectionByID(collectionID string) (*entity.Collection, error) {
+	collection, err := s.repo.FindCollectionByID(collectionID)
+	if err != nil {
+		if err == entity.ErrNotFound {
+			return nil, entity.ErrCollectionNotFound
+		}
+		s.logger.Error(s.ctx, "FindCollectionByID: FindCollectionByID failed", err, map[string]any{
+			"collectionID": collectionID,
+		})
+		return nil, err
+	}
+	return collection, nil
+}
+
+// RefreshReleaseInfo updates the expected size and release status of the
+// collection from the books found by a sync.
+func (s *Service) RefreshReleaseInfo(collectionID string, books []entity.Book) (*entity.Collection, error) {
+	collection, err := s.FindCollectionByID(collectionID)
+	if err != nil {
+		return nil, err
+	}
+	if !collection.ApplyBooks(books, time.Now()) {
+		return collection, nil
+	}
+	collection.UpdatedAt = time.Now()
+	if err := s.repo.UpdateCollection(collection); err != nil {
+		s.logger.Error(s.ctx, "RefreshReleaseInfo: UpdateCollection failed", err, map[string]any{
+			"collectionID": collectionID,
+		})
+		return nil, err
+	}
+	s.event.Publish(entity.NewEvent(
+		entity.EventCollectionUpdated,
+		collection.UserID,
+		collection,
+	))
+	return collection, nil
+}
+
+func (s *Service) SyncCollection(userID, slug string, force bool) (*entity.Collection, error) {
+	collection, err := s.FindCollectionBySlug(userID, slug)
+	if err != nil {
+		return nil, err
+	}
+	if collection.IsArchived() {
+		return nil, entity.ErrCollectionArchived
+	}
+	if len(collection.SyncSources) == 0 {
+		return nil, entity.ErrCollectionWithoutSources
+	}
+	if collection.IsSyncing() {
+		return nil, entity.ErrCollectionAlreadySyncing
+	}
+	return s.requestSync(collection, force)
+}
+
+// ScheduleSync queues a sync on behalf of the scheduler, which works across
+// users and so looks collections up by ID.
+func (s *Service) ScheduleSync(collectionID string) (*entity.Collection, error) {
+	collection, err := s.FindCollectionByID(collectionID)
+	if err != nil {
+		return nil, err
+	}
+	if collection.IsArchived() {
+		return nil, entity.ErrCollectionArchived
+	}
+	if len(collection.SyncSources) == 0 {
+		return nil, entity.ErrCollectionWithoutSources
+	}
+	if collection.IsSyncing() {
+		return nil, entity.ErrCollectionAlreadySyncing
+	}
+	return s.requestSync(collection, false)
+}
+
+func (s *Service) FindCollectionsDueForSync(before time.Time, limit int) ([]entity.Collection, error) {
+	collections, err := s.repo.FindCollectionsDueForSync(before, limit)
+	if err != nil {
+		s.logger.Error(s.ctx, "FindCollectionsDueForSync: FindCollectionsDueForSync failed", err, map[string]any{
+			"before": before,
+			"limit":  limit,
+		})
+		return nil, err
+	}
+	return collections, nil
+}
+
+func (s *Service) CountSyncingCollections() (int, error) {
+	count, err := s.repo.CountSyncingCollections()
+	if err != nil {
+		s.logger.Error(s.ctx, "CountSyncingCollections: CountSyncingCollections failed", err, nil)
+		return 0, err
+	}
+	return count, nil
+}
+
+func (s *Service) requestSync(collection *entity.Collection, force bool) (*entity.Collection, error) {
+	collection.SyncStatus = entity.SyncStatusPending
+	collection.UpdatedAt = time.Now()
+	if err := s.repo.UpdateCollection(collection); err != nil {
+		s.logger.Error(s.ctx, "requestSync: UpdateCollection failed", err, map[string]any{
+			"userID":       collection.UserID,
+			"collectionID": collection.ID,
+		})
+		return nil, err
+	}
+	s.event.Publish(entity.NewEvent(
+		entity.EventCollectionSyncFetching,
+		collection.UserID,
+		&entity.SyncRequest{Collection: collection, ForceRefresh: force},
+	))
+	return collection, nil
+}
+
+func (s *Service) UpdateCollectionSettings(userID, slug string, opts entity.SyncOptions) (*entity.Collection, error) {
+	collection, err := s.FindCollectionBySlug(userID, slug)
+	if err != nil {
+		return nil, err
+	}
+	collection.CrawlerOptions = opts
+	collection.UpdatedAt = time.Now()
+	if err := s.repo.UpdateCollection(collection); err != nil {
+		s.logger.Error(s.ctx, "UpdateCollectionSettings: UpdateCollection failed", err, map[string]any{
+			"userID":       userID,
+			"collectionID": collection.ID,
+		})
+		return nil, err
+	}
+	return collection, nil
+}
+
+func (s *Service) UpdateSyncStatus(collectionID string, status entity.SyncStatus) error {
+	collection, err := s.repo.FindCollectionByID(collectionID)
+	if err != nil {
+		if err == entity.ErrNotFound {
+			return entity.ErrCollectionNotFound
+		}
+		s.logger.Error(s.ctx, "UpdateSyncStatus: FindCollectionByID failed", err, map[string]any{
+			"collectionID": collectionID,
+		})
+		return err
+	}
+	collection.SyncStatus = status
+	collection.UpdatedAt = time.Now()
+	if status == entity.SyncStatusSynced {
+		collection.LastSync = collection.UpdatedAt
+	}
+	if status == entity.SyncStatusSynced || status == entity.SyncStatusFailed {
+		collection.LastSyncAttempt = collection.UpdatedAt
+	}
+	if err := s.repo.UpdateCollection(collection); err != nil {
+		s.logger.Error(s.ctx, "UpdateSyncStatus: UpdateCollection failed", err, map[string]any{
+			"collectionID": collectionID,
+			"status":       status,
+		})
+		return err
+	}
+	switch status {
+	case entity.SyncStatusSynced:
+		s.event.Publish(entity.NewEvent(
+			entity.EventCollectionSyncCompleted,
+			collection.UserID,
+			collection,
+		))
+	case entity.SyncStatusFailed:
+		s.event.Publish(entity.NewEvent(
+			entity.EventCollectionSyncFailed,
+			collection.UserID,
+			collection,
+		))
+	}
+	return nil
+}
+
+// RestoreSyncStatus puts back the status a collection had before a sync that
+// could not be queued, without touching its sync times or announcing it.
+func (s *Service) RestoreSyncStatus(collectionID string, status entity.SyncStatus) error {
+	collection, err := s.repo.FindCollectionByID(collectionID)
+	if err != nil {
+		if err == entity.ErrNotFound {
+			return entity.ErrCollectionNotFound
+		}
+		s.logger.Error(s.ctx, "RestoreSyncStatus: FindCollectionByID failed", err, map[string]any{
+			"collectionID": collectionID,
+		})
+		return err
+	}
+	collection.SyncStatus = status
+	collection.UpdatedAt = time.Now()
+	if err := s.repo.UpdateCollection(collection); err != nil {
+		s.logger.Error(s.ctx, "RestoreSyncStatus: UpdateCollection failed", err, map[string]any{
+			"collectionID": collectionID,
+			"status":       status,
+		})
+		return err
+	}
+	return nil
+}
+
+func (s *Service) ListCollections(
+	userID string,
+	filter entity.CollectionFilter,
+	sort entity.CollectionSort,
+	page int,
+) (*entity.CollectionPage, error) {
+	if !entity.IsValidCollectionFilter(string(filter)) {
+		filter = entity.CollectionFilterAll
+	}
+	if !entity.IsValidCollectionSort(string(sort)) {
+		sort = entity.CollectionSortRecent
+	}
+	if page < 1 {
+		page = 1
+	}
+	result, err := s.repo.ListCollections(userID, filter, sort, page)
+	if err != nil {
+		s.logger.Error(s.ctx, "ListCollections: ListCollections failed", err, map[string]any{
+			"userID": userID,
+			"filter": filter,
+			"sort":   sort,
+			"page":   page,
+		})
+		return nil, err
+	}
+	return result, nil
+}
+
+func (s *Service) UpdateCollection(userID, collectionID string, req entity.UpdateCollectionRequest) (*entity.Collection, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	collection, err := s.findOwnedCollection(userID, collectionID)
+	if err != nil {
+		return nil, err
+	}
+	if req.Name != collection.Name {
+		// the slug only follows the name when it changes, so links to the
+		// collection keep working after unrelated edits
+		slug, err := s.ensureUniqueSlug(userID, req.Name, collection.ID)
+		if err != nil {
+			s.logger.Error(s.ctx, "UpdateCollection: ensureUniqueSlug failed", err, map[string]any{
+				"userID": userID,
+				"name":   req.Name,
+			})
+			return nil, err
+		}
+		collection.Slug = slug
+	}
+	collection.Name = req.Name
+	collection.Edition = req.Edition
+	collection.TotalVolumes = req.TotalVolumes
+	collection.Author = req.Author
+	collection.Publisher = req.Publisher
+	collection.Language = req.Language
+	collection.Tags = req.Tags
+	collection.SyncSources = req.SyncSources
+	collection.CrawlerOptions = req.CrawlerOptions
+	collection.SetSearchTerms(req.SearchTerms)
+	collection.UpdatedAt = time.Now()
+	if err := s.repo.UpdateCollection(collection); err != nil {
+		s.logger.Error(s.ctx, "UpdateCollection: UpdateCollection failed", err, map[string]any{
+			"userID":       userID,
+			"collectionID": collectionID,
+		})
+		return nil, err
+	}
+	s.event.Publish(entity.NewEvent(
+		entity.EventCollectionUpdated,
+		userID,
+		collection,
+	))
+	return collection, nil
+}
+
+func (s *Service) ArchiveCollection(userID, collectionID string) (*entity.Collection, error) {
+	collection, err := s.findOwnedCollection(userID, collectionID)
+	if err != nil {
+		return nil, err
+	}
+	if collection.IsArchived() {
+		return collection, nil
+	}
+	collection.ArchivedAt = time.Now()
+	return s.saveArchiveState(userID, collection)
+}
+
+func (s *Service) UnarchiveCollection(userID, collectionID string) (*entity.Collection, error) {
+	collection, err := s.findOwnedCollection(userID, collectionID)
+	if err != nil {
+		return nil, err
+	}
+	if !collection.IsArchived() {
+		return collection, nil
+	}
+	collection.ArchivedAt = time.Time{}
+	return s.saveArchiveState(userID, collection)
+}
+
+func (s *Service) DeleteCollection(userID, collectionID string) error {
+	collection, err := s.findOwnedCollection(userID, collectionID)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.DeleteCollection(collection.ID); err != nil {
+		s.logger.Error(s.ctx, "DeleteCollection: DeleteCollection failed", err, map[string]any{
+			"userID":       userID,
+			"collectionID": collectionID,
+		})
+		return err
+	}
+	s.event.Publish(entity.NewEvent(
+		entity.EventCollectionDeleted,
+		userID,
+		collection,
+	))
+	return nil
+}
+
+func (s *Service) saveArchiveState(userID string, collection *entity.Collection) (*entity.Collection, error) {
+	collection.UpdatedAt = time.Now()
+	if err := s.repo.UpdateCollection(collection); err != nil {
+		s.logger.Error(s.ctx, "saveArchiveState: UpdateCollection failed", err, map[string]any{
+			"userID":       userID,
+			"collectionID": collection.ID,
+			"archived":     collection.IsArchived(),
+		})
+		return nil, err
+	}
+	s.event.Publish(entity.NewEvent(
+		entity.EventCollectionUpdated,
+		userID,
+		collection,
+	))
+	return collection, nil
+}
+
+func (s *Service) findOwnedCollection(userID, collectionID string) (*entity.Collection, error) {
+	collection, err := s.repo.FindCollectionByID(collectionID)
+	if err != nil {
+		if err == entity.ErrNotFound {
+			return nil, entity.ErrCollectionNotFound
+		}
+		s.logger.Error(s.ctx, "findOwnedCollection: FindCollectionByID failed", err, map[string]any{
+			"userID":       userID,
+			"collectionID": collectionID,
+		})
+		return nil, err
+	}
+	if collection.UserID != userID {
+		s.logger.Warn(s.ctx, "findOwnedCollection: collection belongs to another user", map[string]any{
+			"userID":       userID,
+			"collectionID": collectionID,
+		})
+		return nil, entity.ErrCollectionForbidden
+	}
+	return collection, nil
+}
+
+// ensureUniqueSlug returns a slug for name that no other collection of the
+// user is using; ignoreID lets a collection keep its own slug on update.
+func (s *Service) ensureUniqueSlug(userID, name, ignoreID string) (string, error) {
 	base := entity.GenerateSlug(name)
 	slug := base
 	count := 1
 	for {
-		_, err := s.repo.FindCollectionBySlug(userID, slug)
+		existing, err := s.repo.FindCollectionBySlug(userID, slug)
 		if err != nil {
 			if err == entity.ErrNotFound {
 				return slug, nil
 			}
 			return "", err
 		}
+		if ignoreID != "" && existing.ID == ignoreID {
+			return slug, nil
+		}
 		slug = fmt.Sprintf("%s-%d", base, count)
 		count++
 	}
@@ -4,6 +4,7 @@ import (
 	"akira/internal/entity"
 	"database/sql"
 	"encoding/json"
+	"time"
 )
 
 var _ entity.CollectionRepository = (*CollectionSqliteRepository)(nil)
@@ -16,13 +17,13 @@ func NewCollectionSqliteRepository(db *sql.DB) entity.CollectionRepository {
 	return &CollectionSqliteRepository{db: db}
 }
 
-func (r *CollectionSqliteRepository) scanCollectionRow(row *sql.Row) (*entity.Collection, error) {
+func (r *CollectionSqliteRepository) scanCollectionRow(row entity.Rowscan, extra ...any) (*entity.Collection, error) {
 	var collection entity.Collection
 	var nullableEdition, nullableAuthor, nullableTags, nullableMetadata, nullableSyncSources, nullableCrawlerOptions sql.NullString
 	var nullablePublisher, nullableLang sql.NullString
 	var nullableTotalVolumes sql.NullInt32
-	var nullableLastSync sql.NullTime
-	err := row.Scan(
+	var nullableLastSync, nullableLastSyncAttempt, nullableArchivedAt sql.NullTime
+	dest := []any{
 		&collection.ID,
 		&collection.Name,
 		&nullableEdition,
@@ -39,9 +40,12 @@ func (r *CollectionSqliteRepository) scanCollectionRow(row *sql.Row) (*entity.Co
 		&nullableCrawlerOptions,
 		&nullableLang,
 		&nullableLastSync,
+		&nullableLastSyncAttempt,
+		&nullableArchivedAt,
 		&collection.CreatedAt,
 		&collection.UpdatedAt,
-	)
+	}
+	err := row.Scan(append(dest, extra...)...)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, entity.ErrNotFound
@@ -93,6 +97,12 @@ func (r *CollectionSqliteRepository) scanCollectionRow(row *sql.Row) (*entity.Co
 	if nullableLastSync.Valid {
 		collection.LastSync = nullableLastSync.Time
 	}
+	if nullableLastSyncAttempt.Valid {
+		collection.LastSyncAttempt = nullableLastSyncAttempt.Time
+	}
+	if nullableArchivedAt.Valid {
+		collection.ArchivedAt = nullableArchivedAt.Time
+	}
 	return &collection, nil
 }
 
@@ -102,8 +112,8 @@ func (r *CollectionSqliteRepository) CreateCollection(collection *entity.Collect
 			id, name, edition, slug, user_id, authors, publisher,
 			tags, metadata, release_status, sync_status, sync_sources,
 			total_volumes, crawler_options, lang, last_sync_at,
-			created_at, updated_at
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			last_sync_attempt_at, created_at, updated_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`)
 	if err != nil {
 		return err
@@ -146,6 +156,7 @@ func (r *CollectionSqliteRepository) CreateCollection(collection *entity.Collect
 		crawlerOptions, // marshal to JSON
 		collection.Language,
 		collection.LastSync,
+		sql.NullTime{Time: collection.LastSyncAttempt, Valid: !collection.LastSyncAttempt.IsZero()},
 		collection.CreatedAt,
 		collection.UpdatedAt,
 	)
@@ -155,12 +166,95 @@ func (r *CollectionSqliteRepository) CreateCollection(collection *entity.Collect
 	return nil
 }
 
+func (r *CollectionSqliteRepository) UpdateCollection(collection *entity.Collection) error {
+	stmt, err := r.db.Prepare(`
+		UPDATE collections SET
+			name = ?, edition = ?, slug = ?, authors = ?, publisher = ?,
+			tags = ?, metadata = ?, release_status = ?, sync_status = ?,
+			sync_sources = ?, total_volumes = ?, crawler_options = ?,
+			lang = ?, last_sync_at = ?, last_sync_attempt_at = ?, archived_at = ?,
+			updated_at = ?
+		WHERE id = ?
+	`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	authors, err := json.Marshal(collection.Author)
+	if err != nil {
+		return err
+	}
+	tags, err := json.Marshal(collection.Tags)
+	if err != nil {
+		return err
+	}
+	metadata, err := json.Marshal(collection.Metadata)
+	if err != nil {
+		return err
+	}
+	syncSources, err := json.Marshal(collection.SyncSources)
+	if err != nil {
+		return err
+	}
+	crawlerOptions, err := json.Marshal(collection.CrawlerOptions)
+	if err != nil {
+		return err
+	}
+	res, err := stmt.Exec(
+		collection.Name,
+		collection.Edition,
+		collection.Slug,
+		authors, // marshal to JSON
+		collection.Publisher,
+		tags,     // marshal to JSON
+		metadata, // marshal to JSON
+		collection.ReleaseStatus,
+		collection.SyncStatus,
+		syncSources, // marshal to JSON
+		collection.TotalVolumes,
+		crawlerOptions, // marshal to JSON
+		collection.Language,
+		collection.LastSync,
+		sql.NullTime{Time: collection.LastSyncAttempt, Valid: !collection.LastSyncAttempt.IsZero()},
+		sql.NullTime{Time: collection.ArchivedAt, Valid: !collection.ArchivedAt.IsZero()},
+		collection.UpdatedAt,
+		collection.ID,
+	)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return entity.ErrNotFound
+	}
+	return nil
+}
+
+func (r *CollectionSqliteRepository) FindCollectionByID(id string) (*entity.Collection, error) {
+	stmt, err := r.db.Prepare(`
+		SELECT id, name, edition, slug, user_id, authors, publisher,
+			tags, metadata, release_status, sync_status, sync_sources,
+			total_volumes, crawler_options, lang, last_sync_at, last_sync_attempt_at,
+			archived_at, created_at, updated_at
+		FROM collections WHERE id = ?
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+	row := stmt.QueryRow(id)
+	return r.scanCollectionRow(row)
+}
+
 func (r *CollectionSqliteRepository) FindCollectionBySlug(userID, slug string) (*entity.Collection, error) {
 	stmt, err := r.db.Prepare(`
 		SELECT id, name, edition, slug, user_id, authors, publisher,
 			tags, metadata, release_status, sync_status, sync_sources,
-			total_volumes, crawler_options, lang, last_sync_at,
-			created_at, updated_at
+			total_volumes, crawler_options, lang, last_sync_at, last_sync_attempt_at,
+			archived_at, created_at, updated_at
 		FROM collections WHERE user_id = ? AND slug = ?
 	`)
 	if err != nil {
@@ -170,3 +264,177 @@ func (r *CollectionSqliteRepository) FindCollectionBySlug(userID, slug string) (
 	row := stmt.QueryRow(userID, slug)
 	return r.scanCollectionRow(row)
 }
+
+func (r *CollectionSqliteRepository) ListCollections(
+	userID string,
+	filter entity.CollectionFilter,
+	sort entity.CollectionSort,
+	page int,
+) (*entity.CollectionPage, error) {
+	where := "c.user_id = ?"
+	if filter == entity.CollectionFilterArchived {
+		where += " AND c.archived_at IS NOT NULL"
+	} else {
+		where += " AND c.archived_at IS NULL"
+	}
+	switch filter {
+	case entity.CollectionFilterManga:
+		where += " AND EXISTS (SELECT 1 FROM json_each(c.tags) WHERE lower(json_each.value) = 'manga')"
+	case entity.CollectionFilterBooks:
+		where += " AND NOT EXISTS (SELECT 1 FROM json_each(c.tags) WHERE lower(json_each.value) = 'manga')"
+	case entity.CollectionFilterComplete:
+		where += " AND c.release_status = 'completed'"
+	case entity.CollectionFilterInProgress:
+		where += " AND c.release_status IN ('ongoing', 'hiatus')"
+	}
+	orderBy := "c.created_at DESC"
+	switch sort {
+	case entity.CollectionSortTitle:
+		orderBy = "c.name COLLATE NOCASE ASC"
+	case entity.CollectionSortLastSync:
+		orderBy = "c.last_sync_at IS NULL, c.last_sync_at DESC"
+	}
+	var total int
+	err := r.db.QueryRow("SELECT COUNT(*) FROM collections c WHERE "+where, userID).Scan(&total)
+	if err != nil {
+		return nil, err
+	}
+	stmt, err := r.db.Prepare(`
+		SELECT c.id, c.name, c.edition, c.slug, c.user_id, c.authors, c.publisher,
+			c.tags, c.metadata, c.release_status, c.sync_status, c.sync_sources,
+			c.total_volumes, c.crawler_options, c.lang, c.last_sync_at, c.last_sync_attempt_at,
+			c.archived_at, c.created_at, c.updated_at,
+			(
+				SELECT b.cover_image FROM books b
+				INNER JOIN collection_books cb ON cb.book_id = b.id
+				WHERE cb.collection_id = c.id AND b.cover_image <> ''
+				ORDER BY b.volume IS NULL, b.volume
+				LIMIT 1
+			) AS cover_image,
+			(
+				SELECT COUNT(DISTINCT b.volume) FROM books b
+				INNER JOIN collection_books cb ON cb.book_id = b.id
+				WHERE cb.collection_id = c.id AND b.volume > 0
+			) AS volumes_count,
+			(
+				SELECT COUNT(DISTINCT b.volume) FROM books b
+				INNER JOIN collection_books cb ON cb.book_id = b.id
+				WHERE cb.collection_id = c.id AND b.volume > 0 AND b.ownership = 'owned'
+			) AS owned_count
+		FROM collections c
+		WHERE ` + where + `
+		ORDER BY ` + orderBy + `
+		LIMIT ? OFFSET ?
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+	if page < 1 {
+		page = 1
+	}
+	rows, err := stmt.Query(userID, entity.CollectionsPerPage, (page-1)*entity.CollectionsPerPage)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := make([]entity.CollectionSummary, 0)
+	for rows.Next() {
+		var coverImage sql.NullString
+		var volumesCount, ownedCount int
+		collection, err := r.scanCollectionRow(rows, &coverImage, &volumesCount, &ownedCount)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, entity.CollectionSummary{
+			Collection:   *collection,
+			CoverImage:   coverImage.String,
+			VolumesCount: volumesCount,
+			OwnedCount:   ownedCount,
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return entity.NewCollectionPage(items, filter, sort, page, total), nil
+}
+
+func (r *CollectionSqliteRepository) DeleteCollection(id string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	_, err = tx.Exec(`
+		DELETE FROM books WHERE id IN (
+			SELECT book_id FROM collection_books WHERE collection_id = ?
+		)
+	`, id)
+	if err != nil {
+		return err
+	}
+	res, err := tx.Exec("DELETE FROM collections WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return entity.ErrNotFound
+	}
+	return tx.Commit()
+}
+
+// FindCollectionsDueForSync returns the auto synced collections, oldest sync
+// first, that were last synced and last attempted to sync before the given
+// time and are not syncing.
+func (r *CollectionSqliteRepository) FindCollectionsDueForSync(before time.Time, limit int) ([]entity.Collection, error) {
+	stmt, err := r.db.Prepare(`
+		SELECT id, name, edition, slug, user_id, authors, publisher,
+			tags, metadata, release_status, sync_status, sync_sources,
+			total_volumes, crawler_options, lang, last_sync_at, last_sync_attempt_at,
+			archived_at, created_at, updated_at
+		FROM collections
+		WHERE archived_at IS NULL
+			AND json_extract(crawler_options, '$.AutoSync') = 1
+			AND json_array_length(sync_sources) > 0
+			AND sync_status NOT IN (?, ?)
+			AND (last_sync_at IS NULL OR last_sync_at < ?)
+			AND (last_sync_attempt_at IS NULL OR last_sync_attempt_at < ?)
+		ORDER BY last_sync_at IS NOT NULL, last_sync_at
+		LIMIT ?
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+	rows, err := stmt.Query(entity.SyncStatusPending, entity.SyncStatusFetching, before, before, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	collections := make([]entity.Collection, 0)
+	for rows.Next() {
+		collection, err := r.scanCollectionRow(rows)
+		if err != nil {
+			return nil, err
+		}
+		collections = append(collections, *collection)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return collections, nil
+}
+
+func (r *CollectionSqliteRepository) CountSyncingCollections() (int, error) {
+	var count int
+	err := r.db.QueryRow(
+		"SELECT COUNT(*) FROM collections WHERE archived_at IS NULL AND sync_status IN (?, ?)",
+		entity.SyncStatusPending,
+		entity.SyncStatusFetching,
+	).Scan(&count)
+	return count, err
+}
@@ -3,19 +3,29 @@ package crawler
 import (
 	"akira/internal/entity"
 	"context"
+	"sync"
 	"time"
 )
 
 var _ entity.CrawlerConsumer = (*Consumer)(nil)
 
+// completedRunsLookup is how many recent runs are checked for a completed one
+// before announcing volume changes.
+const completedRunsLookup = 10
+
 type Consumer struct {
 	service    entity.CrawlerService
 	collection entity.CollectionService
 	book       entity.BookService
+	price      entity.PriceService
+	review     entity.ReviewService
+	candidate  entity.CandidateService
+	event      entity.EventService
 	sub        *entity.Subscriber
 	logger     entity.Logger
 	ctx        context.Context
 	cancelFunc context.CancelFunc
+	locks      sync.Map
 }
 
 func NewConsumer(
@@ -23,6 +33,9 @@ func NewConsumer(
 	service entity.CrawlerService,
 	collection entity.CollectionService,
 	book entity.BookService,
+	price entity.PriceService,
+	review entity.ReviewService,
+	candidate entity.CandidateService,
 	event entity.EventService,
 	logger entity.Logger,
 ) *Consumer {
@@ -31,6 +44,10 @@ func NewConsumer(
 		service:    service,
 		collection: collection,
 		book:       book,
+		price:      price,
+		review:     review,
+		candidate:  candidate,
+		event:      event,
 		logger:     logger,
 		ctx:        consumerCtx,
 		cancelFunc: cancel,
@@ -39,9 +56,14 @@ func NewConsumer(
 		"crawler-consumer",
 		cancel,
 		entity.EventCollectionCreated,
+		entity.EventCollectionUpdated,
+		entity.EventCollectionDeleted,
 		entity.EventCollectionSyncFetching,
+		entity.EventCrawlerStarted,
 		entity.EventCrawlerCompleted,
+		entity.EventCrawlerFailed,
 		entity.EventCrawlerItemFounded,
+		entity.EventCrawlerCandidateAccepted,
 	)
 	go consumer.ConsumeEvents()
 	return consumer
@@ -74,15 +96,25 @@ func (c *Consumer) ConsumeEvents() {
 						"collection_id": event.Data,
 					})
 					c.handleCollectionCreated(event)
+				case entity.EventCollectionUpdated:
+					c.handleCollectionUpdated(event)
+				case entity.EventCollectionDeleted:
+					c.handleCollectionDeleted(event)
 				case entity.EventCollectionSyncFetching:
-					// Handle the sync fetching event
+					c.handleCollectionSyncFetching(event)
+				case entity.EventCrawlerStarted:
+					c.handleCrawlerStarted(event)
 				case entity.EventCrawlerCompleted:
-					// Handle the crawler completed event
+					c.handleCrawlerFinished(event, entity.SyncStatusSynced)
+				case entity.EventCrawlerFailed:
+					c.handleCrawlerFinished(event, entity.SyncStatusFailed)
 				case entity.EventCrawlerItemFounded:
 					c.logger.Info(c.ctx, "crawler item founded event received", map[string]any{
 						"collection_id": event.Data,
 					})
 					c.handleCrawlerItemFounded(event)
+				case entity.EventCrawlerCandidateAccepted:
+					c.handleCandidateAccepted(event)
 				}
 			}(event)
 		}
@@ -110,18 +142,140 @@ func (c *Consumer) handleCollectionCreated(event entity.Event) {
 		})
 		return
 	}
-	if !data.CrawlerOptions.TrackNewVolumes {
-		c.logger.Info(c.ctx, "collection not configured to track new volumes", map[string]any{
-			"collection_id": data.ID,
+	c.startCrawler(data, false)
+}
+
+func (c *Consumer) handleCollectionSyncFetching(event entity.Event) {
+	data, ok := event.Data.(*entity.SyncRequest)
+	if !ok {
+		c.logger.Warn(c.ctx, "invalid event data type", map[string]any{
+			"expected": "*entity.SyncRequest",
+			"received": event.Data,
 		})
 		return
 	}
-	searchTerms := []string{data.Name}
-	if data.Metadata != nil {
-		if terms, ok := data.Metadata["search_terms"]; ok {
-			searchTerms = append(searchTerms, terms)
+	c.startCrawler(data.Collection, data.ForceRefresh)
+}
+
+func (c *Consumer) handleCollectionUpdated(event entity.Event) {
+	data, ok := event.Data.(*entity.Collection)
+	if !ok {
+		c.logger.Warn(c.ctx, "invalid event data type", map[string]any{
+			"expected": "*entity.Collection",
+			"received": event.Data,
+		})
+		return
+	}
+	if data.IsArchived() {
+		c.cancelCrawler(data.ID, "collection archived")
+	}
+}
+
+func (c *Consumer) handleCollectionDeleted(event entity.Event) {
+	data, ok := event.Data.(*entity.Collection)
+	if !ok {
+		c.logger.Warn(c.ctx, "invalid event data type", map[string]any{
+			"expected": "*entity.Collection",
+			"received": event.Data,
+		})
+		return
+	}
+	c.cancelCrawler(data.ID, "collection deleted")
+	c.locks.Delete(data.ID)
+}
+
+func (c *Consumer) cancelCrawler(collectionID, reason string) {
+	err := c.service.CancelFetch(collectionID)
+	if err != nil {
+		if err != entity.ErrCrawlerNotRunning && err != entity.ErrCrawlerCannotBeCancelled {
+			c.logger.Error(c.ctx, "failed to cancel crawler", err, map[string]any{
+				"collection_id": collectionID,
+				"reason":        reason,
+			})
 		}
+		return
+	}
+	c.logger.Info(c.ctx, "crawler cancelled", map[string]any{
+		"collection_id": collectionID,
+		"reason":        reason,
+	})
+}
+
+// handleCrawlerStarted moves the collection from pending to fetching once a
+// worker picks its run from the queue. Events are handled concurrently, so
+// the run is checked to be still running under the collection lock shared
+// with handleCrawlerFinished, or a quick run would be left as fetching.
+func (c *Consumer) handleCrawlerStarted(event entity.Event) {
+	data, ok := event.Data.(map[string]any)
+	if !ok {
+		c.logger.Warn(c.ctx, "invalid event data type", map[string]any{
+			"expected": "map[string]any",
+			"received": event.Data,
+		})
+		return
+	}
+	collectionID, ok := data["collection_id"].(string)
+	if !ok || collectionID == "" {
+		c.logger.Warn(c.ctx, "invalid collection ID", nil)
+		return
+	}
+	lock, _ := c.locks.LoadOrStore(collectionID, &sync.Mutex{})
+	lock.(*sync.Mutex).Lock()
+	defer lock.(*sync.Mutex).Unlock()
+	if status, err := c.service.GetStatus(collectionID); err != nil || status != entity.SyncStatusFetching {
+		return
+	}
+	if err := c.collection.UpdateSyncStatus(collectionID, entity.SyncStatusFetching); err != nil && err != entity.ErrCollectionNotFound {
+		c.logger.Error(c.ctx, "failed to update collection sync status", err, map[string]any{
+			"collection_id": collectionID,
+			"status":        entity.SyncStatusFetching,
+		})
+	}
+}
+
+func (c *Consumer) handleCrawlerFinished(event entity.Event, status entity.SyncStatus) {
+	data, ok := event.Data.(map[string]any)
+	if !ok {
+		c.logger.Warn(c.ctx, "invalid event data type", map[string]any{
+			"expected": "map[string]any",
+			"received": event.Data,
+		})
+		return
+	}
+	collectionID, ok := data["collection_id"].(string)
+	if !ok || collectionID == "" {
+		c.logger.Warn(c.ctx, "invalid collection ID", nil)
+		return
+	}
+	if status == entity.SyncStatusSynced {
+		c.refreshReleaseInfo(event.UserID, collectionID)
 	}
+	lock, _ := c.locks.LoadOrStore(collectionID, &sync.Mutex{})
+	lock.(*sync.Mutex).Lock()
+	defer lock.(*sync.Mutex).Unlock()
+	if err := c.collection.UpdateSyncStatus(collectionID, status); err != nil && err != entity.ErrCollectionNotFound {
+		c.logger.Error(c.ctx, "failed to update collection sync status", err, map[string]any{
+			"collection_id": collectionID,
+			"status":        status,
+		})
+	}
+}
+
+func (c *Consumer) startCrawler(data *entity.Collection, force bool) {
+	if data.IsArchived() {
+		c.logger.Info(c.ctx, "collection is archived", map[string]any{
+			"collection_id": data.ID,
+		})
+		return
+	}
+	if len(data.SyncSources) == 0 {
+		c.logger.Info(c.ctx, "collection without sync sources", map[string]any{
+			"collection_id": data.ID,
+		})
+		return
+	}
+	searchTerms := []string{data.Name}
+	searchTerms = append(searchTerms, data.SearchTerms()...)
 	// searchTerms = append(searchTerms, data.Edition)
 	searchTerms = append(searchTerms, data.Author...)
 
@@ -130,9 +284,12 @@ func (c *Consumer) handleCollectionCreated(event entity.Event) {
 		Timeout:         3 * time.Minute,
 		MaxConcurrency:  2,
 		RequestInterval: 3 * time.Second,
+		ImportReviews:   data.CrawlerOptions.TrackReviews,
+		ForceRefresh:    force,
 	}
 
 	req := entity.CrawlerRequest{
+		UserID:       data.UserID,
 		CollectionID: data.ID,
 		SearchTerms:  searchTerms,
 		Sites:        data.SyncSources,
@@ -142,11 +299,15 @@ func (c *Consumer) handleCollectionCreated(event entity.Event) {
 		"collection_id": data.ID,
 		"search_terms":  searchTerms,
 		"sites":         data.SyncSources,
+		"force_refresh": force,
 	})
 	if err := c.service.FetchCollection(c.ctx, req); err != nil {
 		c.logger.Error(c.ctx, "failed to start crawler", err, map[string]any{
 			"collection_id": data.ID,
 		})
+		if err != entity.ErrCrawlerAlreadyRunning {
+			c.collection.UpdateSyncStatus(data.ID, entity.SyncStatusFailed)
+		}
 	}
 }
 
@@ -169,6 +330,12 @@ func (c *Consumer) handleCrawlerItemFounded(event entity.Event) {
 		c.logger.Warn(c.ctx, "invalid crawled result", nil)
 		return
 	}
+	if event.UserID == "" {
+		c.logger.Warn(c.ctx, "crawled item without user ID", map[string]any{
+			"collection_id": collectionID,
+		})
+		return
+	}
 	c.logger.Debug(c.ctx, "crawled item founded", map[string]any{
 		"collection_id": collectionID,
 		"title":         result.Title,
@@ -177,4 +344,274 @@ func (c *Consumer) handleCrawlerItemFounded(event entity.Event) {
 		"price":         result.Price,
 		"source":        result.Source,
 	})
+	runID, _ := data["run_id"].(string)
+	c.persistCrawledResult(event.UserID, collectionID, runID, result, false)
+}
+
+func (c *Consumer) handleCandidateAccepted(event entity.Event) {
+	data, ok := event.Data.(map[string]any)
+	if !ok {
+		c.logger.Warn(c.ctx, "invalid event data type", map[string]any{
+			"expected": "map[string]any",
+			"received": event.Data,
+		})
+		return
+	}
+	collectionID, ok := data["collection_id"].(string)
+	if !ok || collectionID == "" {
+		c.logger.Warn(c.ctx, "invalid collection ID", nil)
+		return
+	}
+	result, ok := data["result"].(entity.CrawledResult)
+	if !ok {
+		c.logger.Warn(c.ctx, "invalid crawled result", nil)
+		return
+	}
+	runID, _ := data["run_id"].(string)
+	c.persistCrawledResult(event.UserID, collectionID, runID, result, true)
+}
+
+// persistCrawledResult updates the book the result is a listing of. Results
+// of volumes not in the collection yet wait in the review queue, unless the
+// user has just accepted them from it.
+func (c *Consumer) persistCrawledResult(userID, collectionID, runID string, result entity.CrawledResult, accepted bool) {
+	// item founded events are handled concurrently, so lock per collection
+	// to avoid persisting the same volume twice
+	lock, _ := c.locks.LoadOrStore(collectionID, &sync.Mutex{})
+	lock.(*sync.Mutex).Lock()
+	defer lock.(*sync.Mutex).Unlock()
+
+	collection, err := c.collection.FindCollectionByID(collectionID)
+	if err != nil {
+		if err != entity.ErrCollectionNotFound {
+			c.logger.Error(c.ctx, "failed to find collection", err, map[string]any{
+				"collection_id": collectionID,
+			})
+		}
+		return
+	}
+	if !accepted {
+		rejected, err := c.candidate.IsRejected(collectionID, result)
+		if err != nil {
+			return
+		}
+		if rejected {
+			c.logger.Debug(c.ctx, "crawled item rejected before", map[string]any{
+				"collection_id": collectionID,
+				"url":           result.URL,
+			})
+			return
+		}
+	}
+	books, err := c.book.FindCollectionBooks(userID, collectionID)
+	if err != nil {
+		c.logger.Error(c.ctx, "failed to find collection books", err, map[string]any{
+			"collection_id": collectionID,
+		})
+		return
+	}
+	if existing := findMatchingBook(books, result); existing != nil {
+		c.logger.Debug(c.ctx, "crawled item already in collection", map[string]any{
+			"collection_id": collectionID,
+			"book_id":       existing.ID,
+			"volume":        result.Volume,
+			"isbn":          result.ISBN,
+		})
+		change, changed := entity.DetectVolumeChange(existing, result)
+		presaleEnded := existing.Metadata["presale"] == "true" && !result.IsPresale()
+		if changed || presaleEnded {
+			updated, err := c.book.UpdateBookRelease(existing.ID, result.ReleaseDate(), result.IsPresale())
+			if err != nil {
+				c.logger.Error(c.ctx, "failed to update book release", err, map[string]any{
+					"collection_id": collectionID,
+					"book_id":       existing.ID,
+				})
+				return
+			}
+			existing = updated
+		}
+		if changed {
+			c.publishVolumeChange(collection, change)
+		}
+		c.recordPrice(collection, existing, result)
+		c.saveReviews(collection, existing, result)
+		return
+	}
+	if !accepted {
+		candidate, err := c.candidate.SubmitCandidate(collection, runID, result)
+		if err != nil {
+			return
+		}
+		c.logger.Info(c.ctx, "crawled item queued for review", map[string]any{
+			"collection_id": collectionID,
+			"candidate_id":  candidate.ID,
+			"volume":        result.Volume,
+			"confidence":    candidate.Confidence,
+		})
+		return
+	}
+	change, changed := entity.DetectVolumeChange(nil, result)
+	book, err := c.book.CreateCollectionBook(userID, collectionID, newBookRequest(result))
+	if err != nil {
+		c.logger.Error(c.ctx, "failed to persist crawled item", err, map[string]any{
+			"collection_id": collectionID,
+			"title":         result.Title,
+			"source":        result.Source,
+		})
+		return
+	}
+	c.logger.Info(c.ctx, "crawled item persisted", map[string]any{
+		"collection_id": collectionID,
+		"book_id":       book.ID,
+		"volume":        result.Volume,
+	})
+	if changed {
+		change.BookID = book.ID
+		c.publishVolumeChange(collection, change)
+	}
+	c.recordPrice(collection, book, result)
+	c.saveReviews(collection, book, result)
+}
+
+// publishVolumeChange announces a change found by a sync. The first sync of a
+// collection only fills it in, so there is nothing new to announce yet.
+func (c *Consumer) publishVolumeChange(collection *entity.Collection, change entity.VolumeChange) {
+	if !collection.CrawlerOptions.TrackNewVolumes || !c.hasCompletedSync(collection.ID) {
+		return
+	}
+	change.CollectionID = collection.ID
+	c.logger.Info(c.ctx, "volume change detected", map[string]any{
+		"collection_id": collection.ID,
+		"book_id":       change.BookID,
+		"kind":          change.Kind,
+		"volume":        change.Volume,
+	})
+	c.event.Publish(entity.NewEvent(
+		entity.EventCollectionNewVolume,
+		collection.UserID,
+		change,
+	))
+}
+
+func (c *Consumer) hasCompletedSync(collectionID string) bool {
+	runs, err := c.service.ListRuns(collectionID, completedRunsLookup)
+	if err != nil {
+		c.logger.Error(c.ctx, "failed to list crawl runs", err, map[string]any{
+			"collection_id": collectionID,
+		})
+		return false
+	}
+	for _, run := range runs {
+		if run.Status == entity.CrawlRunCompleted {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Consumer) refreshReleaseInfo(userID, collectionID string) {
+	books, err := c.book.FindCollectionBooks(userID, collectionID)
+	if err != nil {
+		c.logger.Error(c.ctx, "failed to find collection books", err, map[string]any{
+			"collection_id": collectionID,
+		})
+		return
+	}
+	if _, err := c.collection.RefreshReleaseInfo(collectionID, books); err != nil && err != entity.ErrCollectionNotFound {
+		c.logger.Error(c.ctx, "failed to refresh collection release info", err, map[string]any{
+			"collection_id": collectionID,
+		})
+	}
+}
+
+// recordPrice records the price of every store listing of the result.
+func (c *Consumer) recordPrice(collection *entity.Collection, book *entity.Book, result entity.CrawledResult) {
+	for _, listing := range result.Listings() {
+		if listing.Price <= 0 {
+			continue
+		}
+		if err := c.price.RecordPrice(collection, book, listing); err != nil {
+			c.logger.Error(c.ctx, "failed to record price", err, map[string]any{
+				"collection_id": collection.ID,
+				"book_id":       book.ID,
+				"source":        listing.Source,
+			})
+		}
+	}
+}
+
+// saveReviews stores the reviews of every store listing of the result, so
+// each review keeps the store it came from.
+func (c *Consumer) saveReviews(collection *entity.Collection, book *entity.Book, result entity.CrawledResult) {
+	if !collection.CrawlerOptions.TrackReviews {
+		return
+	}
+	for _, listing := range result.Listings() {
+		if len(listing.Reviews) == 0 {
+			continue
+		}
+		created, err := c.review.SaveReviews(book, listing)
+		if err != nil {
+			c.logger.Error(c.ctx, "failed to save reviews", err, map[string]any{
+				"collection_id": collection.ID,
+				"book_id":       book.ID,
+				"source":        listing.Source,
+			})
+			continue
+		}
+		if created > 0 {
+			c.logger.Info(c.ctx, "reviews saved", map[string]any{
+				"collection_id": collection.ID,
+				"book_id":       book.ID,
+				"source":        listing.Source,
+				"count":         created,
+			})
+		}
+	}
+}
+
+func findMatchingBook(books []entity.Book, result entity.CrawledResult) *entity.Book {
+	for i := range books {
+		// older books may keep an ISBN-10 or an ASIN as their ISBN
+		if entity.SameISBN(books[i].ISBN, result.ISBN) {
+			return &books[i]
+		}
+	}
+	if result.Volume <= 0 {
+		return nil
+	}
+	for i := range books {
+		if books[i].Volume != nil && *books[i].Volume == result.Volume {
+			return &books[i]
+		}
+	}
+	return nil
+}
+
+func newBookRequest(result entity.CrawledResult) entity.CreateBookRequest {
+	var volume *int
+	if result.Volume > 0 {
+		v := result.Volume
+		volume = &v
+	}
+	metadata := make(map[string]string, len(result.Metadata)+2)
+	for k, v := range result.Metadata {
+		metadata[k] = v
+	}
+	metadata["source"] = result.Source
+	metadata["source_url"] = result.URL
+	return entity.CreateBookRequest{
+		Name:        result.Title,
+		Description: result.Description,
+		CoverImage:  result.CoverImage,
+		Volume:      volume,
+		Rating:      result.Rating,
+		Publisher:   result.Publisher,
+		Author:      result.Author,
+		ISBN:        entity.CanonicalISBN(result.ISBN),
+		Tags:        result.Tags,
+		Metadata:    metadata,
+		Language:    result.Language,
+		ReleasedAt:  result.ReleaseDate(),
+	}
 }
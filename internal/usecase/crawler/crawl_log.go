@@ -0,0 +1,156 @@
+package crawler
+
+import (
+	"akira/internal/entity"
+	"context"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+var _ entity.Logger = (*crawlLog)(nil)
+
+// crawlLogMaxEntries caps the entries kept for a run, so a crawl gone wrong
+// can't fill the database. Later events still reach the service logger.
+const crawlLogMaxEntries = 2000
+
+// crawlLog is the logger of a crawl run and its providers. It tags the
+// events with the run and the site that logged them, passes them on to the
+// service logger and keeps them with save, in the log of the run.
+type crawlLog struct {
+	logger entity.Logger
+	save   func(entry *entity.CrawlLogEntry) error
+	run    *entity.CrawlRun
+	site   string
+	// count is shared by the loggers of all sites of the run
+	count *atomic.Int64
+}
+
+func newCrawlLog(logger entity.Logger, save func(entry *entity.CrawlLogEntry) error, run *entity.CrawlRun) *crawlLog {
+	return &crawlLog{logger: logger, save: save, run: run, count: &atomic.Int64{}}
+}
+
+// forSite returns the logger of a provider of the run.
+func (l *crawlLog) forSite(site string) *crawlLog {
+	log := *l
+	log.site = site
+	return &log
+}
+
+func (l *crawlLog) Info(ctx context.Context, msg string, args map[string]any) {
+	l.logger.Info(ctx, msg, l.tag(args))
+	l.record(entity.CrawlLogInfo, msg, nil, args)
+}
+
+func (l *crawlLog) Error(ctx context.Context, msg string, err error, args map[string]any) {
+	l.logger.Error(ctx, msg, err, l.tag(args))
+	l.record(entity.CrawlLogError, msg, err, args)
+}
+
+func (l *crawlLog) Warn(ctx context.Context, msg string, args map[string]any) {
+	l.logger.Warn(ctx, msg, l.tag(args))
+	l.record(entity.CrawlLogWarn, msg, nil, args)
+}
+
+func (l *crawlLog) Debug(ctx context.Context, msg string, args map[string]any) {
+	l.logger.Debug(ctx, msg, l.tag(args))
+	l.record(entity.CrawlLogDebug, msg, nil, args)
+}
+
+// Close does nothing, the service logger outlives the run.
+func (l *crawlLog) Close() {}
+
+func (l *crawlLog) tag(args map[string]any) map[string]any {
+	tagged := make(map[string]any, len(args)+3)
+	for k, v := range args {
+		tagged[k] = v
+	}
+	tagged["run_id"] = l.run.ID
+	if l.run.CollectionID != "" {
+		tagged["collection_id"] = l.run.CollectionID
+	}
+	if l.site != "" {
+		tagged["site"] = l.site
+	}
+	return tagged
+}
+
+func (l *crawlLog) record(level entity.CrawlLogLevel, msg string, err error, args map[string]any) {
+	count := l.count.Add(1)
+	if count > crawlLogMaxEntries+1 {
+		return
+	}
+	entry := &entity.CrawlLogEntry{
+		ID:           entity.NewID(),
+		RunID:        l.run.ID,
+		CollectionID: l.run.CollectionID,
+		Site:         l.site,
+		Level:        level,
+		Message:      msg,
+		Fields:       plainFields(args),
+		CreatedAt:    time.Now(),
+	}
+	if count > crawlLogMaxEntries {
+		entry.Level = entity.CrawlLogWarn
+		entry.Message = "crawl log truncated"
+		entry.Fields = map[string]any{"max_entries": crawlLogMaxEntries}
+	} else if err != nil {
+		entry.Error = err.Error()
+	}
+	if err := l.save(entry); err != nil {
+		l.logger.Warn(context.Background(), "failed to write crawl log", map[string]any{
+			"run_id": l.run.ID,
+			"error":  err.Error(),
+		})
+	}
+}
+
+// plainFields turns errors and other values with a String method into text,
+// as they are lost when the fields are stored as JSON.
+func plainFields(args map[string]any) map[string]any {
+	if len(args) == 0 {
+		return nil
+	}
+	fields := make(map[string]any, len(args))
+	for k, v := range args {
+		switch value := v.(type) {
+		case error:
+			fields[k] = value.Error()
+		case fmt.Stringer:
+			fields[k] = value.String()
+		default:
+			fields[k] = v
+		}
+	}
+	return fields
+}
+
+// transport logs every page the provider requests, with its status and how
+// long it took, cached pages included.
+func (l *crawlLog) transport(next http.RoundTripper) http.RoundTripper {
+	return &visitTransport{next: next, log: l}
+}
+
+type visitTransport struct {
+	next http.RoundTripper
+	log  *crawlLog
+}
+
+func (t *visitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		t.log.Warn(req.Context(), "page visit failed", map[string]any{
+			"url":   req.URL.String(),
+			"error": err.Error(),
+		})
+		return nil, err
+	}
+	t.log.Debug(req.Context(), "page visited", map[string]any{
+		"url":         req.URL.String(),
+		"status":      resp.StatusCode,
+		"duration_ms": time.Since(start).Milliseconds(),
+	})
+	return resp, nil
+}
@@ -2,17 +2,50 @@ package crawler
 
 import (
 	"akira/internal/entity"
+	"akira/internal/usecase/crawler/provider"
 	"context"
+	"database/sql"
 )
 
 func Make(
 	ctx context.Context,
+	db *sql.DB,
 	event entity.EventService,
 	book entity.BookService,
 	collection entity.CollectionService,
+	price entity.PriceService,
+	review entity.ReviewService,
+	candidate entity.CandidateService,
 	logger entity.Logger,
+	providersDir string,
+	policy entity.CrawlPolicy,
 ) (entity.CrawlerService, entity.CrawlerConsumer) {
-	service := NewService(ctx, event, logger)
-	consumer := NewConsumer(ctx, service, collection, book, event, logger)
+	repo := NewCrawlRunSqliteRepository(db)
+	pages := NewPageCacheSqliteRepository(db)
+	service := NewService(ctx, repo, pages, event, logger, policy)
+	definitions, err := provider.LoadSiteDefinitions(providersDir)
+	if err != nil {
+		logger.Error(ctx, "failed to load site definitions", err, map[string]any{
+			"dir": providersDir,
+		})
+	}
+	for _, def := range definitions {
+		err := service.RegisterProvider(func() entity.SiteProvider {
+			return provider.NewDeclarativeProvider(def)
+		})
+		if err != nil {
+			logger.Warn(ctx, "site definition skipped", map[string]any{
+				"site":  def.Name,
+				"file":  def.Path,
+				"error": err.Error(),
+			})
+			continue
+		}
+		logger.Info(ctx, "site provider registered", map[string]any{
+			"site": def.Name,
+			"file": def.Path,
+		})
+	}
+	consumer := NewConsumer(ctx, service, collection, book, price, review, candidate, event, logger)
 	return service, consumer
 }
@@ -0,0 +1,122 @@
+package crawler
+
+import (
+	"akira/internal/entity"
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	crawlDebugTimeout  = 5 * time.Minute
+	crawlDebugMaxPages = 10
+)
+
+// DebugFetch runs the provider of the site alone, without a collection or a
+// queued run. It goes through the page cache and the politeness limits like
+// a sync, while its log is kept in the report only.
+func (s *Service) DebugFetch(ctx context.Context, req entity.CrawlDebugRequest) (*entity.CrawlDebugReport, error) {
+	factory, ok := s.providers[req.Site]
+	if !ok {
+		return nil, entity.ErrCrawlerProviderNotFound
+	}
+	if len(req.SearchTerms) == 0 {
+		return nil, entity.ErrCrawlerNoSearchTerms
+	}
+	ctx, cancel := context.WithTimeoutCause(ctx, crawlDebugTimeout, entity.ErrCrawlRunTimeout)
+	defer cancel()
+	report := &entity.CrawlDebugReport{
+		Site:        req.Site,
+		SearchTerms: req.SearchTerms,
+	}
+	var mu sync.Mutex
+	// requests a provider leaves behind when it stops are not reported
+	done := false
+	run := &entity.CrawlRun{ID: entity.NewID(), SearchTerms: req.SearchTerms, Sites: []string{req.Site}}
+	log := newCrawlLog(s.logger, func(entry *entity.CrawlLogEntry) error {
+		mu.Lock()
+		defer mu.Unlock()
+		if !done {
+			report.Log = append(report.Log, *entry)
+		}
+		return nil
+	}, run).forSite(req.Site)
+	opts := entity.CrawlerOptions{
+		MaxPages:        min(max(req.MaxPages, 1), crawlDebugMaxPages),
+		Timeout:         crawlDebugTimeout,
+		MaxConcurrency:  1,
+		RequestInterval: time.Second,
+		ForceRefresh:    req.ForceRefresh,
+		Logger:          log,
+	}
+	opts.Transport = &debugTransport{
+		next: log.transport(s.pages.transport(
+			s.politeness.transport(ctx, req.Site, nil),
+			req.ForceRefresh,
+		)),
+		record: func(visit entity.CrawlDebugVisit) {
+			mu.Lock()
+			defer mu.Unlock()
+			if !done {
+				report.Visits = append(report.Visits, visit)
+			}
+		},
+	}
+	log.Info(ctx, "debug fetch started", map[string]any{
+		"search_terms":  req.SearchTerms,
+		"max_pages":     opts.MaxPages,
+		"force_refresh": req.ForceRefresh,
+	})
+	start := time.Now()
+	provider := factory()
+	if err := provider.Setup(opts); err != nil {
+		log.Error(ctx, "provider setup failed", err, nil)
+		return nil, err
+	}
+	results, err := provider.Fetch(ctx, req.SearchTerms)
+	report.Duration = time.Since(start)
+	if err != nil {
+		log.Error(ctx, "provider fetch failed", err, map[string]any{
+			"results": len(results),
+		})
+		report.Error = err.Error()
+	}
+	clusters, decisions := clusterResults(results)
+	for _, decision := range decisions {
+		report.Results = append(report.Results, entity.CrawlDebugResult{
+			Result:          decision.result,
+			ExtractedVolume: entity.ExtractVolumeNumber(decision.result.Title),
+			SeriesKey:       seriesKey(decision.result),
+			Merged:          decision.cluster,
+			MergeReason:     decision.reason,
+		})
+	}
+	for _, cluster := range clusters {
+		report.Volumes = append(report.Volumes, cluster.canonical())
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	done = true
+	return report, nil
+}
+
+// debugTransport records every request of a debug fetch for its report.
+type debugTransport struct {
+	next   http.RoundTripper
+	record func(visit entity.CrawlDebugVisit)
+}
+
+func (t *debugTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	visit := entity.CrawlDebugVisit{URL: req.URL.String(), Duration: time.Since(start)}
+	if err != nil {
+		visit.Error = err.Error()
+		t.record(visit)
+		return nil, err
+	}
+	visit.Status = resp.StatusCode
+	t.record(visit)
+	return resp, nil
+}
@@ -0,0 +1,143 @@
+package crawler
+
+import (
+	"akira/internal/entity"
+	"sort"
+	"sync"
+	"time"
+)
+
+var _ entity.CrawlRunRepository = (*MemoRepository)(nil)
+
+type MemoRepository struct {
+	mu   sync.Mutex
+	runs map[string]*entity.CrawlRun
+	logs []entity.CrawlLogEntry
+}
+
+func NewMemoRepository() *MemoRepository {
+	return &MemoRepository{
+		runs: make(map[string]*entity.CrawlRun),
+	}
+}
+
+func (r *MemoRepository) CreateCrawlRun(run *entity.CrawlRun) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored := *run
+	r.runs[run.ID] = &stored
+	return nil
+}
+
+func (r *MemoRepository) UpdateCrawlRun(run *entity.CrawlRun) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.runs[run.ID]; !ok {
+		return entity.ErrNotFound
+	}
+	stored := *run
+	r.runs[run.ID] = &stored
+	return nil
+}
+
+func (r *MemoRepository) ClaimNextCrawlRun(now time.Time) (*entity.CrawlRun, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	queued := r.sorted(func(run *entity.CrawlRun) bool {
+		return run.Status == entity.CrawlRunQueued
+	}, false)
+	if len(queued) == 0 {
+		return nil, entity.ErrNotFound
+	}
+	run := r.runs[queued[0].ID]
+	run.Status = entity.CrawlRunRunning
+	run.Attempts++
+	run.StartedAt = now
+	run.UpdatedAt = now
+	claimed := *run
+	return &claimed, nil
+}
+
+func (r *MemoRepository) CancelQueuedCrawlRun(run *entity.CrawlRun) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored, ok := r.runs[run.ID]
+	if !ok || stored.Status != entity.CrawlRunQueued {
+		return entity.ErrNotFound
+	}
+	stored.Status = run.Status
+	stored.Error = run.Error
+	stored.FinishedAt = run.FinishedAt
+	stored.UpdatedAt = run.UpdatedAt
+	return nil
+}
+
+func (r *MemoRepository) FindLatestCrawlRun(collectionID string) (*entity.CrawlRun, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	runs := r.sorted(func(run *entity.CrawlRun) bool {
+		return run.CollectionID == collectionID
+	}, true)
+	if len(runs) == 0 {
+		return nil, entity.ErrNotFound
+	}
+	return &runs[0], nil
+}
+
+func (r *MemoRepository) FindCrawlRunsByStatus(status entity.CrawlRunStatus) ([]entity.CrawlRun, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.sorted(func(run *entity.CrawlRun) bool {
+		return run.Status == status
+	}, false), nil
+}
+
+func (r *MemoRepository) ListCrawlRuns(collectionID string, limit int) ([]entity.CrawlRun, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	runs := r.sorted(func(run *entity.CrawlRun) bool {
+		return run.CollectionID == collectionID
+	}, true)
+	if len(runs) > limit {
+		runs = runs[:limit]
+	}
+	return runs, nil
+}
+
+func (r *MemoRepository) AddCrawlLogEntry(entry *entity.CrawlLogEntry) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.logs = append(r.logs, *entry)
+	return nil
+}
+
+func (r *MemoRepository) ListCrawlLogEntries(collectionID, runID string, limit int) ([]entity.CrawlLogEntry, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	entries := make([]entity.CrawlLogEntry, 0)
+	for _, entry := range r.logs {
+		if entry.RunID == runID && entry.CollectionID == collectionID {
+			entries = append(entries, entry)
+		}
+	}
+	if len(entries) > limit {
+		entries = entries[:limit]
+	}
+	return entries, nil
+}
+
+func (r *MemoRepository) sorted(match func(run *entity.CrawlRun) bool, desc bool) []entity.CrawlRun {
+	runs := make([]entity.CrawlRun, 0)
+	for _, run := range r.runs {
+		if match(run) {
+			runs = append(runs, *run)
+		}
+	}
+	sort.Slice(runs, func(i, j int) bool {
+		if desc {
+			return runs[i].QueuedAt.After(runs[j].QueuedAt)
+		}
+		return runs[i].QueuedAt.Before(runs[j].QueuedAt)
+	})
+	return runs
+}
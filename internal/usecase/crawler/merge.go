@@ -0,0 +1,251 @@
+package crawler
+
+import (
+	"akira/internal/entity"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	nonAlphanumeric = regexp.MustCompile(`[^\p{L}\p{N}]+`)
+	publisherName   = regexp.MustCompile(`[\p{L}\p{N}]+`)
+)
+
+// resultCluster holds the results of every source found to be the same
+// volume. Its ISBN and publisher are the first ones seen, used to keep other
+// editions out.
+type resultCluster struct {
+	index     int
+	members   []entity.CrawledResult
+	isbn      string
+	publisher string
+}
+
+func (c *resultCluster) accepts(isbn, publisher string) bool {
+	return (c.isbn == "" || isbn == "" || c.isbn == isbn) &&
+		(c.publisher == "" || publisher == "" || c.publisher == publisher)
+}
+
+func (c *resultCluster) add(result entity.CrawledResult, isbn, publisher string) {
+	c.members = append(c.members, result)
+	if c.isbn == "" {
+		c.isbn = isbn
+	}
+	if c.publisher == "" {
+		c.publisher = publisher
+	}
+}
+
+// mergeDecision records the cluster a result was placed in and why.
+type mergeDecision struct {
+	result  entity.CrawledResult
+	cluster int
+	reason  entity.CrawlMergeReason
+}
+
+// mergeResults joins the results of all providers into one record per
+// volume. Results are the same volume when they share an ISBN-13, or the
+// series signature and volume number without conflicting ISBNs or
+// publishers.
+func mergeResults(results []entity.CrawledResult) []entity.CrawledResult {
+	clusters, _ := clusterResults(results)
+	merged := make([]entity.CrawledResult, 0, len(clusters))
+	for _, cluster := range clusters {
+		merged = append(merged, cluster.canonical())
+	}
+	return merged
+}
+
+// clusterResults groups the results by volume for mergeResults, telling
+// where each one went.
+func clusterResults(results []entity.CrawledResult) ([]*resultCluster, []mergeDecision) {
+	sorted := append([]entity.CrawledResult(nil), results...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Source != sorted[j].Source {
+			return sorted[i].Source < sorted[j].Source
+		}
+		return sorted[i].URL < sorted[j].URL
+	})
+	clusters := make([]*resultCluster, 0, len(sorted))
+	decisions := make([]mergeDecision, 0, len(sorted))
+	byISBN := make(map[string]*resultCluster)
+	bySeries := make(map[string][]*resultCluster)
+	for _, result := range sorted {
+		isbn := entity.CanonicalISBN(result.ISBN)
+		publisher := normalizePublisher(result.Publisher)
+		series := seriesKey(result)
+		var cluster *resultCluster
+		reason := entity.CrawlMergeISBN
+		if isbn != "" {
+			cluster = byISBN[isbn]
+		}
+		if cluster == nil && series != "" {
+			reason = entity.CrawlMergeSeries
+			for _, candidate := range bySeries[series] {
+				if candidate.accepts(isbn, publisher) {
+					cluster = candidate
+					break
+				}
+			}
+		}
+		if cluster == nil {
+			reason = entity.CrawlMergeNew
+			cluster = &resultCluster{index: len(clusters)}
+			clusters = append(clusters, cluster)
+			if series != "" {
+				bySeries[series] = append(bySeries[series], cluster)
+			}
+		}
+		cluster.add(result, isbn, publisher)
+		if isbn != "" && byISBN[isbn] == nil {
+			byISBN[isbn] = cluster
+		}
+		decisions = append(decisions, mergeDecision{result: result, cluster: cluster.index, reason: reason})
+	}
+	return clusters, decisions
+}
+
+// canonical builds the volume record from the cluster, taking each field
+// from the most complete source that has it and the lowest price offered.
+func (c *resultCluster) canonical() entity.CrawledResult {
+	members := append([]entity.CrawledResult(nil), c.members...)
+	sort.SliceStable(members, func(i, j int) bool {
+		return completeness(members[i]) > completeness(members[j])
+	})
+	first := members[0]
+	result := entity.CrawledResult{
+		Source:     first.Source,
+		URL:        first.URL,
+		Metadata:   make(map[string]string),
+		Provenance: make(map[string]string),
+	}
+	for _, m := range members {
+		if m.Title != "" && result.Title == "" {
+			result.Title = m.Title
+			result.Provenance["title"] = m.Source
+		}
+		if m.Volume > 0 && result.Volume == 0 {
+			result.Volume = m.Volume
+			result.Provenance["volume"] = m.Source
+		}
+		if m.CoverImage != "" && result.CoverImage == "" {
+			result.CoverImage = m.CoverImage
+			result.Provenance["cover_image"] = m.Source
+		}
+		if m.Description != "" && result.Description == "" {
+			result.Description = m.Description
+			result.Provenance["description"] = m.Source
+		}
+		if m.Publisher != "" && result.Publisher == "" {
+			result.Publisher = m.Publisher
+			result.Provenance["publisher"] = m.Source
+		}
+		if len(m.Author) > 0 && len(result.Author) == 0 {
+			result.Author = m.Author
+			result.Provenance["author"] = m.Source
+		}
+		if len(m.Tags) > 0 && len(result.Tags) == 0 {
+			result.Tags = m.Tags
+			result.Provenance["tags"] = m.Source
+		}
+		if m.Rating > 0 && result.Rating == 0 {
+			result.Rating = m.Rating
+			result.Provenance["rating"] = m.Source
+		}
+		if m.ReleasedAt != "" && result.ReleasedAt == "" {
+			result.ReleasedAt = m.ReleasedAt
+			result.Provenance["released_at"] = m.Source
+		}
+		if m.Language != "" && result.Language == "" {
+			result.Language = m.Language
+			result.Provenance["language"] = m.Source
+		}
+		for key, value := range m.Metadata {
+			if _, ok := result.Metadata[key]; !ok {
+				result.Metadata[key] = value
+			}
+		}
+		result.Offers = append(result.Offers, entity.CrawledOffer{
+			Source:  m.Source,
+			URL:     m.URL,
+			Price:   m.Price,
+			Stock:   m.Metadata["stock_status"],
+			Presale: m.IsPresale(),
+			Reviews: m.Reviews,
+		})
+	}
+	result.Provenance["url"] = first.Source
+	if c.isbn != "" {
+		result.ISBN = c.isbn
+		for _, m := range members {
+			if entity.CanonicalISBN(m.ISBN) == c.isbn {
+				result.Provenance["isbn"] = m.Source
+				break
+			}
+		}
+	}
+	sort.SliceStable(result.Offers, func(i, j int) bool {
+		pi, pj := result.Offers[i].Price, result.Offers[j].Price
+		if (pi > 0) != (pj > 0) {
+			return pi > 0
+		}
+		return pi < pj
+	})
+	if best := result.Offers[0]; best.Price > 0 {
+		result.Price = best.Price
+		result.Provenance["price"] = best.Source
+	}
+	for _, offer := range result.Offers {
+		result.Reviews = append(result.Reviews, offer.Reviews...)
+		if offer.Presale {
+			result.Metadata["presale"] = "true"
+		}
+	}
+	result.Metadata["sources"] = strconv.Itoa(len(result.Offers))
+	return result
+}
+
+// completeness ranks results by how much they tell about the volume, so
+// the canonical fields come from the best described listing.
+func completeness(r entity.CrawledResult) int {
+	score := 0
+	if entity.CanonicalISBN(r.ISBN) != "" {
+		score += 2
+	}
+	for _, present := range []bool{
+		r.Volume > 0,
+		r.ReleasedAt != "",
+		r.CoverImage != "",
+		r.Description != "",
+		r.Publisher != "",
+		len(r.Author) > 0,
+	} {
+		if present {
+			score++
+		}
+	}
+	return score
+}
+
+// seriesKey identifies the volume of a series by the series signature of
+// its title and the volume number. Titles are compared without case and
+// punctuation, so "Dandadan - Vol. 1" and "DANDADAN VOL 1" match.
+func seriesKey(r entity.CrawledResult) string {
+	if r.Volume <= 0 {
+		return ""
+	}
+	signature := entity.CalculateSeriesSignature(entity.CrawledResult{Title: strings.ToLower(r.Title)})
+	signature = strings.TrimSpace(nonAlphanumeric.ReplaceAllString(signature, " "))
+	if signature == "" {
+		return ""
+	}
+	return signature + "#" + strconv.Itoa(r.Volume)
+}
+
+// normalizePublisher keeps the first word of the publisher, as stores add
+// the edition or the imprint to it, e.g. "Panini; 1ª edição".
+func normalizePublisher(publisher string) string {
+	return strings.ToLower(publisherName.FindString(publisher))
+}
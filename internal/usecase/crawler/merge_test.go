@@ -0,0 +1,176 @@
+package crawler
+
+import (
+	"akira/internal/entity"
+	"testing"
+)
+
+func TestClusterResults(t *testing.T) {
+	tests := []struct {
+		name    string
+		results []entity.CrawledResult
+		// want lists the cluster and reason of each result, in the order
+		// clusterResults goes through them: by source, then URL
+		want []mergeDecision
+	}{
+		{
+			name: "same volume in two stores with ISBN-13 and ISBN-10",
+			results: []entity.CrawledResult{
+				{Source: "panini", URL: "https://panini.com.br/dandadan-1", Title: "DANDADAN VOL 1", ISBN: "6555123451", Volume: 1},
+				{Source: "amazon", URL: "https://amazon.com.br/dp/6555123451", Title: "Dandadan - Vol. 1", ISBN: "978-65-5512-345-6", Volume: 1},
+			},
+			want: []mergeDecision{
+				{cluster: 0, reason: entity.CrawlMergeNew},
+				{cluster: 0, reason: entity.CrawlMergeISBN},
+			},
+		},
+		{
+			name: "same ISBN with different titles",
+			results: []entity.CrawledResult{
+				{Source: "amazon", URL: "a", Title: "Dandadan 1", ISBN: "9786555123456"},
+				{Source: "panini", URL: "b", Title: "Dan Da Dan - Edição 1", ISBN: "978 65 5512 345 6"},
+			},
+			want: []mergeDecision{
+				{cluster: 0, reason: entity.CrawlMergeNew},
+				{cluster: 0, reason: entity.CrawlMergeISBN},
+			},
+		},
+		{
+			name: "same series and volume without ISBN on one side",
+			results: []entity.CrawledResult{
+				{Source: "amazon", URL: "a", Title: "Dandadan - Vol. 2", ISBN: "9788542629866", Volume: 2},
+				{Source: "panini", URL: "b", Title: "DANDADAN VOL 2", Volume: 2},
+			},
+			want: []mergeDecision{
+				{cluster: 0, reason: entity.CrawlMergeNew},
+				{cluster: 0, reason: entity.CrawlMergeSeries},
+			},
+		},
+		{
+			name: "same series and volume with different ISBNs",
+			results: []entity.CrawledResult{
+				{Source: "amazon", URL: "a", Title: "Dandadan - Vol. 3", ISBN: "9786559601233", Volume: 3},
+				{Source: "panini", URL: "b", Title: "Dandadan - Vol. 3", ISBN: "9788573512342", Volume: 3},
+			},
+			want: []mergeDecision{
+				{cluster: 0, reason: entity.CrawlMergeNew},
+				{cluster: 1, reason: entity.CrawlMergeNew},
+			},
+		},
+		{
+			name: "same series and volume from different publishers",
+			results: []entity.CrawledResult{
+				{Source: "amazon", URL: "a", Title: "Berserk - Vol. 1", Publisher: "Panini; 1ª edição", Volume: 1},
+				{Source: "amazon", URL: "b", Title: "Berserk - Vol. 1", Publisher: "JBC", Volume: 1},
+				{Source: "panini", URL: "c", Title: "Berserk - Vol. 1", Publisher: "Panini", Volume: 1},
+			},
+			want: []mergeDecision{
+				{cluster: 0, reason: entity.CrawlMergeNew},
+				{cluster: 1, reason: entity.CrawlMergeNew},
+				{cluster: 0, reason: entity.CrawlMergeSeries},
+			},
+		},
+		{
+			name: "different volumes of a series",
+			results: []entity.CrawledResult{
+				{Source: "amazon", URL: "a", Title: "Dandadan - Vol. 4", Volume: 4},
+				{Source: "amazon", URL: "b", Title: "Dandadan - Vol. 5", Volume: 5},
+			},
+			want: []mergeDecision{
+				{cluster: 0, reason: entity.CrawlMergeNew},
+				{cluster: 1, reason: entity.CrawlMergeNew},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, decisions := clusterResults(tt.results)
+			if len(decisions) != len(tt.want) {
+				t.Fatalf("got %d decisions, want %d", len(decisions), len(tt.want))
+			}
+			for i, want := range tt.want {
+				got := decisions[i]
+				if got.cluster != want.cluster || got.reason != want.reason {
+					t.Errorf("result %d (%s %s) in cluster %d by %q, want %d by %q",
+						i, got.result.Source, got.result.URL, got.cluster, got.reason, want.cluster, want.reason)
+				}
+			}
+		})
+	}
+}
+
+func TestCanonical(t *testing.T) {
+	merged := mergeResults([]entity.CrawledResult{
+		{
+			Source:      "amazon",
+			URL:         "https://amazon.com.br/dp/6555123451",
+			Title:       "Dandadan - Vol. 1",
+			ISBN:        "6555123451",
+			Volume:      1,
+			Price:       49.9,
+			Publisher:   "Panini",
+			Description: "Momo e Okarun...",
+			CoverImage:  "https://amazon.com.br/cover.jpg",
+			Metadata:    map[string]string{"stock_status": "in_stock"},
+		},
+		{
+			Source:     "panini",
+			URL:        "https://panini.com.br/dandadan-1",
+			Title:      "DANDADAN VOL 1",
+			ISBN:       "978-65-5512-345-6",
+			Volume:     1,
+			Price:      39.9,
+			ReleasedAt: "2024-05-01",
+		},
+		{
+			Source: "mangaplace",
+			URL:    "https://mangaplace.example/dandadan-1",
+			Title:  "Dandadan 1",
+			ISBN:   "9786555123456",
+		},
+	})
+	if len(merged) != 1 {
+		t.Fatalf("got %d volumes, want 1: %+v", len(merged), merged)
+	}
+	volume := merged[0]
+	if volume.ISBN != "9786555123456" {
+		t.Errorf("ISBN = %q, want the ISBN-13", volume.ISBN)
+	}
+	if volume.Price != 39.9 {
+		t.Errorf("price = %v, want the lowest offer 39.9", volume.Price)
+	}
+	if volume.Title != "Dandadan - Vol. 1" || volume.URL != "https://amazon.com.br/dp/6555123451" {
+		t.Errorf("title and URL = %q %q, want the most complete listing", volume.Title, volume.URL)
+	}
+	wantOffers := []string{"panini", "amazon", "mangaplace"}
+	if len(volume.Offers) != len(wantOffers) {
+		t.Fatalf("got %d offers, want %d", len(volume.Offers), len(wantOffers))
+	}
+	for i, source := range wantOffers {
+		if volume.Offers[i].Source != source {
+			t.Errorf("offer %d from %q, want %q", i, volume.Offers[i].Source, source)
+		}
+	}
+	if volume.Offers[1].Stock != "in_stock" {
+		t.Errorf("amazon offer stock = %q, want in_stock", volume.Offers[1].Stock)
+	}
+	wantProvenance := map[string]string{
+		"title":       "amazon",
+		"url":         "amazon",
+		"isbn":        "amazon",
+		"volume":      "amazon",
+		"publisher":   "amazon",
+		"description": "amazon",
+		"cover_image": "amazon",
+		"released_at": "panini",
+		"price":       "panini",
+	}
+	for field, source := range wantProvenance {
+		if volume.Provenance[field] != source {
+			t.Errorf("provenance of %s = %q, want %q", field, volume.Provenance[field], source)
+		}
+	}
+	if volume.Metadata["sources"] != "3" {
+		t.Errorf("sources = %q, want 3", volume.Metadata["sources"])
+	}
+}
@@ -0,0 +1,184 @@
+package crawler
+
+import (
+	"akira/internal/entity"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// pageCacheRetention is how long a page is kept after it was last fetched
+	// or revalidated, so it can still be revalidated once expired.
+	pageCacheRetention = 30 * 24 * time.Hour
+	pageCacheMaxBody   = 8 << 20
+)
+
+// searchParams are the query parameters the stores take the search terms in.
+var searchParams = []string{"q", "k", "s", "search", "term"}
+
+// pageCache answers the requests of the providers with the pages fetched by
+// earlier runs while they are fresh. Expired pages are revalidated with the
+// store, which answers 304 without the page when it did not change.
+type pageCache struct {
+	repo   entity.PageCacheRepository
+	policy entity.CrawlPolicy
+	logger entity.Logger
+}
+
+func newPageCache(repo entity.PageCacheRepository, policy entity.CrawlPolicy, logger entity.Logger) *pageCache {
+	return &pageCache{repo: repo, policy: policy, logger: logger}
+}
+
+// transport wraps next with the cache. With force every page is fetched
+// again, and the fresh copy replaces the cached one.
+func (c *pageCache) transport(next http.RoundTripper, force bool) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &cacheTransport{next: next, force: force, cache: c}
+}
+
+// ttl tells how long the page at u stays fresh.
+func (c *pageCache) ttl(u *url.URL) time.Duration {
+	if isSearchPage(u) {
+		return c.policy.SearchPageTTL
+	}
+	return c.policy.ProductPageTTL
+}
+
+// prune drops the pages not fetched for pageCacheRetention.
+func (c *pageCache) prune(ctx context.Context) {
+	deleted, err := c.repo.DeletePagesFetchedBefore(time.Now().Add(-pageCacheRetention))
+	if err != nil {
+		c.logger.Error(ctx, "failed to prune page cache", err, nil)
+		return
+	}
+	if deleted > 0 {
+		c.logger.Info(ctx, "page cache pruned", map[string]any{
+			"deleted": deleted,
+		})
+	}
+}
+
+func isSearchPage(u *url.URL) bool {
+	path := strings.ToLower(u.Path)
+	if path == "/s" || strings.Contains(path, "search") {
+		return true
+	}
+	query := u.Query()
+	for _, param := range searchParams {
+		if query.Has(param) {
+			return true
+		}
+	}
+	return false
+}
+
+type cacheTransport struct {
+	next  http.RoundTripper
+	force bool
+	cache *pageCache
+}
+
+func (t *cacheTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ttl := t.cache.ttl(req.URL)
+	if req.Method != http.MethodGet || ttl <= 0 {
+		return t.next.RoundTrip(req)
+	}
+	key := req.URL.String()
+	page, err := t.cache.repo.FindPage(key)
+	if err != nil {
+		if err != entity.ErrNotFound {
+			t.cache.logger.Warn(req.Context(), "failed to read page cache", map[string]any{
+				"url":   key,
+				"error": err.Error(),
+			})
+		}
+		page = nil
+	}
+	if t.force {
+		page = nil
+	}
+	now := time.Now()
+	if page != nil && page.IsFresh(now) {
+		return cachedResponse(page, req), nil
+	}
+	out := req
+	if page != nil && (page.ETag != "" || page.LastModified != "") {
+		out = req.Clone(req.Context())
+		if page.ETag != "" {
+			out.Header.Set("If-None-Match", page.ETag)
+		}
+		if page.LastModified != "" {
+			out.Header.Set("If-Modified-Since", page.LastModified)
+		}
+	}
+	resp, err := t.next.RoundTrip(out)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusNotModified && out != req {
+		resp.Body.Close()
+		page.FetchedAt = now
+		page.ExpiresAt = now.Add(ttl)
+		t.save(req.Context(), page)
+		return cachedResponse(page, req), nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return resp, nil
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	if len(body) > pageCacheMaxBody {
+		return resp, nil
+	}
+	header := resp.Header.Clone()
+	// cookies belong to the session that fetched the page
+	header.Del("Set-Cookie")
+	t.save(req.Context(), &entity.CachedPage{
+		URL:          key,
+		Status:       resp.StatusCode,
+		Header:       header,
+		Body:         body,
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+		FetchedAt:    now,
+		ExpiresAt:    now.Add(ttl),
+	})
+	return resp, nil
+}
+
+// save stores the page, logging failures as the fetched page is still good
+// for the run.
+func (t *cacheTransport) save(ctx context.Context, page *entity.CachedPage) {
+	if err := t.cache.repo.SavePage(page); err != nil {
+		t.cache.logger.Warn(ctx, "failed to write page cache", map[string]any{
+			"url":   page.URL,
+			"error": err.Error(),
+		})
+	}
+}
+
+func cachedResponse(page *entity.CachedPage, req *http.Request) *http.Response {
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", page.Status, http.StatusText(page.Status)),
+		StatusCode:    page.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        page.Header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(page.Body)),
+		ContentLength: int64(len(page.Body)),
+		Request:       req,
+	}
+}
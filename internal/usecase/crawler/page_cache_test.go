@@ -0,0 +1,249 @@
+package crawler
+
+import (
+	"akira/internal/entity"
+	"akira/internal/usecase/logger"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	testSearchPageTTL  = time.Hour
+	testProductPageTTL = 72 * time.Hour
+)
+
+func TestIsSearchPage(t *testing.T) {
+	tests := []struct {
+		url  string
+		want bool
+	}{
+		{"https://www.amazon.com.br/s?k=dandadan", true},
+		{"https://panini.com.br/catalogsearch/result/?q=dandadan", true},
+		{"https://store.example/busca?q=berserk", true},
+		{"https://store.example/produtos?term=berserk", true},
+		{"https://store.example/SEARCH/berserk", true},
+		{"https://www.amazon.com.br/dp/6555123451", false},
+		{"https://panini.com.br/dandadan-vol-1", false},
+		{"https://store.example/produtos?page=2", false},
+	}
+	for _, tt := range tests {
+		u, err := url.Parse(tt.url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := isSearchPage(u); got != tt.want {
+			t.Errorf("isSearchPage(%s) = %v, want %v", tt.url, got, tt.want)
+		}
+	}
+}
+
+func newTestPageCache(repo entity.PageCacheRepository) *pageCache {
+	return newPageCache(repo, entity.CrawlPolicy{
+		SearchPageTTL:  testSearchPageTTL,
+		ProductPageTTL: testProductPageTTL,
+	}, logger.NewSlogLogger())
+}
+
+// revalidatingStore serves pages with an ETag and a Last-Modified date,
+// answering 304 to the requests that send them back.
+func revalidatingStore(t *testing.T) *storeServer {
+	return newStoreServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Last-Modified", "Mon, 02 Sep 2024 10:00:00 GMT")
+		w.Header().Set("Set-Cookie", "session=abc")
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte("page " + r.URL.RequestURI()))
+	})
+}
+
+func readBody(t *testing.T, resp *http.Response) string {
+	t.Helper()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(body)
+}
+
+func TestCacheTransportServesFreshPages(t *testing.T) {
+	server := revalidatingStore(t)
+	repo := NewPageCacheMemoRepository()
+	transport := newTestPageCache(repo).transport(nil, false)
+
+	for i := range 2 {
+		resp, err := server.get(t, transport, "/dp/1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("request %d: status = %d, want 200", i, resp.StatusCode)
+		}
+		if body := readBody(t, resp); body != "page /dp/1" {
+			t.Fatalf("request %d: body = %q", i, body)
+		}
+	}
+	if got := len(server.times("/dp/1")); got != 1 {
+		t.Errorf("store got %d requests, want 1", got)
+	}
+	page, err := repo.FindPage(server.URL + "/dp/1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.ETag != `"v1"` || page.LastModified == "" {
+		t.Errorf("validators = %q %q, want the ones of the store", page.ETag, page.LastModified)
+	}
+	if page.Header.Get("Set-Cookie") != "" {
+		t.Error("cached page keeps the Set-Cookie of the session")
+	}
+}
+
+func TestCacheTransportTTL(t *testing.T) {
+	server := revalidatingStore(t)
+	repo := NewPageCacheMemoRepository()
+	transport := newTestPageCache(repo).transport(nil, false)
+	tests := []struct {
+		path string
+		ttl  time.Duration
+	}{
+		{"/s?k=dandadan", testSearchPageTTL},
+		{"/dp/1", testProductPageTTL},
+	}
+	for _, tt := range tests {
+		start := time.Now()
+		if _, err := server.get(t, transport, tt.path); err != nil {
+			t.Fatal(err)
+		}
+		page, err := repo.FindPage(server.URL + tt.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ttl := page.ExpiresAt.Sub(start); ttl < tt.ttl || ttl > tt.ttl+time.Minute {
+			t.Errorf("%s cached for %v, want %v", tt.path, ttl, tt.ttl)
+		}
+	}
+}
+
+func TestCacheTransportRevalidatesExpiredPages(t *testing.T) {
+	var conditional http.Header
+	server := newStoreServer(t, func(w http.ResponseWriter, r *http.Request) {
+		conditional = r.Header.Clone()
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Last-Modified", "Mon, 02 Sep 2024 10:00:00 GMT")
+		w.Write([]byte("volume 1"))
+	})
+	repo := NewPageCacheMemoRepository()
+	transport := newTestPageCache(repo).transport(nil, false)
+	if _, err := server.get(t, transport, "/dp/1"); err != nil {
+		t.Fatal(err)
+	}
+	key := server.URL + "/dp/1"
+	page, _ := repo.FindPage(key)
+	page.ExpiresAt = time.Now().Add(-time.Minute)
+	repo.SavePage(page)
+
+	resp, err := server.get(t, transport, "/dp/1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := conditional.Get("If-None-Match"); got != `"v1"` {
+		t.Errorf("If-None-Match = %q, want %q", got, `"v1"`)
+	}
+	if got := conditional.Get("If-Modified-Since"); got != "Mon, 02 Sep 2024 10:00:00 GMT" {
+		t.Errorf("If-Modified-Since = %q, want the Last-Modified of the page", got)
+	}
+	if resp.StatusCode != http.StatusOK || readBody(t, resp) != "volume 1" {
+		t.Errorf("304 answered with %d, want the cached page", resp.StatusCode)
+	}
+	page, _ = repo.FindPage(key)
+	if !page.IsFresh(time.Now()) {
+		t.Error("page still expired after the store confirmed it")
+	}
+	if got := len(server.times("/dp/1")); got != 2 {
+		t.Errorf("store got %d requests, want 2", got)
+	}
+}
+
+func TestCacheTransportForceRefresh(t *testing.T) {
+	version := "v1"
+	var conditional http.Header
+	server := newStoreServer(t, func(w http.ResponseWriter, r *http.Request) {
+		conditional = r.Header.Clone()
+		w.Header().Set("ETag", `"`+version+`"`)
+		w.Write([]byte("volume 1 " + version))
+	})
+	repo := NewPageCacheMemoRepository()
+	cache := newTestPageCache(repo)
+	if _, err := server.get(t, cache.transport(nil, false), "/dp/1"); err != nil {
+		t.Fatal(err)
+	}
+	version = "v2"
+	resp, err := server.get(t, cache.transport(nil, true), "/dp/1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if conditional.Get("If-None-Match") != "" {
+		t.Error("forced request sent the cached ETag")
+	}
+	if body := readBody(t, resp); body != "volume 1 v2" {
+		t.Errorf("body = %q, want the page fetched again", body)
+	}
+	// the fresh copy replaces the cached one
+	resp, err = server.get(t, cache.transport(nil, false), "/dp/1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body := readBody(t, resp); body != "volume 1 v2" {
+		t.Errorf("cached body = %q, want the refreshed page", body)
+	}
+	if got := len(server.times("/dp/1")); got != 2 {
+		t.Errorf("store got %d requests, want 2", got)
+	}
+}
+
+func TestCacheTransportSkipsUncacheable(t *testing.T) {
+	server := revalidatingStore(t)
+	repo := NewPageCacheMemoRepository()
+	transport := newTestPageCache(repo).transport(nil, false)
+	for range 2 {
+		resp, err := server.get(t, transport, "/missing")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != http.StatusNotFound {
+			t.Fatalf("status = %d, want 404", resp.StatusCode)
+		}
+	}
+	if got := len(server.times("/missing")); got != 2 {
+		t.Errorf("store got %d requests for a missing page, want 2", got)
+	}
+	for range 2 {
+		req, _ := http.NewRequest(http.MethodPost, server.URL+"/cart", strings.NewReader("id=1"))
+		resp, err := transport.RoundTrip(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+	if got := len(server.times("/cart")); got != 2 {
+		t.Errorf("store got %d POST requests, want 2", got)
+	}
+	if _, err := repo.FindPage(server.URL + "/cart"); err != entity.ErrNotFound {
+		t.Errorf("POST response cached: %v", err)
+	}
+}
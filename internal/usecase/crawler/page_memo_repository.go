@@ -0,0 +1,54 @@
+package crawler
+
+import (
+	"akira/internal/entity"
+	"sync"
+	"time"
+)
+
+var _ entity.PageCacheRepository = (*PageCacheMemoRepository)(nil)
+
+type PageCacheMemoRepository struct {
+	mu    sync.Mutex
+	pages map[string]*entity.CachedPage
+}
+
+func NewPageCacheMemoRepository() *PageCacheMemoRepository {
+	return &PageCacheMemoRepository{
+		pages: make(map[string]*entity.CachedPage),
+	}
+}
+
+func (r *PageCacheMemoRepository) FindPage(url string) (*entity.CachedPage, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	page, ok := r.pages[url]
+	if !ok {
+		return nil, entity.ErrNotFound
+	}
+	found := *page
+	found.Header = page.Header.Clone()
+	return &found, nil
+}
+
+func (r *PageCacheMemoRepository) SavePage(page *entity.CachedPage) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored := *page
+	stored.Header = page.Header.Clone()
+	r.pages[page.URL] = &stored
+	return nil
+}
+
+func (r *PageCacheMemoRepository) DeletePagesFetchedBefore(before time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var deleted int64
+	for url, page := range r.pages {
+		if page.FetchedAt.Before(before) {
+			delete(r.pages, url)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
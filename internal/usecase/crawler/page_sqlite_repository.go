@@ -0,0 +1,86 @@
+package crawler
+
+import (
+	"akira/internal/entity"
+	"database/sql"
+	"encoding/json"
+	"time"
+)
+
+var _ entity.PageCacheRepository = (*PageCacheSqliteRepository)(nil)
+
+type PageCacheSqliteRepository struct {
+	db *sql.DB
+}
+
+func NewPageCacheSqliteRepository(db *sql.DB) entity.PageCacheRepository {
+	return &PageCacheSqliteRepository{db: db}
+}
+
+func (r *PageCacheSqliteRepository) FindPage(url string) (*entity.CachedPage, error) {
+	var page entity.CachedPage
+	var header string
+	var nullableETag, nullableLastModified sql.NullString
+	err := r.db.QueryRow(`
+		SELECT url, status, header, body, etag, last_modified, fetched_at, expires_at
+		FROM crawl_pages WHERE url = ?
+	`, url).Scan(
+		&page.URL,
+		&page.Status,
+		&header,
+		&page.Body,
+		&nullableETag,
+		&nullableLastModified,
+		&page.FetchedAt,
+		&page.ExpiresAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, entity.ErrNotFound
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(header), &page.Header); err != nil {
+		return nil, err
+	}
+	page.ETag = nullableETag.String
+	page.LastModified = nullableLastModified.String
+	return &page, nil
+}
+
+func (r *PageCacheSqliteRepository) SavePage(page *entity.CachedPage) error {
+	header, err := json.Marshal(page.Header)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.Exec(`
+		INSERT INTO crawl_pages (url, status, header, body, etag, last_modified, fetched_at, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (url) DO UPDATE SET
+			status = excluded.status, header = excluded.header, body = excluded.body,
+			etag = excluded.etag, last_modified = excluded.last_modified,
+			fetched_at = excluded.fetched_at, expires_at = excluded.expires_at
+	`,
+		page.URL,
+		page.Status,
+		header, // marshal to JSON
+		page.Body,
+		nullString(page.ETag),
+		nullString(page.LastModified),
+		page.FetchedAt,
+		page.ExpiresAt,
+	)
+	return err
+}
+
+func (r *PageCacheSqliteRepository) DeletePagesFetchedBefore(before time.Time) (int64, error) {
+	result, err := r.db.Exec("DELETE FROM crawl_pages WHERE fetched_at < ?", before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
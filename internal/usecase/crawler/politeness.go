@@ -0,0 +1,289 @@
+package crawler
+
+import (
+	"akira/internal/entity"
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/temoto/robotstxt"
+)
+
+const (
+	robotsAgent    = "akira"
+	robotsTTL      = 24 * time.Hour
+	backoffBase    = 30 * time.Second
+	captchaMaxPeek = 512 << 10
+)
+
+// captchaForm matches the forms stores show instead of the page when they
+// suspect a bot, like Amazon's validateCaptcha.
+var captchaForm = regexp.MustCompile(`(?i)<form[^>]+action=["'][^"']*captcha`)
+
+// politeness enforces the crawl policy for every run of the service. The
+// providers keep their own colly limits within a crawl, while the domain
+// slots, backoffs and budgets here add up across crawls.
+type politeness struct {
+	policy  entity.CrawlPolicy
+	logger  entity.Logger
+	mu      sync.Mutex
+	domains map[string]*domainState
+	budgets map[string]*sourceBudget
+	robots  map[string]*robotsEntry
+}
+
+type domainState struct {
+	// next is the earliest time the next request may start
+	next         time.Time
+	blockedUntil time.Time
+	backoff      time.Duration
+}
+
+type sourceBudget struct {
+	day  string
+	used int
+}
+
+type robotsEntry struct {
+	data      *robotstxt.RobotsData
+	fetchedAt time.Time
+}
+
+func newPoliteness(policy entity.CrawlPolicy, logger entity.Logger) *politeness {
+	return &politeness{
+		policy:  policy,
+		logger:  logger,
+		domains: make(map[string]*domainState),
+		budgets: make(map[string]*sourceBudget),
+		robots:  make(map[string]*robotsEntry),
+	}
+}
+
+// transport wraps next, or the default transport, for the requests of one
+// provider within a run. Waits end when ctx is done.
+func (p *politeness) transport(ctx context.Context, source string, next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &politeTransport{ctx: ctx, source: source, next: next, politeness: p}
+}
+
+// domainKey groups the hosts of a store, so www.store.com and store.com share
+// their limits.
+func domainKey(host string) string {
+	return strings.TrimPrefix(strings.ToLower(host), "www.")
+}
+
+func (p *politeness) domain(host string) *domainState {
+	key := domainKey(host)
+	state, ok := p.domains[key]
+	if !ok {
+		state = &domainState{}
+		p.domains[key] = state
+	}
+	return state
+}
+
+// wait reserves the next slot of the domain and sleeps until it starts. A
+// domain backing off for longer than ctx allows fails at once.
+func (p *politeness) wait(ctx context.Context, host string) error {
+	p.mu.Lock()
+	state := p.domain(host)
+	now := time.Now()
+	start := now
+	if state.next.After(start) {
+		start = state.next
+	}
+	if state.blockedUntil.After(start) {
+		start = state.blockedUntil
+	}
+	if deadline, ok := ctx.Deadline(); ok && state.blockedUntil.After(deadline) {
+		p.mu.Unlock()
+		return entity.ErrCrawlerBlocked
+	}
+	state.next = start.Add(p.policy.DomainInterval)
+	p.mu.Unlock()
+	if !start.After(now) {
+		return nil
+	}
+	timer := time.NewTimer(start.Sub(now))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return context.Cause(ctx)
+	}
+}
+
+// spend takes one request from the daily budget of the source.
+func (p *politeness) spend(source string) error {
+	if p.policy.DailyBudget <= 0 {
+		return nil
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	today := time.Now().Format(time.DateOnly)
+	budget, ok := p.budgets[source]
+	if !ok || budget.day != today {
+		budget = &sourceBudget{day: today}
+		p.budgets[source] = budget
+	}
+	if budget.used >= p.policy.DailyBudget {
+		return entity.ErrCrawlerBudgetExceeded
+	}
+	budget.used++
+	if budget.used == p.policy.DailyBudget {
+		p.logger.Warn(context.Background(), "crawler daily budget exhausted", map[string]any{
+			"source": source,
+			"budget": p.policy.DailyBudget,
+		})
+	}
+	return nil
+}
+
+// backOff pauses the domain, doubling the pause on each refusal in a row up
+// to MaxBackoff. A Retry-After from the store is honored within that cap.
+func (p *politeness) backOff(host string, retryAfter time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	state := p.domain(host)
+	state.backoff = min(max(state.backoff*2, backoffBase), p.policy.MaxBackoff)
+	pause := max(state.backoff, min(retryAfter, p.policy.MaxBackoff))
+	state.blockedUntil = time.Now().Add(pause)
+	p.logger.Warn(context.Background(), "crawler backing off", map[string]any{
+		"domain": domainKey(host),
+		"pause":  pause.String(),
+	})
+}
+
+func (p *politeness) recover(host string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.domain(host).backoff = 0
+}
+
+// allowed tells whether robots.txt of the host lets the crawler fetch the
+// page, fetching and caching it when needed. Stores without one allow all.
+func (p *politeness) allowed(ctx context.Context, req *http.Request, next http.RoundTripper) (bool, error) {
+	key := domainKey(req.URL.Host)
+	p.mu.Lock()
+	entry, ok := p.robots[key]
+	p.mu.Unlock()
+	if !ok || time.Since(entry.fetchedAt) > robotsTTL {
+		data, err := p.fetchRobots(ctx, req, next)
+		if err != nil {
+			return false, err
+		}
+		entry = &robotsEntry{data: data, fetchedAt: time.Now()}
+		p.mu.Lock()
+		p.robots[key] = entry
+		p.mu.Unlock()
+	}
+	path := req.URL.EscapedPath()
+	if req.URL.RawQuery != "" {
+		path += "?" + req.URL.RawQuery
+	}
+	return entry.data.TestAgent(path, robotsAgent), nil
+}
+
+func (p *politeness) fetchRobots(ctx context.Context, req *http.Request, next http.RoundTripper) (*robotstxt.RobotsData, error) {
+	if err := p.wait(ctx, req.URL.Host); err != nil {
+		return nil, err
+	}
+	robotsURL := *req.URL
+	robotsURL.Path, robotsURL.RawPath, robotsURL.RawQuery, robotsURL.Fragment = "/robots.txt", "", "", ""
+	robotsReq, err := http.NewRequestWithContext(ctx, http.MethodGet, robotsURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	robotsReq.Header.Set("User-Agent", req.Header.Get("User-Agent"))
+	resp, err := next.RoundTrip(robotsReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return robotstxt.FromResponse(resp)
+}
+
+type politeTransport struct {
+	ctx        context.Context
+	source     string
+	next       http.RoundTripper
+	politeness *politeness
+}
+
+func (t *politeTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	p := t.politeness
+	if p.policy.RespectRobotsTxt {
+		ok, err := p.allowed(t.ctx, req, t.next)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, entity.ErrCrawlerDisallowed
+		}
+	}
+	if err := p.spend(t.source); err != nil {
+		return nil, err
+	}
+	if err := p.wait(t.ctx, req.URL.Host); err != nil {
+		return nil, err
+	}
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable {
+		p.backOff(req.URL.Host, retryAfter(resp))
+		return resp, nil
+	}
+	if captcha, err := peekCaptcha(resp); err != nil {
+		return nil, err
+	} else if captcha {
+		resp.Body.Close()
+		p.backOff(req.URL.Host, 0)
+		return nil, entity.ErrCrawlerBlocked
+	}
+	p.recover(req.URL.Host)
+	return resp, nil
+}
+
+// peekCaptcha looks for a CAPTCHA form in the start of an HTML page, leaving
+// the body readable again.
+func peekCaptcha(resp *http.Response) (bool, error) {
+	if !strings.Contains(resp.Header.Get("Content-Type"), "html") {
+		return false, nil
+	}
+	head, err := io.ReadAll(io.LimitReader(resp.Body, captchaMaxPeek))
+	if err != nil {
+		resp.Body.Close()
+		return false, err
+	}
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(head), resp.Body), resp.Body}
+	return captchaForm.Match(head), nil
+}
+
+// retryAfter reads the Retry-After header given in seconds or as a date.
+func retryAfter(resp *http.Response) time.Duration {
+	value := resp.Header.Get("Retry-After")
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return time.Until(at)
+	}
+	return 0
+}
@@ -0,0 +1,309 @@
+package crawler
+
+import (
+	"akira/internal/entity"
+	"akira/internal/usecase/logger"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+// storeServer answers the requests of the politeness tests and records when
+// each one arrived.
+type storeServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	requests map[string][]time.Time
+}
+
+func newStoreServer(t *testing.T, handler http.HandlerFunc) *storeServer {
+	t.Helper()
+	s := &storeServer{requests: make(map[string][]time.Time)}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.requests[r.URL.Path] = append(s.requests[r.URL.Path], time.Now())
+		s.mu.Unlock()
+		if handler != nil {
+			handler(w, r)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *storeServer) times(path string) []time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]time.Time(nil), s.requests[path]...)
+}
+
+func (s *storeServer) get(t *testing.T, transport http.RoundTripper, path string) (*http.Response, error) {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, s.URL+path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := transport.RoundTrip(req)
+	if err == nil {
+		t.Cleanup(func() { resp.Body.Close() })
+	}
+	return resp, err
+}
+
+func newTestPoliteness(policy entity.CrawlPolicy) *politeness {
+	return newPoliteness(policy, logger.NewSlogLogger())
+}
+
+// sentTransport records when each request leaves the politeness transport,
+// before the network can delay it.
+type sentTransport struct {
+	mu   sync.Mutex
+	sent []time.Time
+}
+
+func (t *sentTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.mu.Lock()
+	t.sent = append(t.sent, time.Now())
+	t.mu.Unlock()
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestPolitenessSharesDomainSlots(t *testing.T) {
+	const interval = 50 * time.Millisecond
+	server := newStoreServer(t, nil)
+	p := newTestPoliteness(entity.CrawlPolicy{DomainInterval: interval})
+	ctx := context.Background()
+	sent := &sentTransport{}
+	// two crawls of the same store, as two collections syncing at once
+	transports := []http.RoundTripper{
+		p.transport(ctx, "amazon", sent),
+		p.transport(ctx, "amazon", sent),
+	}
+	start := time.Now()
+	var wg sync.WaitGroup
+	for _, transport := range transports {
+		for range 3 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if _, err := server.get(t, transport, "/page"); err != nil {
+					t.Error(err)
+				}
+			}()
+		}
+	}
+	wg.Wait()
+	times := sent.sent
+	if len(times) != 6 {
+		t.Fatalf("got %d requests, want 6", len(times))
+	}
+	sort.Slice(times, func(i, j int) bool { return times[i].Before(times[j]) })
+	// the slots are reserved one interval apart and a timer never fires early
+	for i, sentAt := range times {
+		if offset, want := sentAt.Sub(start), time.Duration(i)*interval; offset < want {
+			t.Errorf("request %d sent %v after the start, want at least %v", i, offset, want)
+		}
+	}
+}
+
+func TestPolitenessDailyBudget(t *testing.T) {
+	server := newStoreServer(t, nil)
+	p := newTestPoliteness(entity.CrawlPolicy{DailyBudget: 2})
+	ctx := context.Background()
+	amazon := p.transport(ctx, "amazon", nil)
+	for range 2 {
+		if _, err := server.get(t, amazon, "/page"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := server.get(t, amazon, "/page"); err != entity.ErrCrawlerBudgetExceeded {
+		t.Fatalf("request over the budget: got %v, want %v", err, entity.ErrCrawlerBudgetExceeded)
+	}
+	// the budget is per source, shared by its crawls
+	if _, err := server.get(t, p.transport(ctx, "amazon", nil), "/page"); err != entity.ErrCrawlerBudgetExceeded {
+		t.Fatalf("request of another amazon crawl: got %v, want %v", err, entity.ErrCrawlerBudgetExceeded)
+	}
+	if _, err := server.get(t, p.transport(ctx, "panini", nil), "/page"); err != nil {
+		t.Fatalf("request of another source: %v", err)
+	}
+	// a new day starts a new budget
+	p.budgets["amazon"].day = time.Now().AddDate(0, 0, -1).Format(time.DateOnly)
+	if _, err := server.get(t, amazon, "/page"); err != nil {
+		t.Fatalf("request on the next day: %v", err)
+	}
+	if got := len(server.times("/page")); got != 4 {
+		t.Errorf("store got %d requests, want 4", got)
+	}
+}
+
+func TestPolitenessBacksOff(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		retryAfter string
+		maxBackoff time.Duration
+		want       time.Duration
+	}{
+		{"429 without Retry-After", http.StatusTooManyRequests, "", time.Hour, backoffBase},
+		{"503 without Retry-After", http.StatusServiceUnavailable, "", time.Hour, backoffBase},
+		{"Retry-After in seconds", http.StatusTooManyRequests, "120", time.Hour, 2 * time.Minute},
+		{"Retry-After as a date", http.StatusServiceUnavailable, time.Now().Add(10 * time.Minute).UTC().Format(http.TimeFormat), time.Hour, 10 * time.Minute},
+		{"Retry-After over the cap", http.StatusTooManyRequests, "7200", time.Hour, time.Hour},
+		{"backoff over the cap", http.StatusTooManyRequests, "", 10 * time.Second, 10 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newStoreServer(t, func(w http.ResponseWriter, r *http.Request) {
+				if tt.retryAfter != "" {
+					w.Header().Set("Retry-After", tt.retryAfter)
+				}
+				w.WriteHeader(tt.status)
+			})
+			p := newTestPoliteness(entity.CrawlPolicy{MaxBackoff: tt.maxBackoff})
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			transport := p.transport(ctx, "amazon", nil)
+			start := time.Now()
+			resp, err := server.get(t, transport, "/page")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.StatusCode != tt.status {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, tt.status)
+			}
+			host, _ := url.Parse(server.URL)
+			pause := p.domain(host.Host).blockedUntil.Sub(start)
+			if pause < tt.want-2*time.Second || pause > tt.want+2*time.Second {
+				t.Errorf("domain paused for %v, want about %v", pause, tt.want)
+			}
+			// the pause outlasts the crawl, which gives up at once
+			if _, err := server.get(t, transport, "/page"); err != entity.ErrCrawlerBlocked {
+				t.Errorf("request while backing off: got %v, want %v", err, entity.ErrCrawlerBlocked)
+			}
+			if got := len(server.times("/page")); got != 1 {
+				t.Errorf("store got %d requests, want 1", got)
+			}
+		})
+	}
+}
+
+func TestPolitenessDoublesBackoff(t *testing.T) {
+	const host = "www.store.example"
+	p := newTestPoliteness(entity.CrawlPolicy{MaxBackoff: time.Hour})
+	for _, want := range []time.Duration{backoffBase, 2 * backoffBase, 4 * backoffBase} {
+		p.backOff(host, 0)
+		// the hosts of a store share their backoff
+		if got := p.domain("store.example").backoff; got != want {
+			t.Errorf("backoff = %v, want %v", got, want)
+		}
+	}
+	p.recover(host)
+	p.backOff(host, 0)
+	if got := p.domain(host).backoff; got != backoffBase {
+		t.Errorf("backoff after a good answer = %v, want %v", got, backoffBase)
+	}
+}
+
+func TestPolitenessDetectsCaptcha(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		blocked     bool
+	}{
+		{"captcha page", "text/html; charset=utf-8", `<html><body><form method="get" action="/errors/validateCaptcha"><input name="field-keywords"></form></body></html>`, true},
+		{"product page", "text/html; charset=utf-8", `<html><body><form action="/cart/add"><button>Comprar</button></form></body></html>`, false},
+		{"not html", "application/json", `{"form action=\"captcha\"": true}`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newStoreServer(t, func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", tt.contentType)
+				w.Write([]byte(tt.body))
+			})
+			p := newTestPoliteness(entity.CrawlPolicy{MaxBackoff: time.Hour})
+			transport := p.transport(context.Background(), "amazon", nil)
+			resp, err := server.get(t, transport, "/page")
+			if tt.blocked {
+				if err != entity.ErrCrawlerBlocked {
+					t.Fatalf("got %v, want %v", err, entity.ErrCrawlerBlocked)
+				}
+				host, _ := url.Parse(server.URL)
+				if !p.domain(host.Host).blockedUntil.After(time.Now()) {
+					t.Error("domain not paused after the CAPTCHA")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(body) != tt.body {
+				t.Errorf("body = %q, want the whole page %q", body, tt.body)
+			}
+		})
+	}
+}
+
+func TestPolitenessRespectsRobotsTxt(t *testing.T) {
+	server := newStoreServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			w.Write([]byte("User-agent: *\nDisallow: /private\nDisallow: /*?ref=\n"))
+			return
+		}
+		w.Write([]byte("ok"))
+	})
+	p := newTestPoliteness(entity.CrawlPolicy{RespectRobotsTxt: true})
+	transport := p.transport(context.Background(), "amazon", nil)
+	tests := []struct {
+		path string
+		err  error
+	}{
+		{"/public", nil},
+		{"/private/page", entity.ErrCrawlerDisallowed},
+		{"/public?ref=home", entity.ErrCrawlerDisallowed},
+		{"/public?page=2", nil},
+	}
+	for _, tt := range tests {
+		if _, err := server.get(t, transport, tt.path); !errors.Is(err, tt.err) {
+			t.Errorf("GET %s: got %v, want %v", tt.path, err, tt.err)
+		}
+	}
+	if got := len(server.times("/private/page")); got != 0 {
+		t.Errorf("store got %d requests for a disallowed page", got)
+	}
+	if got := len(server.times("/robots.txt")); got != 1 {
+		t.Errorf("robots.txt fetched %d times, want once", got)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", 0},
+		{"30", 30 * time.Second},
+		{time.Now().Add(time.Minute).UTC().Format(http.TimeFormat), time.Minute},
+		{"soon", 0},
+	}
+	for _, tt := range tests {
+		resp := &http.Response{Header: http.Header{"Retry-After": []string{tt.value}}}
+		got := retryAfter(resp)
+		if diff := got - tt.want; diff < -2*time.Second || diff > 2*time.Second {
+			t.Errorf("retryAfter(%q) = %v, want about %v", tt.value, got, tt.want)
+		}
+	}
+}
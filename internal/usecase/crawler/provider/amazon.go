@@ -3,7 +3,9 @@ package provider
 import (
 	"akira/internal/entity"
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
 	"regexp"
 	"sort"
 	"strconv"
@@ -16,14 +18,20 @@ import (
 
 var _ entity.SiteProvider = (*AmazonProvider)(nil)
 
+const amazonReviewSelector = "div[data-hook=review]"
+
 type AmazonProvider struct {
 	collector    *colly.Collector
 	opts         entity.CrawlerOptions
+	logger       entity.Logger
 	pagesCrawled int
+	// pause is the unit of the fixed waits between searches, kept apart
+	// from the options so replayed crawls can run without them.
+	pause time.Duration
 }
 
 func NewAmazonProvider() entity.SiteProvider {
-	return &AmazonProvider{}
+	return &AmazonProvider{pause: time.Second}
 }
 
 func (p *AmazonProvider) SiteName() string {
@@ -32,6 +40,7 @@ func (p *AmazonProvider) SiteName() string {
 
 func (p *AmazonProvider) Setup(opts entity.CrawlerOptions) error {
 	p.opts = opts
+	p.logger = setupLogger(opts)
 	if opts.MaxPages < 5 {
 		opts.MaxPages = 5
 	}
@@ -40,17 +49,14 @@ func (p *AmazonProvider) Setup(opts entity.CrawlerOptions) error {
 		colly.AllowedDomains("www.amazon.com.br", "amazon.com.br"),
 		colly.MaxDepth(3),
 	)
-    c.OnRequest(func(r *colly.Request) {
-        fmt.Printf("Visiting: %s\n", r.URL)
-    })
-	c.OnError(func(r *colly.Response, err error) {
-        fmt.Printf("Error on %s: %s\n", r.Request.URL, err)
-    })
+	if opts.Transport != nil {
+		c.WithTransport(opts.Transport)
+	}
 	c.Limit(&colly.LimitRule{
 		DomainGlob:  "*amazon.*",
 		Parallelism: p.opts.MaxConcurrency,
 		Delay:       p.opts.RequestInterval,
-		RandomDelay: 2 * time.Second,
+		RandomDelay: 2 * p.pause,
 	})
 	p.collector = c
 	return nil
@@ -60,16 +66,23 @@ func (p *AmazonProvider) Fetch(ctx context.Context, searchTerms []string) ([]ent
 	allResults := make([]entity.CrawledResult, 0)
 	for _, term := range searchTerms {
 		results, err := p.searchTerm(ctx, term)
+		if errors.Is(err, entity.ErrCrawlerBlocked) {
+			// more searches would only extend the block
+			return p.processResults(ctx, allResults), err
+		}
 		if err != nil {
-			fmt.Printf("Warning: Initial search for '%s' failed: %v\n", term, err)
+			p.logger.Warn(ctx, "search failed", map[string]any{
+				"term":  term,
+				"error": err.Error(),
+			})
 			continue
 		}
 		allResults = append(allResults, results...)
 		select {
-        case <-time.After(3 * time.Second):
+        case <-time.After(3 * p.pause):
             // Continue after delay
         case <-ctx.Done():
-            return p.processResults(allResults), nil
+            return p.processResults(ctx, allResults), nil
         }
 	}
 	series := p.identifySeries(allResults)
@@ -79,7 +92,11 @@ func (p *AmazonProvider) Fetch(ctx context.Context, searchTerms []string) ([]ent
 		}
 		maxVolumeToSearch := info.maxVolume
         if info.isMangaSeries && maxVolumeToSearch > 10 {
-            fmt.Printf("Doing targeted search for manga series: %s (missing volumes)\n", seriesName)
+            p.logger.Info(ctx, "searching missing volumes", map[string]any{
+                "series":     seriesName,
+                "max_volume": maxVolumeToSearch,
+                "coverage":   info.coverage,
+            })
 
             // Use a much simpler search strategy for manga series
             for vol := 1; vol <= maxVolumeToSearch; vol++ {
@@ -92,13 +109,16 @@ func (p *AmazonProvider) Fetch(ctx context.Context, searchTerms []string) ([]ent
 
                 // Add delay between searches (crucial for Amazon)
                 select {
-                case <-time.After(3 * time.Second):
+                case <-time.After(3 * p.pause):
                     // Continue after delay
                 case <-ctx.Done():
-                    return p.processResults(allResults), nil
+                    return p.processResults(ctx, allResults), nil
                 }
 
                 results, err := p.searchTerm(ctx, searchQuery)
+                if errors.Is(err, entity.ErrCrawlerBlocked) {
+                    return p.processResults(ctx, allResults), err
+                }
                 if err != nil {
                     continue
                 }
@@ -114,12 +134,15 @@ func (p *AmazonProvider) Fetch(ctx context.Context, searchTerms []string) ([]ent
 
                 if foundRightVolume {
                     allResults = append(allResults, results...)
-                    fmt.Printf("Found volume %d for %s\n", vol, seriesName)
+                    p.logger.Debug(ctx, "missing volume found", map[string]any{
+                        "series": seriesName,
+                        "volume": vol,
+                    })
                 }
             }
         }
 	}
-	return p.processResults(allResults), nil
+	return p.processResults(ctx, allResults), nil
 }
 
 type seriesInfo struct {
@@ -185,8 +208,8 @@ func (p *AmazonProvider) looksLikeManga(title, publisher string) bool {
 	return false
 }
 
-func (p *AmazonProvider) processResults(results []entity.CrawledResult) []entity.CrawledResult {
-	results = p.deduplicateResults(results)
+func (p *AmazonProvider) processResults(ctx context.Context, results []entity.CrawledResult) []entity.CrawledResult {
+	results = p.deduplicateResults(ctx, results)
 	return p.postProcessResults(results)
 }
 
@@ -199,12 +222,22 @@ func (p *AmazonProvider) searchTerm(ctx context.Context, term string) ([]entity.
 	pageResults := make([]entity.CrawledResult, 0)
 
 	noResultsFound := false
+	blocked := false
     c.OnHTML("div.s-no-results-result", func(e *colly.HTMLElement) {
         noResultsFound = true
     })
 	c.OnHTML("form[action*='validateCaptcha']", func(e *colly.HTMLElement) {
-        fmt.Printf("CAPTCHA detected when searching for: %s\n", term)
-    })
+		p.logger.Warn(ctx, "captcha detected", map[string]any{
+			"term": term,
+			"url":  e.Request.URL.String(),
+		})
+		blocked = true
+	})
+	c.OnError(func(r *colly.Response, err error) {
+		if errors.Is(err, entity.ErrCrawlerBlocked) || r.StatusCode == http.StatusTooManyRequests || r.StatusCode == http.StatusServiceUnavailable {
+			blocked = true
+		}
+	})
 
 	c.OnHTML("div.s-result-item", func(e *colly.HTMLElement) {
 		select {
@@ -233,7 +266,13 @@ func (p *AmazonProvider) searchTerm(ctx context.Context, term string) ([]entity.
 		priceStr = strings.TrimSpace(priceStr)
 		var price float64
 		if priceStr != "" {
-			price, _ = strconv.ParseFloat(priceStr, 64)
+			var err error
+			if price, err = strconv.ParseFloat(priceStr, 64); err != nil {
+				p.logger.Debug(ctx, "failed to parse price", map[string]any{
+					"url":   productURL,
+					"price": priceStr,
+				})
+			}
 		}
 
 		volume := entity.ExtractVolumeNumber(title)
@@ -241,7 +280,7 @@ func (p *AmazonProvider) searchTerm(ctx context.Context, term string) ([]entity.
 		result := entity.CrawledResult{
 			Title:      title,
 			Volume:     volume,
-			ISBN:       asin,
+			ISBN:       entity.CanonicalISBN(asin),
 			Price:      price,
 			CoverImage: coverURL,
 			URL:        productURL,
@@ -251,7 +290,7 @@ func (p *AmazonProvider) searchTerm(ctx context.Context, term string) ([]entity.
 			},
 		}
 
-		if price == 0 {
+		if price == 0 || p.opts.ImportReviews {
 			productCollector := p.collector.Clone()
 			productCollector.OnHTML("span#price", func(e *colly.HTMLElement) {
 				priceStr := strings.TrimSpace(e.Text)
@@ -277,11 +316,9 @@ func (p *AmazonProvider) searchTerm(ctx context.Context, term string) ([]entity.
 				if strings.Contains(text, "ISBN") {
 					parts := strings.Split(text, ":")
 					if len(parts) > 1 {
-						isbn := strings.TrimSpace(parts[1])
-						isbn = strings.ReplaceAll(isbn, "-", "")
-						isbn = strings.ReplaceAll(isbn, " ", "")
-						isbn = strings.ReplaceAll(isbn, "\n", "")
-						result.ISBN = isbn
+						if isbn, err := entity.ParseISBN(parts[1]); err == nil {
+							result.ISBN = isbn
+						}
 					}
 				}
 
@@ -307,7 +344,20 @@ func (p *AmazonProvider) searchTerm(ctx context.Context, term string) ([]entity.
 				}
 			})
 
-			productCollector.Visit(productURL)
+			if p.opts.ImportReviews {
+				productCollector.OnHTML(amazonReviewSelector, func(e *colly.HTMLElement) {
+					if review, ok := parseAmazonReview(e); ok {
+						result.Reviews = append(result.Reviews, review)
+					}
+				})
+			}
+
+			if err := productCollector.Visit(productURL); err != nil {
+				p.logger.Warn(ctx, "failed to visit product page", map[string]any{
+					"url":   productURL,
+					"error": err.Error(),
+				})
+			}
 		}
 
 		resultsMutex.Lock()
@@ -334,26 +384,63 @@ func (p *AmazonProvider) searchTerm(ctx context.Context, term string) ([]entity.
 			p.pagesCrawled++
 			nextPage := e.Attr("href")
 			if nextPage != "" {
+				p.logger.Debug(ctx, "following next page", map[string]any{
+					"term": term,
+					"url":  e.Request.AbsoluteURL(nextPage),
+					"page": p.pagesCrawled + 1,
+				})
 				c.Visit(e.Request.AbsoluteURL(nextPage))
 			}
 		}
 	})
 
 	err := c.Visit(searchURL)
+	if blocked {
+		return pageResults, entity.ErrCrawlerBlocked
+	}
 	if err != nil {
-		fmt.Println("Error visiting search URL:", err)
+		p.logger.Error(ctx, "failed to visit search page", err, map[string]any{
+			"url": searchURL,
+		})
 		return nil, err
 	}
 
 	c.Wait()
+	if blocked {
+		return pageResults, entity.ErrCrawlerBlocked
+	}
 	if noResultsFound {
-        fmt.Printf("No results found for: %s\n", term)
+        p.logger.Info(ctx, "no results found", map[string]any{
+            "term": term,
+        })
     }
 
 	return pageResults, nil
 }
 
-func (p *AmazonProvider) deduplicateResults(results []entity.CrawledResult) []entity.CrawledResult {
+// parseAmazonReview reads one customer review from the product page. The
+// date line reads like "Avaliado no Brasil em 12 de março de 2024".
+func parseAmazonReview(e *colly.HTMLElement) (entity.CrawledReview, bool) {
+	review := entity.CrawledReview{
+		Author:  strings.TrimSpace(e.ChildText("span.a-profile-name")),
+		Title:   strings.TrimSpace(e.ChildText("[data-hook=review-title] > span:last-child")),
+		Content: strings.TrimSpace(e.ChildText("span[data-hook=review-body]")),
+	}
+	ratingText := e.ChildText("i[data-hook=review-star-rating] span.a-icon-alt")
+	if ratingText == "" {
+		ratingText = e.ChildText("i[data-hook=cmps-review-star-rating] span.a-icon-alt")
+	}
+	review.Rating = parseRating(ratingText)
+	if date, ok := parsePortugueseDate(e.ChildText("span[data-hook=review-date]")); ok {
+		review.Date = date.Format("2006-01-02")
+	}
+	if review.Title == "" && review.Content == "" {
+		return review, false
+	}
+	return review, true
+}
+
+func (p *AmazonProvider) deduplicateResults(ctx context.Context, results []entity.CrawledResult) []entity.CrawledResult {
 	for i := range results {
 		if results[i].Metadata == nil {
 			results[i].Metadata = make(map[string]string)
@@ -365,7 +452,15 @@ func (p *AmazonProvider) deduplicateResults(results []entity.CrawledResult) []en
 	volMap := make(map[string]map[int][]entity.CrawledResult)
 	for _, result := range results {
 		seriesTitle := result.Metadata["normalized_title"]
-		if result.Volume <= 0 || seriesTitle == "" {
+		if result.Volume <= 0 {
+			continue
+		}
+		if seriesTitle == "" {
+			p.logger.Debug(ctx, "result dropped without series title", map[string]any{
+				"url":    result.URL,
+				"title":  result.Title,
+				"volume": result.Volume,
+			})
 			continue
 		}
 		if _, exists := volMap[seriesTitle]; !exists {
@@ -398,6 +493,14 @@ func (p *AmazonProvider) deduplicateResults(results []entity.CrawledResult) []en
 			if len(candidates) > 0 {
 				unique = append(unique, candidates[0])
 			}
+			for _, dropped := range candidates[1:] {
+				p.logger.Debug(ctx, "duplicate volume dropped", map[string]any{
+					"url":      dropped.URL,
+					"title":    dropped.Title,
+					"volume":   dropped.Volume,
+					"kept_url": candidates[0].URL,
+				})
+			}
 		}
 	}
 	for _, result := range results {
@@ -0,0 +1,56 @@
+package provider
+
+import (
+	"akira/internal/entity"
+	"testing"
+	"time"
+
+	"github.com/gocolly/colly/v2"
+)
+
+func TestParseAmazonReview(t *testing.T) {
+	reviews := make([]entity.CrawledReview, 0)
+	visitFixture(t, "amazon_product.html", amazonReviewSelector, func(e *colly.HTMLElement) {
+		if review, ok := parseAmazonReview(e); ok {
+			reviews = append(reviews, review)
+		}
+	})
+
+	want := []entity.CrawledReview{
+		{
+			Author:  "Mariana S.",
+			Title:   "Chegou impecável",
+			Content: "Edição muito bem cuidada, papel de boa qualidade.",
+			Rating:  5,
+			Date:    "2024-03-12",
+		},
+		{
+			Author:  "Cliente Amazon",
+			Title:   "Capa amassada",
+			Content: "A história é ótima, mas a capa veio amassada.",
+			Rating:  3,
+			Date:    "2025-01-05",
+		},
+	}
+	if len(reviews) != len(want) {
+		t.Fatalf("got %d reviews, want %d: %+v", len(reviews), len(want), reviews)
+	}
+	for i := range want {
+		if reviews[i] != want[i] {
+			t.Errorf("review %d = %+v, want %+v", i, reviews[i], want[i])
+		}
+	}
+}
+
+func TestAmazonFetchReplay(t *testing.T) {
+	provider := &AmazonProvider{}
+	if *record {
+		provider.pause = time.Second
+	}
+	results := fetchReplay(t, provider, entity.CrawlerOptions{
+		MaxPages:       5,
+		MaxConcurrency: 1,
+		ImportReviews:  true,
+	}, []string{"Dandadan"})
+	assertGolden(t, "amazon_fetch", results)
+}
@@ -0,0 +1,151 @@
+package provider
+
+import (
+	"akira/internal/entity"
+	"akira/internal/usecase/crawler/replay"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fixtureServer serves the pages recorded in testdata/replay over HTTP,
+// looking them up by the host the request was meant for.
+func fixtureServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	sites, err := filepath.Glob(filepath.Join("testdata", "replay", "*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		u := *r.URL
+		u.Host = r.Host
+		for _, dir := range sites {
+			body, err := os.ReadFile(filepath.Join(dir, replay.Key(&u)))
+			if err == nil {
+				w.Header().Set("Content-Type", "text/html; charset=utf-8")
+				w.Write(body)
+				return
+			}
+		}
+		http.NotFound(w, r)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// serverTransport sends every request to the fixture server, keeping the
+// original host so the server can find the page.
+type serverTransport struct {
+	target *url.URL
+}
+
+func (t serverTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	out := req.Clone(req.Context())
+	out.URL.Scheme = t.target.Scheme
+	out.URL.Host = t.target.Host
+	out.Host = req.URL.Host
+	return http.DefaultTransport.RoundTrip(out)
+}
+
+// TestConcurrentFetches runs several crawls of every provider at once, each
+// with its own instance from the factory, as the crawler service does when
+// collections sync together. Run it with -race.
+func TestConcurrentFetches(t *testing.T) {
+	definitions, err := LoadSiteDefinitions(definitionsDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	factories := map[string]entity.SiteProviderFactory{
+		"amazon": func() entity.SiteProvider { return &AmazonProvider{} },
+		"panini": func() entity.SiteProvider { return &PaniniProvider{} },
+	}
+	for _, def := range definitions {
+		if def.Name == "jbc" {
+			factories["jbc"] = func() entity.SiteProvider { return NewDeclarativeProvider(def) }
+		}
+	}
+	terms := map[string][]string{
+		"amazon": {"Dandadan"},
+		"panini": {"Dandadan"},
+		"jbc":    {"Bungo Stray Dogs"},
+	}
+	server := fixtureServer(t)
+	target, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := entity.CrawlerOptions{
+		MaxPages:       5,
+		MaxConcurrency: 2,
+		ImportReviews:  true,
+		Transport:      serverTransport{target: target},
+	}
+
+	const crawls = 3
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	found := make(map[string][][]entity.CrawledResult)
+	for site, factory := range factories {
+		for range crawls {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				provider := factory()
+				if err := provider.Setup(opts); err != nil {
+					t.Errorf("setup %s: %v", site, err)
+					return
+				}
+				results, err := provider.Fetch(ctx, terms[site])
+				if err != nil {
+					t.Errorf("fetch %s: %v", site, err)
+					return
+				}
+				mu.Lock()
+				found[site] = append(found[site], results)
+				mu.Unlock()
+			}()
+		}
+	}
+	wg.Wait()
+
+	for site := range factories {
+		if len(found[site]) != crawls {
+			t.Fatalf("%s: %d of %d crawls finished", site, len(found[site]), crawls)
+		}
+		want := resultURLs(found[site][0])
+		if len(want) == 0 {
+			t.Errorf("%s: no results from the fixture server", site)
+		}
+		for i, results := range found[site][1:] {
+			got := resultURLs(results)
+			if len(got) != len(want) {
+				t.Errorf("%s: crawl %d found %v, want %v", site, i+2, got, want)
+				continue
+			}
+			for j := range want {
+				if got[j] != want[j] {
+					t.Errorf("%s: crawl %d found %v, want %v", site, i+2, got, want)
+					break
+				}
+			}
+		}
+	}
+}
+
+func resultURLs(results []entity.CrawledResult) []string {
+	urls := make([]string, 0, len(results))
+	for _, result := range results {
+		urls = append(urls, result.URL)
+	}
+	sort.Strings(urls)
+	return urls
+}
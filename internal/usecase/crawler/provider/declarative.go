@@ -0,0 +1,482 @@
+package provider
+
+import (
+	"akira/internal/entity"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gocolly/colly/v2"
+	"github.com/invopop/yaml"
+)
+
+var _ entity.SiteProvider = (*DeclarativeProvider)(nil)
+
+const searchTermPlaceholder = "{term}"
+
+var pricePattern = regexp.MustCompile(`\d[\d.,]*`)
+
+// SiteDefinition describes a store that can be crawled with selectors alone,
+// so adding one doesn't need a provider written in Go.
+type SiteDefinition struct {
+	Name           string   `json:"name"`
+	AllowedDomains []string `json:"allowed_domains"`
+	// SearchURL is the search page, with {term} where the search term goes.
+	SearchURL string `json:"search_url"`
+	// Publisher and Language are used when the pages don't tell them.
+	Publisher string `json:"publisher"`
+	Language  string `json:"language"`
+	// DateLayout is the Go layout of the release dates, 02/01/2006 by default.
+	DateLayout string            `json:"date_layout"`
+	List       ListDefinition    `json:"list"`
+	Detail     *PageDefinition   `json:"detail"`
+	Pagination string            `json:"pagination"`
+	Volumes    *VolumeDefinition `json:"volumes"`
+	// Path is the file the definition was loaded from.
+	Path string `json:"-"`
+}
+
+// ListDefinition describes the search results. When Link is set and the
+// definition has a Detail page, each result is completed from its page.
+type ListDefinition struct {
+	Item   string         `json:"item"`
+	Link   FieldSelector  `json:"link"`
+	Fields FieldSelectors `json:"fields"`
+}
+
+type PageDefinition struct {
+	Fields FieldSelectors `json:"fields"`
+}
+
+// VolumeDefinition follows the links to other volumes of the series found on
+// the detail pages, up to Max pages per search term.
+type VolumeDefinition struct {
+	Links string `json:"links"`
+	Max   int    `json:"max"`
+}
+
+type FieldSelectors struct {
+	Title       FieldSelector `json:"title"`
+	Price       FieldSelector `json:"price"`
+	ISBN        FieldSelector `json:"isbn"`
+	Cover       FieldSelector `json:"cover"`
+	Publisher   FieldSelector `json:"publisher"`
+	Author      FieldSelector `json:"author"`
+	Description FieldSelector `json:"description"`
+	ReleaseDate FieldSelector `json:"release_date"`
+}
+
+// FieldSelector reads the text of the first element matching Selector, or
+// its Attr attribute. An empty Selector reads the current element itself.
+// Pattern keeps the first group, or the whole match, of a regexp.
+type FieldSelector struct {
+	Selector string `json:"selector"`
+	Attr     string `json:"attr"`
+	Pattern  string `json:"pattern"`
+	pattern  *regexp.Regexp
+}
+
+// UnmarshalJSON accepts a plain string as a shorthand for a selector.
+func (f *FieldSelector) UnmarshalJSON(data []byte) error {
+	var selector string
+	if err := json.Unmarshal(data, &selector); err == nil {
+		*f = FieldSelector{Selector: selector}
+		return nil
+	}
+	type plain FieldSelector
+	return json.Unmarshal(data, (*plain)(f))
+}
+
+func (f FieldSelector) IsZero() bool {
+	return f.Selector == "" && f.Attr == ""
+}
+
+func (f FieldSelector) value(e *colly.HTMLElement) string {
+	var value string
+	switch {
+	case f.Selector == "" && f.Attr == "":
+		return ""
+	case f.Selector == "":
+		value = e.Attr(f.Attr)
+	case f.Attr != "":
+		value = e.ChildAttr(f.Selector, f.Attr)
+	default:
+		value = e.DOM.Find(f.Selector).First().Text()
+	}
+	value = strings.TrimSpace(value)
+	if f.pattern != nil {
+		match := f.pattern.FindStringSubmatch(value)
+		switch {
+		case match == nil:
+			return ""
+		case len(match) > 1:
+			return strings.TrimSpace(match[1])
+		default:
+			return strings.TrimSpace(match[0])
+		}
+	}
+	return value
+}
+
+func (f *FieldSelector) compile() error {
+	if f.Pattern == "" {
+		return nil
+	}
+	pattern, err := regexp.Compile(f.Pattern)
+	if err != nil {
+		return err
+	}
+	f.pattern = pattern
+	return nil
+}
+
+func (s *FieldSelectors) compile() error {
+	for name, f := range map[string]*FieldSelector{
+		"title":        &s.Title,
+		"price":        &s.Price,
+		"isbn":         &s.ISBN,
+		"cover":        &s.Cover,
+		"publisher":    &s.Publisher,
+		"author":       &s.Author,
+		"description":  &s.Description,
+		"release_date": &s.ReleaseDate,
+	} {
+		if err := f.compile(); err != nil {
+			return fmt.Errorf("invalid pattern for %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// Validate checks the definition and compiles its patterns. Allowed domains
+// default to the host of the search URL.
+func (d *SiteDefinition) Validate() error {
+	if d.Name == "" {
+		return errors.New("missing name")
+	}
+	if !strings.Contains(d.SearchURL, searchTermPlaceholder) {
+		return fmt.Errorf("search_url must contain %s", searchTermPlaceholder)
+	}
+	searchURL, err := url.Parse(strings.ReplaceAll(d.SearchURL, searchTermPlaceholder, "term"))
+	if err != nil || searchURL.Host == "" {
+		return fmt.Errorf("invalid search_url %q", d.SearchURL)
+	}
+	if len(d.AllowedDomains) == 0 {
+		d.AllowedDomains = []string{searchURL.Hostname()}
+	}
+	if d.List.Item == "" {
+		return errors.New("missing list.item")
+	}
+	if d.List.Fields.Title.IsZero() && (d.Detail == nil || d.Detail.Fields.Title.IsZero()) {
+		return errors.New("missing title selector")
+	}
+	if d.Detail != nil && d.List.Link.IsZero() {
+		return errors.New("list.link is required to visit the detail pages")
+	}
+	if d.Volumes != nil && (d.Detail == nil || d.Volumes.Links == "") {
+		return errors.New("volumes needs detail pages and a links selector")
+	}
+	if d.DateLayout == "" {
+		d.DateLayout = "02/01/2006"
+	}
+	if err := d.List.Link.compile(); err != nil {
+		return fmt.Errorf("invalid pattern for link: %w", err)
+	}
+	if err := d.List.Fields.compile(); err != nil {
+		return err
+	}
+	if d.Detail != nil {
+		if err := d.Detail.Fields.compile(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LoadSiteDefinitions reads the .yaml, .yml and .json files of dir. Files
+// that can't be read or are invalid are reported in the error, while the
+// valid definitions are still returned. A missing dir has no definitions.
+func LoadSiteDefinitions(dir string) ([]SiteDefinition, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	definitions := make([]SiteDefinition, 0)
+	var errs []error
+	for _, entry := range entries {
+		switch strings.ToLower(filepath.Ext(entry.Name())) {
+		case ".yaml", ".yml", ".json":
+		default:
+			continue
+		}
+		if entry.IsDir() {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		definition, err := loadSiteDefinition(path)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", path, err))
+			continue
+		}
+		definitions = append(definitions, definition)
+	}
+	return definitions, errors.Join(errs...)
+}
+
+func loadSiteDefinition(path string) (SiteDefinition, error) {
+	var definition SiteDefinition
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return definition, err
+	}
+	if err := yaml.Unmarshal(data, &definition); err != nil {
+		return definition, err
+	}
+	if err := definition.Validate(); err != nil {
+		return definition, err
+	}
+	definition.Path = path
+	return definition, nil
+}
+
+// DeclarativeProvider crawls a store described by a SiteDefinition.
+type DeclarativeProvider struct {
+	def       SiteDefinition
+	collector *colly.Collector
+	opts      entity.CrawlerOptions
+	logger    entity.Logger
+}
+
+// NewDeclarativeProvider expects a definition that passed Validate.
+func NewDeclarativeProvider(def SiteDefinition) *DeclarativeProvider {
+	return &DeclarativeProvider{def: def}
+}
+
+func (p *DeclarativeProvider) SiteName() string {
+	return p.def.Name
+}
+
+func (p *DeclarativeProvider) Setup(opts entity.CrawlerOptions) error {
+	p.opts = opts
+	p.logger = setupLogger(opts)
+	c := colly.NewCollector(
+		colly.UserAgent("Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"),
+		colly.AllowedDomains(p.def.AllowedDomains...),
+	)
+	if opts.Transport != nil {
+		c.WithTransport(opts.Transport)
+	}
+	c.SetRequestTimeout(30 * time.Second)
+	c.OnRequest(func(r *colly.Request) {
+		r.Headers.Set("Accept-Language", "pt-BR,pt;q=0.9,en-US;q=0.8,en;q=0.7")
+	})
+	c.Limit(&colly.LimitRule{
+		DomainGlob:  "*",
+		Parallelism: max(opts.MaxConcurrency, 1),
+		Delay:       opts.RequestInterval,
+	})
+	p.collector = c
+	return nil
+}
+
+func (p *DeclarativeProvider) Fetch(ctx context.Context, searchTerms []string) ([]entity.CrawledResult, error) {
+	found := &declarativeResults{byURL: make(map[string]int)}
+	for _, term := range searchTerms {
+		if ctx.Err() != nil {
+			break
+		}
+		p.searchTerm(ctx, term, found)
+	}
+	return found.results, nil
+}
+
+type declarativeResults struct {
+	results []entity.CrawledResult
+	byURL   map[string]int
+}
+
+func (r *declarativeResults) add(result entity.CrawledResult) {
+	if i, ok := r.byURL[result.URL]; ok {
+		mergeResult(&r.results[i], result)
+		return
+	}
+	r.byURL[result.URL] = len(r.results)
+	r.results = append(r.results, result)
+}
+
+func (p *DeclarativeProvider) searchTerm(ctx context.Context, term string, found *declarativeResults) {
+	searchURL := strings.ReplaceAll(p.def.SearchURL, searchTermPlaceholder, url.QueryEscape(term))
+	list := p.collector.Clone()
+	detail := p.collector.Clone()
+	pending := make(map[string]entity.CrawledResult)
+	pagesCrawled, volumesFollowed := 0, 0
+
+	list.OnHTML(p.def.List.Item, func(e *colly.HTMLElement) {
+		if ctx.Err() != nil {
+			return
+		}
+		result := p.extract(e, p.def.List.Fields)
+		link := p.def.List.Link.value(e)
+		if link != "" {
+			link = e.Request.AbsoluteURL(link)
+		}
+		if p.def.Detail != nil && link != "" {
+			pending[link] = result
+			detail.Visit(link)
+			return
+		}
+		if link == "" {
+			link = e.Request.URL.String()
+		}
+		result.URL = link
+		p.add(found, result)
+	})
+	if p.def.Pagination != "" {
+		list.OnHTML(p.def.Pagination, func(e *colly.HTMLElement) {
+			if ctx.Err() != nil || pagesCrawled >= p.opts.MaxPages {
+				return
+			}
+			pagesCrawled++
+			p.logger.Debug(ctx, "following next page", map[string]any{
+				"term": term,
+				"url":  e.Request.AbsoluteURL(e.Attr("href")),
+				"page": pagesCrawled + 1,
+			})
+			e.Request.Visit(e.Attr("href"))
+		})
+	}
+
+	if p.def.Detail != nil {
+		detail.OnHTML("html", func(e *colly.HTMLElement) {
+			u := e.Request.URL.String()
+			result := pending[u]
+			mergeResult(&result, p.extract(e, p.def.Detail.Fields))
+			result.URL = u
+			p.add(found, result)
+		})
+	}
+	if p.def.Volumes != nil {
+		detail.OnHTML(p.def.Volumes.Links, func(e *colly.HTMLElement) {
+			if ctx.Err() != nil || volumesFollowed >= p.def.Volumes.Max {
+				return
+			}
+			volumesFollowed++
+			e.Request.Visit(e.Attr("href"))
+		})
+	}
+
+	if err := list.Visit(searchURL); err != nil {
+		p.logger.Error(ctx, "failed to visit search page", err, map[string]any{
+			"url": searchURL,
+		})
+		return
+	}
+	list.Wait()
+	detail.Wait()
+}
+
+func (p *DeclarativeProvider) add(found *declarativeResults, result entity.CrawledResult) {
+	if result.Title == "" {
+		return
+	}
+	result.Source = p.SiteName()
+	result.Volume = entity.ExtractVolumeNumber(result.Title)
+	if result.Publisher == "" {
+		result.Publisher = p.def.Publisher
+	}
+	if result.Language == "" {
+		result.Language = p.def.Language
+	}
+	found.add(result)
+}
+
+func (p *DeclarativeProvider) extract(e *colly.HTMLElement, fields FieldSelectors) entity.CrawledResult {
+	result := entity.CrawledResult{
+		Title:       fields.Title.value(e),
+		Price:       parsePrice(fields.Price.value(e)),
+		ISBN:        entity.CanonicalISBN(fields.ISBN.value(e)),
+		Publisher:   fields.Publisher.value(e),
+		Description: fields.Description.value(e),
+		Metadata:    make(map[string]string),
+	}
+	if cover := fields.Cover.value(e); cover != "" {
+		result.CoverImage = e.Request.AbsoluteURL(cover)
+	}
+	if authors := fields.Author.value(e); authors != "" {
+		for _, author := range strings.Split(authors, ",") {
+			if author = strings.TrimSpace(author); author != "" {
+				result.Author = append(result.Author, author)
+			}
+		}
+	}
+	if date := fields.ReleaseDate.value(e); date != "" {
+		if t, err := time.Parse(p.def.DateLayout, date); err == nil {
+			result.ReleasedAt = t.Format("2006-01-02")
+		} else if t, ok := parsePortugueseDate(date); ok {
+			result.ReleasedAt = t.Format("2006-01-02")
+		}
+	}
+	return result
+}
+
+// mergeResult fills dst with the fields found in src, which take precedence.
+func mergeResult(dst *entity.CrawledResult, src entity.CrawledResult) {
+	if src.Title != "" {
+		dst.Title = src.Title
+	}
+	if src.Price > 0 {
+		dst.Price = src.Price
+	}
+	if src.ISBN != "" {
+		dst.ISBN = src.ISBN
+	}
+	if src.CoverImage != "" {
+		dst.CoverImage = src.CoverImage
+	}
+	if src.Publisher != "" {
+		dst.Publisher = src.Publisher
+	}
+	if len(src.Author) > 0 {
+		dst.Author = src.Author
+	}
+	if src.Description != "" {
+		dst.Description = src.Description
+	}
+	if src.ReleasedAt != "" {
+		dst.ReleasedAt = src.ReleasedAt
+	}
+	if dst.Metadata == nil {
+		dst.Metadata = make(map[string]string)
+	}
+	for key, value := range src.Metadata {
+		dst.Metadata[key] = value
+	}
+}
+
+// parsePrice reads prices written as "R$ 1.234,56" or "1234.56".
+func parsePrice(text string) float64 {
+	match := pricePattern.FindString(text)
+	if match == "" {
+		return 0
+	}
+	if strings.Contains(match, ",") {
+		match = strings.ReplaceAll(match, ".", "")
+		match = strings.ReplaceAll(match, ",", ".")
+	}
+	price, err := strconv.ParseFloat(strings.TrimRight(match, "."), 64)
+	if err != nil {
+		return 0
+	}
+	return price
+}
@@ -0,0 +1,74 @@
+package provider
+
+import (
+	"akira/internal/entity"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// definitionsDir holds the site definitions shipped with the app.
+const definitionsDir = "../../../../providers"
+
+func TestDeclarativeFetchReplay(t *testing.T) {
+	definitions, err := LoadSiteDefinitions(definitionsDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, def := range definitions {
+		if def.Name != "jbc" {
+			continue
+		}
+		results := fetchReplay(t, NewDeclarativeProvider(def), entity.CrawlerOptions{
+			MaxPages:       5,
+			MaxConcurrency: 1,
+		}, []string{"Bungo Stray Dogs"})
+		assertGolden(t, "jbc_fetch", results)
+		return
+	}
+	t.Fatalf("no jbc definition in %s", definitionsDir)
+}
+
+func TestLoadSiteDefinitions(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"valid.yaml": `
+name: store
+search_url: "https://store.example/busca?q={term}"
+list:
+  item: li.product
+  fields:
+    title: h2
+    price:
+      selector: span.price
+      pattern: "R\\$\\s*([\\d.,]+)"
+`,
+		"valid.json":   `{"name": "other", "search_url": "https://other.example/?s={term}", "list": {"item": "li", "fields": {"title": "a"}}}`,
+		"invalid.yaml": "name: broken\nsearch_url: https://broken.example/\n",
+		"notes.txt":    "ignored",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	definitions, err := LoadSiteDefinitions(dir)
+	if err == nil {
+		t.Error("expected an error for invalid.yaml")
+	}
+	if len(definitions) != 2 {
+		t.Fatalf("got %d definitions, want 2", len(definitions))
+	}
+	for _, def := range definitions {
+		if len(def.AllowedDomains) != 1 {
+			t.Errorf("%s: allowed domains = %v, want the search URL host", def.Name, def.AllowedDomains)
+		}
+		if filepath.Dir(def.Path) != dir {
+			t.Errorf("%s: path = %q, want a file of %s", def.Name, def.Path, dir)
+		}
+	}
+	if definitions, err := LoadSiteDefinitions(filepath.Join(dir, "missing")); err != nil || len(definitions) != 0 {
+		t.Errorf("missing dir = %v, %v, want no definitions", definitions, err)
+	}
+}
@@ -0,0 +1,28 @@
+package provider
+
+import (
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"github.com/gocolly/colly/v2"
+)
+
+// visitFixture serves the named file from testdata and runs the callback for
+// every element matching the selector.
+func visitFixture(t *testing.T, name, selector string, fn func(e *colly.HTMLElement)) {
+	t.Helper()
+	dir, err := filepath.Abs("testdata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	transport := &http.Transport{}
+	transport.RegisterProtocol("file", http.NewFileTransport(http.Dir(dir)))
+	c := colly.NewCollector()
+	c.WithTransport(transport)
+	c.OnHTML(selector, fn)
+	if err := c.Visit("file:///" + name); err != nil {
+		t.Fatalf("visit %s: %v", name, err)
+	}
+	c.Wait()
+}
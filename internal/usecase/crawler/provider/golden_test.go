@@ -0,0 +1,80 @@
+package provider
+
+import (
+	"akira/internal/entity"
+	"akira/internal/usecase/crawler/replay"
+	"context"
+	"encoding/json"
+	"flag"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+)
+
+var (
+	update = flag.Bool("update", false, "rewrite the golden files with the current results")
+	record = flag.Bool("record", false, "record the pages from the live site before replaying them")
+)
+
+// fetchReplay runs a provider against the pages recorded in
+// testdata/replay/<site>. With -record the pages are fetched from the live
+// site first.
+func fetchReplay(t *testing.T, provider entity.SiteProvider, opts entity.CrawlerOptions, terms []string) []entity.CrawledResult {
+	t.Helper()
+	dir := filepath.Join("testdata", "replay", provider.SiteName())
+	transport := replay.NewTransport(dir)
+	opts.Transport = transport
+	if *record {
+		opts.Transport = replay.NewRecorder(dir, http.DefaultTransport)
+	}
+	if err := provider.Setup(opts); err != nil {
+		t.Fatalf("setup %s: %v", provider.SiteName(), err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	results, err := provider.Fetch(ctx, terms)
+	if err != nil {
+		t.Fatalf("fetch %s: %v", provider.SiteName(), err)
+	}
+	for _, u := range transport.Missed() {
+		t.Logf("no recording for %s", u)
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Volume != results[j].Volume {
+			return results[i].Volume < results[j].Volume
+		}
+		return results[i].URL < results[j].URL
+	})
+	return results
+}
+
+// assertGolden compares the results with testdata/golden/<name>.json,
+// rewriting the file instead when -update is set.
+func assertGolden(t *testing.T, name string, results []entity.CrawledResult) {
+	t.Helper()
+	got, err := json.MarshalIndent(results, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got = append(got, '\n')
+	path := filepath.Join("testdata", "golden", name+".json")
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read golden file: %v (run with -update to create it)", err)
+	}
+	if string(got) != string(want) {
+		t.Errorf("results differ from %s (run with -update to accept them)\ngot:\n%s", path, got)
+	}
+}
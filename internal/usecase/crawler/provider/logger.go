@@ -0,0 +1,23 @@
+package provider
+
+import (
+	"akira/internal/entity"
+	"context"
+)
+
+// setupLogger returns the logger of the crawl the provider runs for, or one
+// dropping every event for providers set up without it, as in the tests.
+func setupLogger(opts entity.CrawlerOptions) entity.Logger {
+	if opts.Logger != nil {
+		return opts.Logger
+	}
+	return nopLogger{}
+}
+
+type nopLogger struct{}
+
+func (nopLogger) Info(ctx context.Context, msg string, args map[string]any)             {}
+func (nopLogger) Error(ctx context.Context, msg string, err error, args map[string]any) {}
+func (nopLogger) Warn(ctx context.Context, msg string, args map[string]any)             {}
+func (nopLogger) Debug(ctx context.Context, msg string, args map[string]any)            {}
+func (nopLogger) Close()                                                                {}
@@ -5,6 +5,7 @@ import (
 	"context"
 	"fmt"
 	"regexp"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
@@ -16,15 +17,24 @@ import (
 
 var _ entity.SiteProvider = (*PaniniProvider)(nil)
 
+const paniniReviewSelector = "ol.review-items li.review-item"
+
 type PaniniProvider struct {
-	collector    *colly.Collector
-	opts         entity.CrawlerOptions
+	collector *colly.Collector
+	opts      entity.CrawlerOptions
+	logger    entity.Logger
+	// mu protege a contagem de páginas e os volumes encontrados, que as
+	// buscas de cada termo atualizam em paralelo
+	mu           sync.Mutex
 	pagesCrawled int
 	foundVolumes map[int]bool
+	// pause é a unidade das esperas fixas entre buscas e páginas, separada
+	// das opções para que os testes com páginas gravadas rodem sem elas
+	pause time.Duration
 }
 
-func NewPaniniProvider() *PaniniProvider {
-	return &PaniniProvider{}
+func NewPaniniProvider() entity.SiteProvider {
+	return &PaniniProvider{pause: time.Second}
 }
 
 func (p *PaniniProvider) SiteName() string {
@@ -33,26 +43,26 @@ func (p *PaniniProvider) SiteName() string {
 
 func (p *PaniniProvider) Setup(opts entity.CrawlerOptions) error {
 	p.opts = opts
+	p.logger = setupLogger(opts)
 	c := colly.NewCollector(
 		colly.UserAgent("Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"),
 		colly.AllowedDomains("www.panini.com.br", "panini.com.br"),
 	)
+	if opts.Transport != nil {
+		c.WithTransport(opts.Transport)
+	}
 	c.SetRequestTimeout(30 * time.Second)
 	c.OnRequest(func(r *colly.Request) {
 		r.Headers.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,image/avif,image/webp,image/apng,*/*;q=0.8")
 		r.Headers.Set("Accept-Language", "pt-BR,pt;q=0.9,en-US;q=0.8,en;q=0.7")
 		r.Headers.Set("Connection", "keep-alive")
 		r.Headers.Set("Cache-Control", "max-age=0")
-		fmt.Printf("Visitando: %s\n", r.URL)
-	})
-	c.OnError(func(r *colly.Response, err error) {
-		fmt.Printf("Erro ao visitar %s: %s (Status: %d)\n", r.Request.URL, err, r.StatusCode)
 	})
 	c.Limit(&colly.LimitRule{
 		DomainGlob:  "*panini.*",
 		Parallelism: p.opts.MaxConcurrency,
 		Delay:       p.opts.RequestInterval,
-		RandomDelay: 1 * time.Second,
+		RandomDelay: p.pause,
 	})
 
 	p.collector = c
@@ -69,16 +79,21 @@ func (p *PaniniProvider) Fetch(ctx context.Context, searchTerms []string) ([]ent
 	// Visitar página inicial para configurar cookies
 	err := p.collector.Visit("https://panini.com.br/")
 	if err != nil {
-		fmt.Printf("Aviso: Não foi possível visitar a página principal da Panini: %v\n", err)
+		p.logger.Warn(ctx, "failed to visit home page", map[string]any{
+			"error": err.Error(),
+		})
 	}
-	time.Sleep(1 * time.Second)
+	time.Sleep(p.pause)
 
 	var wg sync.WaitGroup
 
 	for _, baseSearchTerm := range searchTerms {
 		select {
 		case <-ctx.Done():
-			return p.processResults(allResults), nil
+			resultsMutex.Lock()
+			partial := slices.Clone(allResults)
+			resultsMutex.Unlock()
+			return p.processResults(ctx, partial), nil
 		default:
 			wg.Add(1)
 			go func(term string) {
@@ -100,7 +115,7 @@ func (p *PaniniProvider) Fetch(ctx context.Context, searchTerms []string) ([]ent
 
 			// Adiciona um pequeno atraso entre termos de busca
 			select {
-			case <-time.After(2 * time.Second):
+			case <-time.After(2 * p.pause):
 			case <-ctx.Done():
 				break
 			}
@@ -119,10 +134,16 @@ func (p *PaniniProvider) Fetch(ctx context.Context, searchTerms []string) ([]ent
 	case <-wgDone:
 		// Buscas concluídas normalmente
 	case <-ctx.Done():
-		fmt.Println("Contexto cancelado, retornando resultados parciais")
+		p.logger.Info(ctx, "crawl stopped, returning partial results", map[string]any{
+			"cause": context.Cause(ctx).Error(),
+		})
 	}
 
-	return p.processResults(allResults), nil
+	// as buscas ainda em andamento após o cancelamento seguem anexando
+	resultsMutex.Lock()
+	partial := slices.Clone(allResults)
+	resultsMutex.Unlock()
+	return p.processResults(ctx, partial), nil
 }
 
 func (p *PaniniProvider) findInitialVolumes(
@@ -149,7 +170,9 @@ func (p *PaniniProvider) findInitialVolumes(
 			return foundVolumes
 		default:
 			searchURL := fmt.Sprintf("https://panini.com.br/catalogsearch/result/?q=%s", strings.ReplaceAll(term, " ", "+"))
-			fmt.Printf("Procurando volume inicial: %s\n", term)
+			p.logger.Debug(ctx, "searching first volume", map[string]any{
+				"term": term,
+			})
 
 			c := p.collector.Clone()
 			productURLs := make([]string, 0)
@@ -174,13 +197,18 @@ func (p *PaniniProvider) findInitialVolumes(
 			// Verifica se não encontrou resultados
 			c.OnHTML("div.message.notice", func(e *colly.HTMLElement) {
 				if strings.Contains(e.Text, "não encontramos") {
-					fmt.Printf("Nenhum resultado encontrado para: %s\n", term)
+					p.logger.Info(ctx, "no results found", map[string]any{
+						"term": term,
+					})
 				}
 			})
 
 			err := c.Visit(searchURL)
 			if err != nil {
-				fmt.Printf("Erro ao visitar página de busca %s: %v\n", searchURL, err)
+				p.logger.Warn(ctx, "failed to visit search page", map[string]any{
+					"url":   searchURL,
+					"error": err.Error(),
+				})
 				continue
 			}
 			c.Wait()
@@ -197,7 +225,7 @@ func (p *PaniniProvider) findInitialVolumes(
 
 				// Adiciona um pequeno atraso entre produtos para evitar sobrecarga
 				select {
-				case <-time.After(1 * time.Second):
+				case <-time.After(p.pause):
 				case <-ctx.Done():
 					return foundVolumes
 				}
@@ -247,7 +275,6 @@ func (p *PaniniProvider) processProductAndFollowSeries(
 				continue
 			}
 
-			fmt.Printf("Processando produto: %s\n", currentURL)
 
 			c := p.collector.Clone()
 			result := entity.CrawledResult{
@@ -284,8 +311,7 @@ func (p *PaniniProvider) processProductAndFollowSeries(
 
 				// Marca este volume como encontrado - CORREÇÃO AQUI
 				if result.Volume > 0 {
-					p.foundVolumes[result.Volume] = true
-					fmt.Printf(">> Marcando volume %d como encontrado para %s\n", result.Volume, seriesTitle)
+					p.markVolumeFound(result.Volume)
 
 					// Atualiza o volume máximo se necessário
 					if result.Volume > seriesMaxVolume {
@@ -307,6 +333,11 @@ func (p *PaniniProvider) processProductAndFollowSeries(
 				if priceStr != "" {
 					if price, err := strconv.ParseFloat(priceStr, 64); err == nil {
 						result.Price = price
+					} else {
+						p.logger.Debug(ctx, "failed to parse price", map[string]any{
+							"url":   currentURL,
+							"price": priceStr,
+						})
 					}
 				}
 
@@ -324,6 +355,16 @@ func (p *PaniniProvider) processProductAndFollowSeries(
 				}
 			})
 
+			// Avaliações dos clientes, registradas depois de product-info-main
+			// para não serem descartadas quando o resultado é recriado
+			if p.opts.ImportReviews {
+				c.OnHTML(paniniReviewSelector, func(e *colly.HTMLElement) {
+					if review, ok := parsePaniniReview(e); ok {
+						result.Reviews = append(result.Reviews, review)
+					}
+				})
+			}
+
 			c.OnHTML("div.product.media", func(e *colly.HTMLElement) {
 				coverImage := e.ChildAttr("img.gallery-placeholder__image", "src")
 				if coverImage == "" {
@@ -333,7 +374,7 @@ func (p *PaniniProvider) processProductAndFollowSeries(
 					coverImage = e.ChildAttr("div.fotorama__stage__frame.fotorama__active img", "src")
 				}
 				if coverImage != "" {
-					result.CoverImage = coverImage
+					result.CoverImage = e.Request.AbsoluteURL(coverImage)
 				}
 			})
 
@@ -350,7 +391,10 @@ func (p *PaniniProvider) processProductAndFollowSeries(
 
 					switch strings.ToLower(attrLabel) {
 					case "isbn", "código do produto", "código", "referência":
-						result.ISBN = attrValue
+						// o código do produto nem sempre é um ISBN
+						if isbn, err := entity.ParseISBN(attrValue); err == nil {
+							result.ISBN = isbn
+						}
 					case "autor", "autores", "autor(es)":
 						result.Author = strings.Split(attrValue, ",")
 						// Limpa espaços nos nomes dos autores
@@ -386,11 +430,14 @@ func (p *PaniniProvider) processProductAndFollowSeries(
 					return
 				}
 
-				fmt.Printf("Encontrado volume relacionado: %s - %s\n", volumeLabel, volumeName)
+				p.logger.Debug(ctx, "related volume found", map[string]any{
+					"url":   currentURL,
+					"label": volumeLabel,
+					"name":  volumeName,
+				})
 
 				// Detecta o volume mais recente para usar como critério de parada
 				volumeLower := strings.ToLower(volumeLabel)
-				fmt.Println("Volume Lower:", volumeLower)
 				if (strings.Contains(volumeLower, "mais recente") || strings.Contains(volumeLower, "recente")) && !maxVolumeDetected{
 					latestVolURL = volumeURL
 					result.Metadata["latest_volume"] = volumeName
@@ -398,8 +445,6 @@ func (p *PaniniProvider) processProductAndFollowSeries(
 
 					// Extrai número do volume mais recente
 					latestVolNumber = entity.ExtractVolumeNumber(volumeName)
-					fmt.Println("Volume Name:", volumeName)
-					fmt.Println("Volume Number:", latestVolNumber)
 					if latestVolNumber > 0 {
 						result.Metadata["latest_volume_number"] = strconv.Itoa(latestVolNumber)
 
@@ -409,7 +454,10 @@ func (p *PaniniProvider) processProductAndFollowSeries(
 						// Atualiza o volume máximo da série se o volume mais recente for maior
 						if latestVolNumber > seriesMaxVolume {
 							seriesMaxVolume = latestVolNumber
-							fmt.Printf(">> Volume máximo da série atualizado para %d\n", seriesMaxVolume)
+							p.logger.Debug(ctx, "series max volume updated", map[string]any{
+								"series":     seriesTitle,
+								"max_volume": seriesMaxVolume,
+							})
 						}
 					}
 				} else if strings.Contains(volumeLower, "próximo") {
@@ -435,33 +483,38 @@ func (p *PaniniProvider) processProductAndFollowSeries(
 			c.OnScraped(func(r *colly.Response) {
 				if resultFound {
 					results = append(results, result)
-					fmt.Printf("Processado: %s (Vol. %d) - R$ %.2f\n", result.Title, result.Volume, result.Price)
-
-					// Dump dos volumes encontrados para depuração
-					volList := make([]int, 0)
-					for vol := range p.foundVolumes {
-						volList = append(volList, vol)
-					}
-					sort.Ints(volList)
-					fmt.Printf(">> Volumes encontrados: %v (total: %d)\n", volList, len(p.foundVolumes))
+					volList := p.foundVolumeList()
+					p.logger.Debug(ctx, "product parsed", map[string]any{
+						"url":           currentURL,
+						"title":         result.Title,
+						"volume":        result.Volume,
+						"price":         result.Price,
+						"found_volumes": len(volList),
+					})
 
 					// Verifica se encontramos todos os volumes da série
 					if maxVolumeDetected && seriesMaxVolume > 0 {
 						// Conta quantos volumes encontramos
-						foundCount := len(p.foundVolumes)
+						foundCount := len(volList)
 
 						// Se encontramos todos ou quase todos (90%) dos volumes, podemos parar
 						completeness := float64(foundCount) / float64(seriesMaxVolume)
 						if completeness >= 0.9 {
-							fmt.Printf("Encontrados %d/%d volumes (%.2f%%). Considerando a série completa.\n",
-								foundCount, seriesMaxVolume, completeness*100)
+							p.logger.Info(ctx, "series considered complete", map[string]any{
+								"series":     seriesTitle,
+								"found":      foundCount,
+								"max_volume": seriesMaxVolume,
+							})
 							// Esvazia a lista toVisit para encerrar o loop
 							toVisit = nil
 							return
 						}
 
-						fmt.Printf("Progresso: %d/%d volumes encontrados (%.2f%%).\n",
-							foundCount, seriesMaxVolume, completeness*100)
+						p.logger.Debug(ctx, "series progress", map[string]any{
+							"series":     seriesTitle,
+							"found":      foundCount,
+							"max_volume": seriesMaxVolume,
+						})
 					}
 
 					// Adiciona próximos URLs para visitar se ainda não foram visitados
@@ -474,7 +527,7 @@ func (p *PaniniProvider) processProductAndFollowSeries(
 						if len(toVisit) == 0 {
 							// Encontra volumes faltantes
 							for vol := 1; vol <= seriesMaxVolume; vol++ {
-								if !p.foundVolumes[vol] {
+								if !p.isVolumeFound(vol) {
 									// Gera URL para tentar encontrar o volume faltante
 									searchURL := fmt.Sprintf("https://panini.com.br/catalogsearch/result/?q=%s+vol+%d",
 										strings.ReplaceAll(seriesTitle, " ", "+"), vol)
@@ -482,7 +535,10 @@ func (p *PaniniProvider) processProductAndFollowSeries(
 									if !visitedURLs[searchURL] {
 										visitedURLs[searchURL] = true
 										toVisit = append(toVisit, searchURL)
-										fmt.Printf("Adicionando busca por volume faltante: %d\n", vol)
+										p.logger.Debug(ctx, "missing volume search queued", map[string]any{
+											"series": seriesTitle,
+											"volume": vol,
+										})
 									}
 								}
 							}
@@ -506,12 +562,19 @@ func (p *PaniniProvider) processProductAndFollowSeries(
 					}
 
 					visitedMutex.Unlock()
+				} else {
+					p.logger.Warn(ctx, "product page not parsed", map[string]any{
+						"url": currentURL,
+					})
 				}
 			})
 
 			err := c.Visit(currentURL)
 			if err != nil {
-				fmt.Printf("Erro ao visitar página do produto %s: %v\n", currentURL, err)
+				p.logger.Warn(ctx, "failed to visit product page", map[string]any{
+					"url":   currentURL,
+					"error": err.Error(),
+				})
 			}
 
 			// Espera pela conclusão da visita
@@ -519,7 +582,7 @@ func (p *PaniniProvider) processProductAndFollowSeries(
 
 			// Adiciona um pequeno atraso entre visitas
 			select {
-			case <-time.After(2 * time.Second):
+			case <-time.After(2 * p.pause):
 			case <-ctx.Done():
 				return results
 			}
@@ -537,20 +600,95 @@ func (p *PaniniProvider) processProductAndFollowSeries(
 				if itemSeriesTitle == seriesTitle {
 					results[i].Metadata["series_max_volume"] = strconv.Itoa(seriesMaxVolume)
 					results[i].Metadata["series_total_expected"] = strconv.Itoa(seriesMaxVolume)
-					results[i].Metadata["series_found_count"] = strconv.Itoa(len(p.foundVolumes))
+					foundCount := len(p.foundVolumeList())
+					results[i].Metadata["series_found_count"] = strconv.Itoa(foundCount)
 
-					completeness := float64(len(p.foundVolumes)) / float64(seriesMaxVolume)
+					completeness := float64(foundCount) / float64(seriesMaxVolume)
 					results[i].Metadata["series_completeness"] = fmt.Sprintf("%.2f", completeness)
 				}
 			}
 		}
 	}
 
-	fmt.Printf("Processamento da série finalizado. Encontrados %d/%d volumes.\n",
-		len(p.foundVolumes), seriesMaxVolume)
+	p.logger.Info(ctx, "series crawl finished", map[string]any{
+		"url":        startURL,
+		"series":     seriesTitle,
+		"found":      len(p.foundVolumeList()),
+		"max_volume": seriesMaxVolume,
+	})
 	return results
 }
 
+func (p *PaniniProvider) markVolumeFound(volume int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.foundVolumes[volume] = true
+}
+
+func (p *PaniniProvider) isVolumeFound(volume int) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.foundVolumes[volume]
+}
+
+// foundVolumeList devolve os volumes encontrados até agora, em ordem.
+func (p *PaniniProvider) foundVolumeList() []int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	volumes := make([]int, 0, len(p.foundVolumes))
+	for volume := range p.foundVolumes {
+		volumes = append(volumes, volume)
+	}
+	sort.Ints(volumes)
+	return volumes
+}
+
+// nextPage reserva mais uma página de resultados dentro do limite MaxPages
+// e devolve quantas já foram seguidas.
+func (p *PaniniProvider) nextPage() (int, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.pagesCrawled >= p.opts.MaxPages {
+		return p.pagesCrawled, false
+	}
+	p.pagesCrawled++
+	return p.pagesCrawled, true
+}
+
+// parsePaniniReview lê uma avaliação da lista de avaliações do produto. A
+// nota vem como porcentagem na largura da barra de estrelas.
+func parsePaniniReview(e *colly.HTMLElement) (entity.CrawledReview, bool) {
+	review := entity.CrawledReview{
+		Title:   strings.TrimSpace(e.ChildText("div.review-title")),
+		Author:  strings.TrimSpace(e.ChildText("p.review-author strong.review-details-value")),
+		Content: strings.TrimSpace(e.ChildText("div.review-content")),
+	}
+	ratingText := e.ChildAttr("div.rating-result", "title")
+	if ratingText == "" {
+		ratingText = e.ChildAttr("div.rating-result > span", "style")
+	}
+	if percent := parseRating(ratingText); percent > 0 {
+		review.Rating = percent / 20
+	}
+	dateText := e.ChildAttr("p.review-date time", "datetime")
+	if dateText == "" {
+		dateText = strings.TrimSpace(e.ChildText("p.review-date time"))
+	}
+	if len(dateText) >= 10 {
+		dateText = dateText[:10]
+	}
+	for _, layout := range []string{"2006-01-02", "02/01/2006"} {
+		if date, err := time.Parse(layout, dateText); err == nil {
+			review.Date = date.Format("2006-01-02")
+			break
+		}
+	}
+	if review.Title == "" && review.Content == "" {
+		return review, false
+	}
+	return review, true
+}
+
 // searchGenericTerm realiza uma busca genérica quando não conseguimos encontrar volumes específicos
 func (p *PaniniProvider) searchGenericTerm(
 	ctx context.Context,
@@ -559,7 +697,9 @@ func (p *PaniniProvider) searchGenericTerm(
 	visitedMutex *sync.Mutex,
 ) []entity.CrawledResult {
 	searchURL := fmt.Sprintf("https://panini.com.br/catalogsearch/result/?q=%s", strings.ReplaceAll(term, " ", "+"))
-	fmt.Printf("Realizando busca genérica: %s\n", term)
+	p.logger.Debug(ctx, "searching term", map[string]any{
+		"term": term,
+	})
 
 	c := p.collector.Clone()
 	results := make([]entity.CrawledResult, 0)
@@ -586,12 +726,19 @@ func (p *PaniniProvider) searchGenericTerm(
 	// Verifica paginação - CORRIGIDO para evitar loops
 	c.OnHTML("ul.pages-items li.pages-item-next a", func(e *colly.HTMLElement) {
 		nextPageURL := e.Request.AbsoluteURL(e.Attr("href"))
-		if nextPageURL != "" && p.pagesCrawled < p.opts.MaxPages {
+		if nextPageURL != "" {
 			// Verifica se já visitamos esta página
 			if _, exists := pageURLs[nextPageURL]; !exists {
+				page, ok := p.nextPage()
+				if !ok {
+					return
+				}
 				pageURLs[nextPageURL] = true
-				p.pagesCrawled++
-				fmt.Printf("Seguindo para próxima página: %s (página %d)\n", nextPageURL, p.pagesCrawled+1)
+				p.logger.Debug(ctx, "following next page", map[string]any{
+					"term": term,
+					"url":  nextPageURL,
+					"page": page + 1,
+				})
 
 				// Usar Visit em vez de VisitURL para manter o contexto
 				c.Visit(nextPageURL)
@@ -599,19 +746,20 @@ func (p *PaniniProvider) searchGenericTerm(
 		}
 	})
 
-	c.OnRequest(func(r *colly.Request) {
-		fmt.Printf("Visitando página de busca: %s\n", r.URL)
-	})
-
 	err := c.Visit(searchURL)
 	if err != nil {
-		fmt.Printf("Erro ao visitar página de busca %s: %v\n", searchURL, err)
+		p.logger.Error(ctx, "failed to visit search page", err, map[string]any{
+			"url": searchURL,
+		})
 		return results
 	}
 
 	// Esperar pela conclusão das visitas - importante para paginação
 	c.Wait()
-	fmt.Printf("Concluída busca genérica: %s - Encontrados %d produtos\n", term, len(productURLs))
+	p.logger.Info(ctx, "term searched", map[string]any{
+		"term":     term,
+		"products": len(productURLs),
+	})
 
 	// Processa cada produto encontrado
 	for _, productURL := range productURLs {
@@ -623,7 +771,7 @@ func (p *PaniniProvider) searchGenericTerm(
 			results = append(results, seriesResults...)
 
 			// Adiciona um pequeno atraso entre produtos
-			time.Sleep(1 * time.Second)
+			time.Sleep(p.pause)
 		}
 	}
 
@@ -631,7 +779,7 @@ func (p *PaniniProvider) searchGenericTerm(
 }
 
 // processResults processa os resultados finais, agrupando-os por série e adicionando metadados úteis
-func (p *PaniniProvider) processResults(results []entity.CrawledResult) []entity.CrawledResult {
+func (p *PaniniProvider) processResults(ctx context.Context, results []entity.CrawledResult) []entity.CrawledResult {
 	if len(results) == 0 {
 		return results
 	}
@@ -697,14 +845,21 @@ func (p *PaniniProvider) processResults(results []entity.CrawledResult) []entity
 	uniqueResults := make([]entity.CrawledResult, 0)
 
 	for _, result := range processedResults {
-		if !seen[result.URL] {
-			seen[result.URL] = true
-			uniqueResults = append(uniqueResults, result)
+		if seen[result.URL] {
+			p.logger.Debug(ctx, "duplicate url dropped", map[string]any{
+				"url":   result.URL,
+				"title": result.Title,
+			})
+			continue
 		}
+		seen[result.URL] = true
+		uniqueResults = append(uniqueResults, result)
 	}
 
-	fmt.Printf("Panini: processamento finalizado com %d resultados, %d resultados únicos\n",
-		len(processedResults), len(uniqueResults))
+	p.logger.Debug(ctx, "results processed", map[string]any{
+		"results": len(processedResults),
+		"unique":  len(uniqueResults),
+	})
 
 	return uniqueResults
 }
@@ -761,7 +916,10 @@ func (p *PaniniProvider) handleSearchPage(
 
 	err := c.Visit(searchURL)
 	if err != nil {
-		fmt.Printf("Erro ao visitar página de busca %s: %v\n", searchURL, err)
+		p.logger.Warn(ctx, "failed to visit search page", map[string]any{
+			"url":   searchURL,
+			"error": err.Error(),
+		})
 	}
 
 	c.Wait()
@@ -0,0 +1,56 @@
+package provider
+
+import (
+	"akira/internal/entity"
+	"testing"
+	"time"
+
+	"github.com/gocolly/colly/v2"
+)
+
+func TestParsePaniniReview(t *testing.T) {
+	reviews := make([]entity.CrawledReview, 0)
+	visitFixture(t, "panini_product.html", paniniReviewSelector, func(e *colly.HTMLElement) {
+		if review, ok := parsePaniniReview(e); ok {
+			reviews = append(reviews, review)
+		}
+	})
+
+	want := []entity.CrawledReview{
+		{
+			Author:  "Rafael",
+			Title:   "Melhor arco",
+			Content: "Wano terminando em grande estilo.",
+			Rating:  5,
+			Date:    "2023-08-20",
+		},
+		{
+			Author:  "Júlia",
+			Title:   "Entrega atrasada",
+			Content: "O mangá é bom, mas demorou para chegar.",
+			Rating:  3,
+			Date:    "2023-09-02",
+		},
+	}
+	if len(reviews) != len(want) {
+		t.Fatalf("got %d reviews, want %d: %+v", len(reviews), len(want), reviews)
+	}
+	for i := range want {
+		if reviews[i] != want[i] {
+			t.Errorf("review %d = %+v, want %+v", i, reviews[i], want[i])
+		}
+	}
+}
+
+func TestPaniniFetchReplay(t *testing.T) {
+	provider := &PaniniProvider{}
+	if *record {
+		provider.pause = time.Second
+	}
+	results := fetchReplay(t, provider, entity.CrawlerOptions{
+		MaxPages:       5,
+		MaxConcurrency: 1,
+		ImportReviews:  true,
+	}, []string{"Dandadan"})
+	assertGolden(t, "panini_fetch", results)
+}
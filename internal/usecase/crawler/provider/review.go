@@ -0,0 +1,58 @@
+package provider
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ratingPattern         = regexp.MustCompile(`\d+(?:[.,]\d+)?`)
+	portugueseDatePattern = regexp.MustCompile(`(\d{1,2}) de (\p{L}+) de (\d{4})`)
+)
+
+var portugueseMonths = map[string]time.Month{
+	"janeiro":   time.January,
+	"fevereiro": time.February,
+	"março":     time.March,
+	"abril":     time.April,
+	"maio":      time.May,
+	"junho":     time.June,
+	"julho":     time.July,
+	"agosto":    time.August,
+	"setembro":  time.September,
+	"outubro":   time.October,
+	"novembro":  time.November,
+	"dezembro":  time.December,
+}
+
+// parseRating reads the first number of a rating label such as
+// "4,5 de 5 estrelas", returning zero when there is none.
+func parseRating(text string) float64 {
+	match := ratingPattern.FindString(text)
+	if match == "" {
+		return 0
+	}
+	rating, err := strconv.ParseFloat(strings.ReplaceAll(match, ",", "."), 64)
+	if err != nil {
+		return 0
+	}
+	return rating
+}
+
+// parsePortugueseDate finds a date written as "12 de março de 2024"
+// anywhere in the text.
+func parsePortugueseDate(text string) (time.Time, bool) {
+	match := portugueseDatePattern.FindStringSubmatch(strings.ToLower(text))
+	if match == nil {
+		return time.Time{}, false
+	}
+	month, ok := portugueseMonths[match[2]]
+	if !ok {
+		return time.Time{}, false
+	}
+	day, _ := strconv.Atoi(match[1])
+	year, _ := strconv.Atoi(match[3])
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC), true
+}
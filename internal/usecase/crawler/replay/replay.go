@@ -0,0 +1,119 @@
+// Package replay serves recorded store pages in place of the live sites, so
+// crawler providers can be exercised offline.
+package replay
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+var _ http.RoundTripper = (*Transport)(nil)
+
+var unsafeKeyChars = regexp.MustCompile(`[^A-Za-z0-9._=-]+`)
+
+// Transport answers requests with the page recorded for their URL. Pages
+// that were never recorded are answered with 404 and remembered, so tests
+// can report what is missing.
+type Transport struct {
+	dir    string
+	next   http.RoundTripper
+	mu     sync.Mutex
+	missed map[string]bool
+}
+
+// NewTransport replays the pages recorded in dir.
+func NewTransport(dir string) *Transport {
+	return &Transport{dir: dir, missed: make(map[string]bool)}
+}
+
+// NewRecorder fetches every page through next and saves it in dir before
+// answering, so a later NewTransport on the same dir replays it.
+func NewRecorder(dir string, next http.RoundTripper) *Transport {
+	return &Transport{dir: dir, next: next, missed: make(map[string]bool)}
+}
+
+// Key is the file name the page for u is recorded under. The scheme and the
+// fragment are ignored and the query is sorted, so equivalent URLs share a
+// recording.
+func Key(u *url.URL) string {
+	key := u.Host + u.Path
+	if query := u.Query().Encode(); query != "" {
+		key += "?" + query
+	}
+	key = unsafeKeyChars.ReplaceAllString(key, "_")
+	return strings.Trim(key, "_") + ".html"
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	path := filepath.Join(t.dir, Key(req.URL))
+	if t.next != nil {
+		return t.record(req, path)
+	}
+	body, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		t.mu.Lock()
+		t.missed[req.URL.String()] = true
+		t.mu.Unlock()
+		return newResponse(req, http.StatusNotFound, nil), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return newResponse(req, http.StatusOK, body), nil
+}
+
+// Missed lists the URLs requested without a recording, sorted.
+func (t *Transport) Missed() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	missed := make([]string, 0, len(t.missed))
+	for u := range t.missed {
+		missed = append(missed, u)
+	}
+	sort.Strings(missed)
+	return missed
+}
+
+func (t *Transport) record(req *http.Request, path string) (*http.Response, error) {
+	res, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := io.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode == http.StatusOK {
+		if err := os.MkdirAll(t.dir, 0o755); err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(path, body, 0o644); err != nil {
+			return nil, err
+		}
+	}
+	res.Body = io.NopCloser(bytes.NewReader(body))
+	return res, nil
+}
+
+func newResponse(req *http.Request, status int, body []byte) *http.Response {
+	return &http.Response{
+		Status:        http.StatusText(status),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": []string{"text/html; charset=utf-8"}},
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
@@ -4,211 +4,503 @@ import (
 	"akira/internal/entity"
 	"akira/internal/usecase/crawler/provider"
 	"context"
+	"errors"
+	"sort"
 	"sync"
 	"time"
 )
 
 var _ entity.CrawlerService = (*Service)(nil)
 
+const (
+	crawlRunWorkers      = 2
+	crawlRunMaxAttempts  = 3
+	crawlRunTimeout      = 10 * time.Minute
+	crawlRunPollInterval = 5 * time.Second
+	shutdownTimeout      = 10 * time.Second
+)
+
 type Service struct {
-	providers      map[string]entity.SiteProvider
-	event          entity.EventService
-	logger         entity.Logger
-	ctx            context.Context
-	activeCrawlers sync.Map
-	results        sync.Map
+	providers  map[string]entity.SiteProviderFactory
+	politeness *politeness
+	pages      *pageCache
+	repo       entity.CrawlRunRepository
+	event      entity.EventService
+	logger     entity.Logger
+	ctx        context.Context
+	cancelFunc context.CancelFunc
+	mu         sync.Mutex
+	running    sync.Map
+	// cancelled holds the IDs of runs cancelled after a worker claimed them
+	// but before it could be interrupted
+	cancelled sync.Map
+	wake      chan struct{}
+	wg        sync.WaitGroup
 }
 
 func NewService(
 	ctx context.Context,
+	repo entity.CrawlRunRepository,
+	pages entity.PageCacheRepository,
 	event entity.EventService,
 	logger entity.Logger,
+	policy entity.CrawlPolicy,
 ) *Service {
+	workerCtx, cancel := context.WithCancel(ctx)
 	service := &Service{
-		providers: make(map[string]entity.SiteProvider),
-		event:     event,
-		logger:    logger,
-		ctx:       ctx,
+		providers:  make(map[string]entity.SiteProviderFactory),
+		politeness: newPoliteness(policy, logger),
+		pages:      newPageCache(pages, policy, logger),
+		repo:       repo,
+		event:      event,
+		logger:     logger,
+		ctx:        workerCtx,
+		cancelFunc: cancel,
+		wake:       make(chan struct{}, 1),
 	}
-	service.RegisterProvider(provider.NewAmazonProvider())
-	service.RegisterProvider(provider.NewPaniniProvider())
+	service.RegisterProvider(provider.NewAmazonProvider)
+	service.RegisterProvider(provider.NewPaniniProvider)
 	return service
 }
 
-func (s *Service) RegisterProvider(provider entity.SiteProvider) {
-	s.providers[provider.SiteName()] = provider
+// RegisterProvider adds the site of the providers built by factory. Every
+// run gets its own provider from the factory. A site keeps the first provider
+// registered for it.
+func (s *Service) RegisterProvider(factory entity.SiteProviderFactory) error {
+	site := factory().SiteName()
+	if _, ok := s.providers[site]; ok {
+		return entity.ErrCrawlerProviderExists
+	}
+	s.providers[site] = factory
+	return nil
+}
+
+func (s *Service) Providers() []string {
+	names := make([]string, 0, len(s.providers))
+	for name := range s.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Start recovers the runs interrupted by the last shutdown, prunes the page
+// cache and starts the workers that consume the queue.
+func (s *Service) Start() error {
+	if err := s.recoverRuns(); err != nil {
+		s.logger.Error(s.ctx, "failed to recover crawl runs", err, nil)
+		return err
+	}
+	s.pages.prune(s.ctx)
+	for i := 0; i < crawlRunWorkers; i++ {
+		s.wg.Add(1)
+		go s.work()
+	}
+	return nil
+}
+
+// Shutdown stops the workers. Runs still in progress are left as running, so
+// the next Start resumes them.
+func (s *Service) Shutdown() error {
+	s.cancelFunc()
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(shutdownTimeout):
+		s.logger.Warn(context.Background(), "crawler workers did not stop in time", nil)
+	}
+	return nil
 }
 
 func (s *Service) FetchCollection(ctx context.Context, req entity.CrawlerRequest) error {
-	if _, exists := s.activeCrawlers.Load(req.CollectionID); exists {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	latest, err := s.repo.FindLatestCrawlRun(req.CollectionID)
+	if err != nil && err != entity.ErrNotFound {
+		s.logger.Error(ctx, "failed to find latest crawl run", err, map[string]any{
+			"collection_id": req.CollectionID,
+		})
+		return err
+	}
+	if latest != nil && latest.Status.IsActive() {
 		return entity.ErrCrawlerAlreadyRunning
 	}
-	s.activeCrawlers.Store(req.CollectionID, entity.SyncStatusFetching)
-	go func() {
-		crawlerCtx, cancel := context.WithTimeout(ctx, 10*time.Minute)
-		defer cancel()
-		s.activeCrawlers.Store(req.CollectionID, cancel)
-		s.event.Publish(entity.NewEvent(
-			entity.EventCrawlerStarted,
-			"",
-			map[string]any{
-				"collection_id": req.CollectionID,
-				"search_terms":  req.SearchTerms,
-				"sites":         req.Sites,
-			},
-		))
-		s.logger.Info(crawlerCtx, "crawler started", map[string]any{
+	run := entity.NewCrawlRun(req)
+	if err := s.repo.CreateCrawlRun(run); err != nil {
+		s.logger.Error(ctx, "failed to queue crawl run", err, map[string]any{
 			"collection_id": req.CollectionID,
-			"search_terms":  req.SearchTerms,
-			"sites":         req.Sites,
 		})
-		resultsChan := make(chan entity.CrawledResult, 100)
-		errChan := make(chan error, len(req.Sites))
-		var wg sync.WaitGroup
-		for _, site := range req.Sites {
-			provider, ok := s.providers[site]
-			if !ok {
-				s.logger.Warn(crawlerCtx, "provider not found", map[string]any{
-					"site": site,
-				})
-				continue
-			}
-			wg.Add(1)
-			go func(site string, provider entity.SiteProvider) {
-				defer wg.Done()
-				err := provider.Setup(req.Opts)
-				if err != nil {
-					s.logger.Error(crawlerCtx, "provider setup failed", err, map[string]any{
-						"site": site,
-					})
-					errChan <- err
-					return
-				}
-				results, err := provider.Fetch(crawlerCtx, req.SearchTerms)
-				if err != nil {
-					s.logger.Error(crawlerCtx, "provider fetch failed", err, map[string]any{
-						"site": site,
-					})
-					errChan <- err
-					return
-				}
-				for _, result := range results {
-					select {
-					case resultsChan <- result:
-						s.event.Publish(entity.NewEvent(
-							entity.EventCrawlerItemFounded,
-							"",
-							map[string]any{
-								"collection_id": req.CollectionID,
-								"site":          site,
-								"result":        result,
-							},
-						))
-					case <-crawlerCtx.Done():
-						s.logger.Info(crawlerCtx, "crawler context done", map[string]any{
-							"site": site,
-						})
-						return
-					}
-				}
-			}(site, provider)
+		return err
+	}
+	s.logger.Info(ctx, "crawl run queued", map[string]any{
+		"run_id":        run.ID,
+		"collection_id": run.CollectionID,
+	})
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// RetryFetch queues again the request of the latest run of the collection,
+// when it failed or was cancelled.
+func (s *Service) RetryFetch(ctx context.Context, collectionID string) error {
+	run, err := s.LatestRun(collectionID)
+	if err != nil {
+		if err == entity.ErrNotFound {
+			return entity.ErrCrawlerNotRetryable
 		}
-		go func() {
-			wg.Wait()
-			close(resultsChan)
-			close(errChan)
-			s.logger.Debug(crawlerCtx, "all providers finished", nil)
-		}()
-		var results []entity.CrawledResult
-		for result := range resultsChan {
-			results = append(results, result)
-			s.logger.Debug(crawlerCtx, "received result", map[string]any{
-				"title":  result.Title,
-				"volume": result.Volume,
-				"source": result.Source,
+		return err
+	}
+	switch run.Status {
+	case entity.CrawlRunFailed, entity.CrawlRunCancelled:
+	case entity.CrawlRunQueued, entity.CrawlRunRunning:
+		return entity.ErrCrawlerAlreadyRunning
+	default:
+		return entity.ErrCrawlerNotRetryable
+	}
+	return s.FetchCollection(ctx, run.Request())
+}
+
+func (s *Service) LatestRun(collectionID string) (*entity.CrawlRun, error) {
+	run, err := s.repo.FindLatestCrawlRun(collectionID)
+	if err != nil {
+		if err != entity.ErrNotFound {
+			s.logger.Error(s.ctx, "failed to find latest crawl run", err, map[string]any{
+				"collection_id": collectionID,
 			})
 		}
+		return nil, err
+	}
+	return run, nil
+}
 
-		s.logger.Info(crawlerCtx, "collected results from channel", map[string]any{
-			"result_count": len(results),
+func (s *Service) GetStatus(collectionID string) (entity.SyncStatus, error) {
+	run, err := s.repo.FindLatestCrawlRun(collectionID)
+	if err != nil {
+		if err == entity.ErrNotFound {
+			return entity.SyncStatusNotFound, nil
+		}
+		return "", err
+	}
+	return run.Status.SyncStatus(), nil
+}
+
+func (s *Service) CancelFetch(collectionID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if value, ok := s.running.Load(collectionID); ok {
+		// the worker records the run as cancelled once the providers stop
+		value.(context.CancelCauseFunc)(entity.ErrCrawlRunCancelled)
+		return nil
+	}
+	run, err := s.repo.FindLatestCrawlRun(collectionID)
+	if err != nil {
+		if err == entity.ErrNotFound {
+			return entity.ErrCrawlerNotRunning
+		}
+		return err
+	}
+	switch run.Status {
+	case entity.CrawlRunQueued:
+		errs := []error{entity.ErrCrawlRunCancelled}
+		setRunOutcome(run, entity.CrawlRunCancelled, 0, errs)
+		err := s.repo.CancelQueuedCrawlRun(run)
+		if err == nil {
+			s.announceRun(s.logger, run, entity.CrawlRunCancelled, 0, errs)
+			return nil
+		}
+		if err != entity.ErrNotFound {
+			return err
+		}
+		// claimed by a worker since it was read, which can't start the
+		// providers before the lock is released
+		s.cancelled.Store(run.ID, struct{}{})
+		return nil
+	case entity.CrawlRunRunning:
+		// claimed by a worker that hasn't started the providers yet
+		s.cancelled.Store(run.ID, struct{}{})
+		return nil
+	}
+	return entity.ErrCrawlerNotRunning
+}
+
+func (s *Service) ListRuns(collectionID string, limit int) ([]entity.CrawlRun, error) {
+	return s.repo.ListCrawlRuns(collectionID, limit)
+}
+
+func (s *Service) RunLog(collectionID, runID string) ([]entity.CrawlLogEntry, error) {
+	entries, err := s.repo.ListCrawlLogEntries(collectionID, runID, crawlLogMaxEntries+1)
+	if err != nil {
+		s.logger.Error(s.ctx, "failed to list crawl log", err, map[string]any{
+			"run_id":        runID,
+			"collection_id": collectionID,
 		})
+		return nil, err
+	}
+	return entries, nil
+}
 
-		var errors []error
-		for err := range errChan {
-			errors = append(errors, err)
-			s.logger.Debug(crawlerCtx, "received error", map[string]any{
-				"error": err.Error(),
+func (s *Service) recoverRuns() error {
+	runs, err := s.repo.FindCrawlRunsByStatus(entity.CrawlRunRunning)
+	if err != nil {
+		return err
+	}
+	for i := range runs {
+		run := &runs[i]
+		if run.Attempts >= crawlRunMaxAttempts {
+			s.finishRun(s.logger, run, entity.CrawlRunFailed, 0, []error{entity.ErrCrawlRunInterrupted})
+			continue
+		}
+		run.Status = entity.CrawlRunQueued
+		run.UpdatedAt = time.Now()
+		if err := s.repo.UpdateCrawlRun(run); err != nil {
+			return err
+		}
+		s.logger.Info(s.ctx, "interrupted crawl run requeued", map[string]any{
+			"run_id":        run.ID,
+			"collection_id": run.CollectionID,
+			"attempts":      run.Attempts,
+		})
+	}
+	return nil
+}
+
+func (s *Service) work() {
+	defer s.wg.Done()
+	ticker := time.NewTicker(crawlRunPollInterval)
+	defer ticker.Stop()
+	for {
+		if s.ctx.Err() != nil {
+			return
+		}
+		run, err := s.repo.ClaimNextCrawlRun(time.Now())
+		if err == nil {
+			s.execute(run)
+			continue
+		}
+		if err != entity.ErrNotFound {
+			s.logger.Error(s.ctx, "failed to claim crawl run", err, nil)
+		}
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-s.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Service) execute(run *entity.CrawlRun) {
+	cancelCtx, cancel := context.WithCancelCause(s.ctx)
+	defer cancel(nil)
+	crawlerCtx, cancelTimeout := context.WithTimeoutCause(cancelCtx, crawlRunTimeout, entity.ErrCrawlRunTimeout)
+	defer cancelTimeout()
+	s.mu.Lock()
+	s.running.Store(run.CollectionID, cancel)
+	if _, ok := s.cancelled.LoadAndDelete(run.ID); ok {
+		cancel(entity.ErrCrawlRunCancelled)
+	}
+	s.mu.Unlock()
+	defer s.running.Delete(run.CollectionID)
+	req := run.Request()
+	log := newCrawlLog(s.logger, s.repo.AddCrawlLogEntry, run)
+	s.event.Publish(entity.NewEvent(
+		entity.EventCrawlerStarted,
+		req.UserID,
+		map[string]any{
+			"run_id":        run.ID,
+			"collection_id": req.CollectionID,
+			"search_terms":  req.SearchTerms,
+			"sites":         req.Sites,
+		},
+	))
+	log.Info(crawlerCtx, "crawler started", map[string]any{
+		"search_terms":  req.SearchTerms,
+		"sites":         req.Sites,
+		"attempt":       run.Attempts,
+		"force_refresh": req.Opts.ForceRefresh,
+	})
+	var mu sync.Mutex
+	var errs []error
+	var found []entity.CrawledResult
+	stats := make(map[string]entity.CrawlRunProviderStats, len(req.Sites))
+	record := func(site string, results int, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		stat := stats[site]
+		stat.Results += results
+		if err != nil {
+			stat.Errors++
+			stat.Error = err.Error()
+			errs = append(errs, err)
+		}
+		stats[site] = stat
+	}
+	var wg sync.WaitGroup
+	for _, site := range req.Sites {
+		factory, ok := s.providers[site]
+		if !ok {
+			log.Warn(crawlerCtx, "provider not found", map[string]any{
+				"site": site,
 			})
+			record(site, 0, entity.ErrCrawlerProviderNotFound)
+			continue
 		}
-		s.results.Store(req.CollectionID, results)
-		if len(errors) > 0 && len(results) == 0 {
-			s.activeCrawlers.Store(req.CollectionID, entity.SyncStatusFailed)
-			s.event.Publish(entity.NewEvent(
-				entity.EventCrawlerFailed,
-				"",
-				map[string]any{
-					"collection_id": req.CollectionID,
-					"errors":        errors,
-				},
-			))
-			s.logger.Error(crawlerCtx, "crawler failed", nil, map[string]any{
-				"collection_id": req.CollectionID,
-				"errors":        errors,
+		provider := factory()
+		siteLog := log.forSite(site)
+		opts := req.Opts
+		opts.Logger = siteLog
+		// cached pages skip the politeness limits, as they reach no store
+		opts.Transport = siteLog.transport(s.pages.transport(
+			s.politeness.transport(crawlerCtx, site, opts.Transport),
+			opts.ForceRefresh,
+		))
+		wg.Add(1)
+		go func(site string, provider entity.SiteProvider) {
+			defer wg.Done()
+			err := provider.Setup(opts)
+			if err != nil {
+				siteLog.Error(crawlerCtx, "provider setup failed", err, nil)
+				record(site, 0, err)
+				return
+			}
+			// a provider stopped by the store still returns what it found
+			results, err := provider.Fetch(crawlerCtx, req.SearchTerms)
+			if err != nil {
+				siteLog.Error(crawlerCtx, "provider fetch failed", err, map[string]any{
+					"results": len(results),
+				})
+			} else {
+				siteLog.Info(crawlerCtx, "provider finished", map[string]any{
+					"results": len(results),
+				})
+			}
+			mu.Lock()
+			found = append(found, results...)
+			mu.Unlock()
+			record(site, len(results), err)
+		}(site, provider)
+	}
+	wg.Wait()
+	if s.ctx.Err() != nil {
+		s.logger.Info(context.Background(), "crawl run interrupted by shutdown", map[string]any{
+			"run_id":        run.ID,
+			"collection_id": run.CollectionID,
+		})
+		return
+	}
+	resultCount := s.publishResults(crawlerCtx, run, mergeResults(found))
+	run.Providers = stats
+	status := entity.CrawlRunCompleted
+	switch {
+	case errors.Is(context.Cause(crawlerCtx), entity.ErrCrawlRunCancelled):
+		status = entity.CrawlRunCancelled
+		errs = append(errs, entity.ErrCrawlRunCancelled)
+	case errors.Is(context.Cause(crawlerCtx), entity.ErrCrawlRunTimeout) && resultCount == 0:
+		status = entity.CrawlRunFailed
+		errs = append(errs, entity.ErrCrawlRunTimeout)
+	case len(errs) > 0 && resultCount == 0:
+		status = entity.CrawlRunFailed
+	}
+	s.finishRun(log, run, status, resultCount, errs)
+}
+
+// publishResults announces the merged volumes found by the run and returns
+// how many were published. A run that timed out still publishes what its
+// providers found, while a cancelled one publishes nothing more.
+func (s *Service) publishResults(ctx context.Context, run *entity.CrawlRun, results []entity.CrawledResult) int {
+	published := 0
+	for _, result := range results {
+		if errors.Is(context.Cause(ctx), entity.ErrCrawlRunCancelled) {
+			s.logger.Info(ctx, "crawl run cancelled before publishing all results", map[string]any{
+				"run_id":    run.ID,
+				"published": published,
 			})
-			return
+			break
 		}
-		s.activeCrawlers.Store(req.CollectionID, entity.SyncStatusSynced)
 		s.event.Publish(entity.NewEvent(
-			entity.EventCrawlerCompleted,
-			"",
+			entity.EventCrawlerItemFounded,
+			run.UserID,
 			map[string]any{
-				"collection_id": req.CollectionID,
-				"result_count":  len(results),
-				"has_errors":    len(errors) > 0,
+				"run_id":        run.ID,
+				"collection_id": run.CollectionID,
+				"site":          result.Source,
+				"result":        result,
 			},
 		))
-		s.logger.Info(crawlerCtx, "crawler completed", map[string]any{
-			"collection_id": req.CollectionID,
-			"result_count":  len(results),
-			"error_count":   len(errors),
-		})
-		s.logger.Debug(crawlerCtx, "crawler results", map[string]any{
-			"collection_id": req.CollectionID,
-			"results":       results,
-			"errors":        errors,
-		})
-	}()
-	return nil
+		published++
+	}
+	return published
 }
 
-func (s *Service) GetStatus(collectionID string) (entity.SyncStatus, error) {
-	status, exists := s.activeCrawlers.Load(collectionID)
-	if !exists {
-		return entity.SyncStatusNotFound, nil
-	}
-	if status, ok := status.(entity.SyncStatus); ok {
-		return status, nil
+// finishRun stores the final state of the run and publishes the matching
+// crawler event. The outcome goes to log, the log of the run when it was
+// executed.
+func (s *Service) finishRun(log entity.Logger, run *entity.CrawlRun, status entity.CrawlRunStatus, resultCount int, errs []error) {
+	setRunOutcome(run, status, resultCount, errs)
+	if err := s.repo.UpdateCrawlRun(run); err != nil {
+		s.logger.Error(s.ctx, "failed to update crawl run", err, map[string]any{
+			"run_id":        run.ID,
+			"collection_id": run.CollectionID,
+		})
 	}
-	return entity.SyncStatusFetching, nil
+	s.announceRun(log, run, status, resultCount, errs)
 }
 
-func (s *Service) CancelFetch(collectionID string) error {
-	value, exists := s.activeCrawlers.Load(collectionID)
-	if !exists {
-		return entity.ErrCrawlerNotRunning
+func setRunOutcome(run *entity.CrawlRun, status entity.CrawlRunStatus, resultCount int, errs []error) {
+	now := time.Now()
+	run.Status = status
+	run.ResultCount = resultCount
+	run.FinishedAt = now
+	run.UpdatedAt = now
+	if len(errs) > 0 {
+		run.Error = errors.Join(errs...).Error()
 	}
-	if cancelFunc, ok := value.(context.CancelFunc); ok {
-		cancelFunc()
-		s.activeCrawlers.Store(collectionID, entity.SyncStatusFailed)
+}
+
+// announceRun publishes the crawler event of a finished run and logs its
+// outcome.
+func (s *Service) announceRun(log entity.Logger, run *entity.CrawlRun, status entity.CrawlRunStatus, resultCount int, errs []error) {
+	if status == entity.CrawlRunCompleted {
 		s.event.Publish(entity.NewEvent(
-			entity.EventCrawlerFailed,
-			"",
+			entity.EventCrawlerCompleted,
+			run.UserID,
 			map[string]any{
-				"collection_id": collectionID,
-				"reason":        "canceled by user",
+				"run_id":        run.ID,
+				"collection_id": run.CollectionID,
+				"result_count":  resultCount,
+				"has_errors":    len(errs) > 0,
 			},
 		))
-		return nil
+		log.Info(s.ctx, "crawler completed", map[string]any{
+			"run_id":        run.ID,
+			"collection_id": run.CollectionID,
+			"result_count":  resultCount,
+			"error_count":   len(errs),
+			"duration":      run.Duration().String(),
+		})
+		return
 	}
-	return entity.ErrCrawlerCannotBeCancelled
+	s.event.Publish(entity.NewEvent(
+		entity.EventCrawlerFailed,
+		run.UserID,
+		map[string]any{
+			"run_id":        run.ID,
+			"collection_id": run.CollectionID,
+			"errors":        errs,
+			"reason":        run.Error,
+		},
+	))
+	log.Error(s.ctx, "crawler failed", nil, map[string]any{
+		"run_id":        run.ID,
+		"collection_id": run.CollectionID,
+		"status":        status,
+		"errors":        run.Error,
+	})
 }
@@ -0,0 +1,38 @@
+package crawler
+
+import (
+	"akira/internal/entity"
+	"akira/internal/usecase/crawler/provider"
+	"akira/internal/usecase/event"
+	"akira/internal/usecase/logger"
+	"context"
+	"testing"
+)
+
+func TestRegisterProviderKeepsRegisteredSite(t *testing.T) {
+	ctx := context.Background()
+	lg := logger.NewSlogLogger()
+	s := NewService(ctx, NewMemoRepository(), NewPageCacheMemoRepository(), event.Make(ctx, lg), lg, entity.CrawlPolicy{})
+	t.Cleanup(s.cancelFunc)
+
+	// a definition dropped in the providers dir under a built-in name
+	err := s.RegisterProvider(func() entity.SiteProvider {
+		return provider.NewDeclarativeProvider(provider.SiteDefinition{Name: "amazon"})
+	})
+	if err != entity.ErrCrawlerProviderExists {
+		t.Errorf("RegisterProvider() = %v, want %v", err, entity.ErrCrawlerProviderExists)
+	}
+	if _, ok := s.providers["amazon"]().(*provider.AmazonProvider); !ok {
+		t.Errorf("amazon provider = %T, want *provider.AmazonProvider", s.providers["amazon"]())
+	}
+
+	err = s.RegisterProvider(func() entity.SiteProvider {
+		return provider.NewDeclarativeProvider(provider.SiteDefinition{Name: "store"})
+	})
+	if err != nil {
+		t.Errorf("RegisterProvider() = %v, want nil", err)
+	}
+	if _, ok := s.providers["store"]; !ok {
+		t.Errorf("providers = %v, want store registered", s.Providers())
+	}
+}
@@ -0,0 +1,322 @@
+package crawler
+
+import (
+	"akira/internal/entity"
+	"database/sql"
+	"encoding/json"
+	"time"
+)
+
+var _ entity.CrawlRunRepository = (*CrawlRunSqliteRepository)(nil)
+
+const crawlRunColumns = `
+	id, collection_id, user_id, search_terms, sites, options, status,
+	providers, result_count, error, attempts, queued_at, started_at,
+	finished_at, created_at, updated_at
+`
+
+type CrawlRunSqliteRepository struct {
+	db *sql.DB
+}
+
+func NewCrawlRunSqliteRepository(db *sql.DB) entity.CrawlRunRepository {
+	return &CrawlRunSqliteRepository{db: db}
+}
+
+func (r *CrawlRunSqliteRepository) scanCrawlRunRow(row entity.Rowscan) (*entity.CrawlRun, error) {
+	var run entity.CrawlRun
+	var nullableSearchTerms, nullableSites, nullableOptions, nullableProviders, nullableError sql.NullString
+	var nullableStartedAt, nullableFinishedAt sql.NullTime
+	err := row.Scan(
+		&run.ID,
+		&run.CollectionID,
+		&run.UserID,
+		&nullableSearchTerms,
+		&nullableSites,
+		&nullableOptions,
+		&run.Status,
+		&nullableProviders,
+		&run.ResultCount,
+		&nullableError,
+		&run.Attempts,
+		&run.QueuedAt,
+		&nullableStartedAt,
+		&nullableFinishedAt,
+		&run.CreatedAt,
+		&run.UpdatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, entity.ErrNotFound
+		}
+		return nil, err
+	}
+	if nullableSearchTerms.Valid {
+		if err := json.Unmarshal([]byte(nullableSearchTerms.String), &run.SearchTerms); err != nil {
+			return nil, err
+		}
+	}
+	if nullableSites.Valid {
+		if err := json.Unmarshal([]byte(nullableSites.String), &run.Sites); err != nil {
+			return nil, err
+		}
+	}
+	if nullableOptions.Valid {
+		if err := json.Unmarshal([]byte(nullableOptions.String), &run.Options); err != nil {
+			return nil, err
+		}
+	}
+	if nullableProviders.Valid {
+		if err := json.Unmarshal([]byte(nullableProviders.String), &run.Providers); err != nil {
+			return nil, err
+		}
+	}
+	if run.Providers == nil {
+		run.Providers = make(map[string]entity.CrawlRunProviderStats)
+	}
+	run.Error = nullableError.String
+	run.StartedAt = nullableStartedAt.Time
+	run.FinishedAt = nullableFinishedAt.Time
+	return &run, nil
+}
+
+func (r *CrawlRunSqliteRepository) CreateCrawlRun(run *entity.CrawlRun) error {
+	searchTerms, err := json.Marshal(run.SearchTerms)
+	if err != nil {
+		return err
+	}
+	sites, err := json.Marshal(run.Sites)
+	if err != nil {
+		return err
+	}
+	options, err := json.Marshal(run.Options)
+	if err != nil {
+		return err
+	}
+	providers, err := json.Marshal(run.Providers)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.Exec(`
+		INSERT INTO crawl_runs (`+crawlRunColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`,
+		run.ID,
+		run.CollectionID,
+		run.UserID,
+		searchTerms, // marshal to JSON
+		sites,       // marshal to JSON
+		options,     // marshal to JSON
+		run.Status,
+		providers, // marshal to JSON
+		run.ResultCount,
+		run.Error,
+		run.Attempts,
+		run.QueuedAt,
+		nullTime(run.StartedAt),
+		nullTime(run.FinishedAt),
+		run.CreatedAt,
+		run.UpdatedAt,
+	)
+	return err
+}
+
+func (r *CrawlRunSqliteRepository) UpdateCrawlRun(run *entity.CrawlRun) error {
+	providers, err := json.Marshal(run.Providers)
+	if err != nil {
+		return err
+	}
+	result, err := r.db.Exec(`
+		UPDATE crawl_runs SET
+			status = ?, providers = ?, result_count = ?, error = ?, attempts = ?,
+			started_at = ?, finished_at = ?, updated_at = ?
+		WHERE id = ?
+	`,
+		run.Status,
+		providers, // marshal to JSON
+		run.ResultCount,
+		run.Error,
+		run.Attempts,
+		nullTime(run.StartedAt),
+		nullTime(run.FinishedAt),
+		run.UpdatedAt,
+		run.ID,
+	)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return entity.ErrNotFound
+	}
+	return nil
+}
+
+func (r *CrawlRunSqliteRepository) ClaimNextCrawlRun(now time.Time) (*entity.CrawlRun, error) {
+	row := r.db.QueryRow(`
+		UPDATE crawl_runs SET
+			status = ?, attempts = attempts + 1, started_at = ?, updated_at = ?
+		WHERE id = (
+			SELECT id FROM crawl_runs WHERE status = ? ORDER BY queued_at LIMIT 1
+		) AND status = ?
+		RETURNING `+crawlRunColumns,
+		entity.CrawlRunRunning,
+		now,
+		now,
+		entity.CrawlRunQueued,
+		entity.CrawlRunQueued,
+	)
+	return r.scanCrawlRunRow(row)
+}
+
+func (r *CrawlRunSqliteRepository) CancelQueuedCrawlRun(run *entity.CrawlRun) error {
+	result, err := r.db.Exec(`
+		UPDATE crawl_runs SET
+			status = ?, error = ?, finished_at = ?, updated_at = ?
+		WHERE id = ? AND status = ?
+	`,
+		run.Status,
+		run.Error,
+		nullTime(run.FinishedAt),
+		run.UpdatedAt,
+		run.ID,
+		entity.CrawlRunQueued,
+	)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return entity.ErrNotFound
+	}
+	return nil
+}
+
+func (r *CrawlRunSqliteRepository) FindLatestCrawlRun(collectionID string) (*entity.CrawlRun, error) {
+	row := r.db.QueryRow(
+		"SELECT "+crawlRunColumns+" FROM crawl_runs WHERE collection_id = ? ORDER BY queued_at DESC LIMIT 1",
+		collectionID,
+	)
+	return r.scanCrawlRunRow(row)
+}
+
+func (r *CrawlRunSqliteRepository) FindCrawlRunsByStatus(status entity.CrawlRunStatus) ([]entity.CrawlRun, error) {
+	rows, err := r.db.Query(
+		"SELECT "+crawlRunColumns+" FROM crawl_runs WHERE status = ? ORDER BY queued_at",
+		status,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return r.scanCrawlRunRows(rows)
+}
+
+func (r *CrawlRunSqliteRepository) ListCrawlRuns(collectionID string, limit int) ([]entity.CrawlRun, error) {
+	rows, err := r.db.Query(
+		"SELECT "+crawlRunColumns+" FROM crawl_runs WHERE collection_id = ? ORDER BY queued_at DESC LIMIT ?",
+		collectionID,
+		limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return r.scanCrawlRunRows(rows)
+}
+
+func (r *CrawlRunSqliteRepository) AddCrawlLogEntry(entry *entity.CrawlLogEntry) error {
+	var fields sql.NullString
+	if len(entry.Fields) > 0 {
+		data, err := json.Marshal(entry.Fields)
+		if err != nil {
+			return err
+		}
+		fields = sql.NullString{String: string(data), Valid: true}
+	}
+	_, err := r.db.Exec(`
+		INSERT INTO crawl_logs (id, run_id, collection_id, site, level, message, error, fields, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`,
+		entry.ID,
+		entry.RunID,
+		entry.CollectionID,
+		nullString(entry.Site),
+		entry.Level,
+		entry.Message,
+		nullString(entry.Error),
+		fields, // marshal to JSON
+		entry.CreatedAt,
+	)
+	return err
+}
+
+func (r *CrawlRunSqliteRepository) ListCrawlLogEntries(collectionID, runID string, limit int) ([]entity.CrawlLogEntry, error) {
+	rows, err := r.db.Query(`
+		SELECT id, run_id, collection_id, site, level, message, error, fields, created_at
+		FROM crawl_logs WHERE run_id = ? AND collection_id = ? ORDER BY created_at, id LIMIT ?
+	`,
+		runID,
+		collectionID,
+		limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	entries := make([]entity.CrawlLogEntry, 0)
+	for rows.Next() {
+		var entry entity.CrawlLogEntry
+		var nullableSite, nullableError, nullableFields sql.NullString
+		err := rows.Scan(
+			&entry.ID,
+			&entry.RunID,
+			&entry.CollectionID,
+			&nullableSite,
+			&entry.Level,
+			&entry.Message,
+			&nullableError,
+			&nullableFields,
+			&entry.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if nullableFields.Valid {
+			if err := json.Unmarshal([]byte(nullableFields.String), &entry.Fields); err != nil {
+				return nil, err
+			}
+		}
+		entry.Site = nullableSite.String
+		entry.Error = nullableError.String
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func (r *CrawlRunSqliteRepository) scanCrawlRunRows(rows *sql.Rows) ([]entity.CrawlRun, error) {
+	defer rows.Close()
+	runs := make([]entity.CrawlRun, 0)
+	for rows.Next() {
+		run, err := r.scanCrawlRunRow(rows)
+		if err != nil {
+			return nil, err
+		}
+		runs = append(runs, *run)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return runs, nil
+}
+
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: !t.IsZero()}
+}
@@ -16,6 +16,7 @@ type Service struct {
 	logger          entity.Logger
 	ctx             context.Context
 	deadLetterQueue []entity.Event
+	deadLetterMu    sync.Mutex
 	maxDeadLetters  int
 	eventsub        *entity.Subscriber
 	cancelFunc      context.CancelFunc
@@ -113,9 +114,11 @@ func (s *Service) shouldReceive(sub *entity.Subscriber, event entity.Event) bool
 	return slices.Contains(sub.Filter, event.Type)
 }
 
+// recordDeadLetter is called from Publish while the subscribers are read
+// locked, so the queue has its own mutex.
 func (s *Service) recordDeadLetter(event entity.Event, reason string, subID string) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+	s.deadLetterMu.Lock()
+	defer s.deadLetterMu.Unlock()
 	s.logger.Warn(s.ctx, "event added to dead letter queue", map[string]any{
 		"event_type":   event.Type,
 		"reason":       reason,
@@ -0,0 +1,148 @@
+package notification
+
+import (
+	"akira/internal/entity"
+	"context"
+	"strconv"
+)
+
+var _ entity.NotificationConsumer = (*Consumer)(nil)
+
+type Consumer struct {
+	service    entity.NotificationService
+	collection entity.CollectionService
+	sub        *entity.Subscriber
+	logger     entity.Logger
+	ctx        context.Context
+	cancelFunc context.CancelFunc
+}
+
+func NewConsumer(
+	ctx context.Context,
+	service entity.NotificationService,
+	collection entity.CollectionService,
+	event entity.EventService,
+	logger entity.Logger,
+) *Consumer {
+	consumerCtx, cancel := context.WithCancel(ctx)
+	consumer := &Consumer{
+		service:    service,
+		collection: collection,
+		logger:     logger,
+		ctx:        consumerCtx,
+		cancelFunc: cancel,
+	}
+	consumer.sub = event.Subscribe(
+		"notification-consumer",
+		cancel,
+		entity.EventCollectionNewVolume,
+		entity.EventCollectionPriceDropped,
+	)
+	go consumer.ConsumeEvents()
+	return consumer
+}
+
+func (c *Consumer) ConsumeEvents() {
+	for {
+		select {
+		case <-c.ctx.Done():
+			c.logger.Info(c.ctx, "notification consumer shutting down", nil)
+			return
+		case event, ok := <-c.sub.Ch:
+			if !ok {
+				c.logger.Info(c.ctx, "notification consumer channel closed", nil)
+				return
+			}
+			switch event.Type {
+			case entity.EventCollectionNewVolume:
+				c.handleNewVolume(event)
+			case entity.EventCollectionPriceDropped:
+				c.handlePriceDropped(event)
+			}
+		}
+	}
+}
+
+func (c *Consumer) Shutdown() error {
+	c.cancelFunc()
+	c.sub.Cancel()
+	return nil
+}
+
+func (c *Consumer) handleNewVolume(event entity.Event) {
+	change, ok := event.Data.(entity.VolumeChange)
+	if !ok {
+		c.logger.Warn(c.ctx, "invalid event data type", map[string]any{
+			"expected": "entity.VolumeChange",
+			"received": event.Data,
+		})
+		return
+	}
+	data := map[string]string{
+		"volume": strconv.Itoa(change.Volume),
+		"title":  change.Title,
+		"source": change.Source,
+		"url":    change.URL,
+	}
+	if !change.ReleasedAt.IsZero() {
+		data["released_at"] = change.ReleasedAt.Format("02/01/2006")
+	}
+	if !change.PreviousReleasedAt.IsZero() {
+		data["previous_released_at"] = change.PreviousReleasedAt.Format("02/01/2006")
+	}
+	c.notify(event.UserID, change.CollectionID, entity.NotificationType(change.Kind), data)
+}
+
+func (c *Consumer) handlePriceDropped(event entity.Event) {
+	payload, ok := event.Data.(map[string]any)
+	if !ok {
+		c.logger.Warn(c.ctx, "invalid event data type", map[string]any{
+			"expected": "map[string]any",
+			"received": event.Data,
+		})
+		return
+	}
+	collectionID, _ := payload["collection_id"].(string)
+	price, _ := payload["price"].(float64)
+	data := map[string]string{
+		"price": strconv.FormatFloat(price, 'f', 2, 64),
+	}
+	for _, key := range []string{"title", "source", "url"} {
+		data[key], _ = payload[key].(string)
+	}
+	if volume, ok := payload["volume"].(*int); ok && volume != nil {
+		data["volume"] = strconv.Itoa(*volume)
+	}
+	c.notify(event.UserID, collectionID, entity.NotificationPriceDropped, data)
+}
+
+// notify adds the collection name and slug to the data, so the entry can be
+// rendered and linked without looking the collection up again.
+func (c *Consumer) notify(userID, collectionID string, notificationType entity.NotificationType, data map[string]string) {
+	if userID == "" || collectionID == "" {
+		c.logger.Warn(c.ctx, "notification without user or collection", map[string]any{
+			"type": notificationType,
+		})
+		return
+	}
+	collection, err := c.collection.FindCollectionByID(collectionID)
+	if err != nil {
+		if err != entity.ErrCollectionNotFound {
+			c.logger.Error(c.ctx, "failed to find collection", err, map[string]any{
+				"collection_id": collectionID,
+			})
+		}
+		return
+	}
+	data["collection"] = collection.Name
+	data["slug"] = collection.Slug
+	notification := entity.NewNotification(userID, collectionID, notificationType, data)
+	if err := c.service.Notify(notification); err != nil {
+		return
+	}
+	c.logger.Info(c.ctx, "notification created", map[string]any{
+		"user_id":       userID,
+		"collection_id": collectionID,
+		"type":          notificationType,
+	})
+}
@@ -0,0 +1,89 @@
+package notification
+
+import (
+	"akira/internal/entity"
+	"sort"
+	"sync"
+	"time"
+)
+
+var _ entity.NotificationRepository = (*MemoRepository)(nil)
+
+type MemoRepository struct {
+	mu            sync.RWMutex
+	notifications map[string]*entity.Notification
+}
+
+func NewMemoRepository() *MemoRepository {
+	return &MemoRepository{
+		notifications: make(map[string]*entity.Notification),
+	}
+}
+
+func (r *MemoRepository) CreateNotification(notification *entity.Notification) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.notifications[notification.ID] = notification
+	return nil
+}
+
+func (r *MemoRepository) FindNotificationByID(id string) (*entity.Notification, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if notification, ok := r.notifications[id]; ok {
+		return notification, nil
+	}
+	return nil, entity.ErrNotFound
+}
+
+func (r *MemoRepository) ListNotifications(userID string, limit int) ([]entity.Notification, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	notifications := make([]entity.Notification, 0)
+	for _, notification := range r.notifications {
+		if notification.UserID == userID {
+			notifications = append(notifications, *notification)
+		}
+	}
+	sort.Slice(notifications, func(i, j int) bool {
+		return notifications[i].CreatedAt.After(notifications[j].CreatedAt)
+	})
+	if len(notifications) > limit {
+		notifications = notifications[:limit]
+	}
+	return notifications, nil
+}
+
+func (r *MemoRepository) CountUnread(userID string) (int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	count := 0
+	for _, notification := range r.notifications {
+		if notification.UserID == userID && !notification.IsRead() {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *MemoRepository) MarkRead(id string, readAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	notification, ok := r.notifications[id]
+	if !ok {
+		return entity.ErrNotFound
+	}
+	notification.ReadAt = readAt
+	return nil
+}
+
+func (r *MemoRepository) MarkAllRead(userID string, readAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, notification := range r.notifications {
+		if notification.UserID == userID && !notification.IsRead() {
+			notification.ReadAt = readAt
+		}
+	}
+	return nil
+}
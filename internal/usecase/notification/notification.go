@@ -0,0 +1,20 @@
+package notification
+
+import (
+	"akira/internal/entity"
+	"context"
+	"database/sql"
+)
+
+func Make(
+	ctx context.Context,
+	db *sql.DB,
+	event entity.EventService,
+	collection entity.CollectionService,
+	logger entity.Logger,
+) (entity.NotificationService, entity.NotificationConsumer) {
+	repo := NewNotificationSqliteRepository(db)
+	service := NewService(ctx, repo, logger)
+	consumer := NewConsumer(ctx, service, collection, event, logger)
+	return service, consumer
+}
@@ -0,0 +1,95 @@
+package notification
+
+import (
+	"akira/internal/entity"
+	"context"
+	"time"
+)
+
+var _ entity.NotificationService = (*Service)(nil)
+
+type Service struct {
+	ctx    context.Context
+	repo   entity.NotificationRepository
+	logger entity.Logger
+}
+
+func NewService(ctx context.Context, repo entity.NotificationRepository, logger entity.Logger) *Service {
+	return &Service{
+		ctx:    ctx,
+		repo:   repo,
+		logger: logger,
+	}
+}
+
+func (s *Service) Notify(notification *entity.Notification) error {
+	if err := s.repo.CreateNotification(notification); err != nil {
+		s.logger.Error(s.ctx, "failed to create notification", err, map[string]any{
+			"user_id": notification.UserID,
+			"type":    notification.Type,
+		})
+		return err
+	}
+	return nil
+}
+
+func (s *Service) ListNotifications(userID string) ([]entity.Notification, error) {
+	notifications, err := s.repo.ListNotifications(userID, entity.NotificationsPerPage)
+	if err != nil {
+		s.logger.Error(s.ctx, "failed to list notifications", err, map[string]any{
+			"user_id": userID,
+		})
+		return nil, err
+	}
+	return notifications, nil
+}
+
+func (s *Service) CountUnread(userID string) (int, error) {
+	count, err := s.repo.CountUnread(userID)
+	if err != nil {
+		s.logger.Error(s.ctx, "failed to count unread notifications", err, map[string]any{
+			"user_id": userID,
+		})
+		return 0, err
+	}
+	return count, nil
+}
+
+func (s *Service) MarkRead(userID, notificationID string) (*entity.Notification, error) {
+	notification, err := s.repo.FindNotificationByID(notificationID)
+	if err != nil {
+		if err == entity.ErrNotFound {
+			return nil, entity.ErrNotificationNotFound
+		}
+		s.logger.Error(s.ctx, "failed to find notification", err, map[string]any{
+			"user_id":         userID,
+			"notification_id": notificationID,
+		})
+		return nil, err
+	}
+	if notification.UserID != userID {
+		return nil, entity.ErrNotificationForbidden
+	}
+	if notification.IsRead() {
+		return notification, nil
+	}
+	notification.ReadAt = time.Now()
+	if err := s.repo.MarkRead(notification.ID, notification.ReadAt); err != nil {
+		s.logger.Error(s.ctx, "failed to mark notification as read", err, map[string]any{
+			"user_id":         userID,
+			"notification_id": notificationID,
+		})
+		return nil, err
+	}
+	return notification, nil
+}
+
+func (s *Service) MarkAllRead(userID string) error {
+	if err := s.repo.MarkAllRead(userID, time.Now()); err != nil {
+		s.logger.Error(s.ctx, "failed to mark all notifications as read", err, map[string]any{
+			"user_id": userID,
+		})
+		return err
+	}
+	return nil
+}
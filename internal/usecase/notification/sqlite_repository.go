@@ -0,0 +1,135 @@
+package notification
+
+import (
+	"akira/internal/entity"
+	"database/sql"
+	"encoding/json"
+	"time"
+)
+
+var _ entity.NotificationRepository = (*NotificationSqliteRepository)(nil)
+
+const notificationColumns = `
+	id, user_id, collection_id, type, data, read_at, created_at
+`
+
+type NotificationSqliteRepository struct {
+	db *sql.DB
+}
+
+func NewNotificationSqliteRepository(db *sql.DB) entity.NotificationRepository {
+	return &NotificationSqliteRepository{db: db}
+}
+
+func (r *NotificationSqliteRepository) scanNotificationRow(row entity.Rowscan) (*entity.Notification, error) {
+	var notification entity.Notification
+	var nullableCollectionID, nullableData sql.NullString
+	var nullableReadAt sql.NullTime
+	err := row.Scan(
+		&notification.ID,
+		&notification.UserID,
+		&nullableCollectionID,
+		&notification.Type,
+		&nullableData,
+		&nullableReadAt,
+		&notification.CreatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, entity.ErrNotFound
+		}
+		return nil, err
+	}
+	if nullableData.Valid {
+		err = json.Unmarshal([]byte(nullableData.String), &notification.Data)
+		if err != nil {
+			return nil, err
+		}
+	}
+	notification.CollectionID = nullableCollectionID.String
+	notification.ReadAt = nullableReadAt.Time
+	return &notification, nil
+}
+
+func (r *NotificationSqliteRepository) CreateNotification(notification *entity.Notification) error {
+	data, err := json.Marshal(notification.Data)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.Exec(`
+		INSERT INTO notifications (`+notificationColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`,
+		notification.ID,
+		notification.UserID,
+		sql.NullString{String: notification.CollectionID, Valid: notification.CollectionID != ""},
+		notification.Type,
+		data, // marshal to JSON
+		sql.NullTime{Time: notification.ReadAt, Valid: !notification.ReadAt.IsZero()},
+		notification.CreatedAt,
+	)
+	return err
+}
+
+func (r *NotificationSqliteRepository) FindNotificationByID(id string) (*entity.Notification, error) {
+	row := r.db.QueryRow("SELECT "+notificationColumns+" FROM notifications WHERE id = ?", id)
+	return r.scanNotificationRow(row)
+}
+
+func (r *NotificationSqliteRepository) ListNotifications(userID string, limit int) ([]entity.Notification, error) {
+	rows, err := r.db.Query(`
+		SELECT `+notificationColumns+` FROM notifications
+		WHERE user_id = ?
+		ORDER BY created_at DESC
+		LIMIT ?
+	`, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	notifications := make([]entity.Notification, 0)
+	for rows.Next() {
+		notification, err := r.scanNotificationRow(rows)
+		if err != nil {
+			return nil, err
+		}
+		notifications = append(notifications, *notification)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return notifications, nil
+}
+
+func (r *NotificationSqliteRepository) CountUnread(userID string) (int, error) {
+	var count int
+	err := r.db.QueryRow(
+		"SELECT COUNT(*) FROM notifications WHERE user_id = ? AND read_at IS NULL",
+		userID,
+	).Scan(&count)
+	return count, err
+}
+
+func (r *NotificationSqliteRepository) MarkRead(id string, readAt time.Time) error {
+	result, err := r.db.Exec("UPDATE notifications SET read_at = ? WHERE id = ?", readAt, id)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return entity.ErrNotFound
+	}
+	return nil
+}
+
+func (r *NotificationSqliteRepository) MarkAllRead(userID string, readAt time.Time) error {
+	_, err := r.db.Exec(
+		"UPDATE notifications SET read_at = ? WHERE user_id = ? AND read_at IS NULL",
+		readAt,
+		userID,
+	)
+	return err
+}
@@ -0,0 +1,70 @@
+package price
+
+import (
+	"akira/internal/entity"
+	"sort"
+	"sync"
+)
+
+var _ entity.PriceRepository = (*MemoRepository)(nil)
+
+type MemoRepository struct {
+	mu           sync.RWMutex
+	observations map[string][]entity.PriceObservation
+}
+
+func NewMemoRepository() *MemoRepository {
+	return &MemoRepository{
+		observations: make(map[string][]entity.PriceObservation),
+	}
+}
+
+func (r *MemoRepository) CreatePriceObservation(observation *entity.PriceObservation) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.observations[observation.BookID] = append(r.observations[observation.BookID], *observation)
+	return nil
+}
+
+func (r *MemoRepository) FindLatestPriceObservation(bookID, source, url string) (*entity.PriceObservation, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var latest *entity.PriceObservation
+	for i, o := range r.observations[bookID] {
+		if o.Source != source || o.URL != url {
+			continue
+		}
+		if latest == nil || o.ObservedAt.After(latest.ObservedAt) {
+			latest = &r.observations[bookID][i]
+		}
+	}
+	if latest == nil {
+		return nil, entity.ErrNotFound
+	}
+	observation := *latest
+	return &observation, nil
+}
+
+func (r *MemoRepository) FindLowestPrice(bookID string) (float64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	observations := r.observations[bookID]
+	if len(observations) == 0 {
+		return 0, entity.ErrNotFound
+	}
+	lowest := observations[0].Price
+	for _, o := range observations[1:] {
+		lowest = min(lowest, o.Price)
+	}
+	return lowest, nil
+}
+
+func (r *MemoRepository) FindBookPriceObservations(bookID string) ([]entity.PriceObservation, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	observations := append([]entity.PriceObservation(nil), r.observations[bookID]...)
+	sort.Slice(observations, func(i, j int) bool {
+		return observations[i].ObservedAt.Before(observations[j].ObservedAt)
+	})
+	return observations, nil
+}
@@ -0,0 +1,12 @@
+package price
+
+import (
+	"akira/internal/entity"
+	"context"
+	"database/sql"
+)
+
+func Make(ctx context.Context, db *sql.DB, event entity.EventService, logger entity.Logger) entity.PriceService {
+	repo := NewPriceSqliteRepository(db)
+	return NewService(ctx, repo, event, logger)
+}
@@ -0,0 +1,110 @@
+package price
+
+import (
+	"akira/internal/entity"
+	"context"
+	"time"
+)
+
+var _ entity.PriceService = (*Service)(nil)
+
+// unchangedPriceWindow keeps a listing that shows up for several search terms
+// of the same crawl from being recorded more than once.
+const unchangedPriceWindow = time.Hour
+
+type Service struct {
+	ctx    context.Context
+	repo   entity.PriceRepository
+	event  entity.EventService
+	logger entity.Logger
+}
+
+func NewService(ctx context.Context, repo entity.PriceRepository, event entity.EventService, logger entity.Logger) *Service {
+	return &Service{
+		ctx:    ctx,
+		repo:   repo,
+		event:  event,
+		logger: logger,
+	}
+}
+
+// RecordPrice stores the price of the crawled listing for the book and, when
+// the collection tracks prices and the volume is still missing, publishes a
+// price drop if it is below the previous low or reaches the target price.
+func (s *Service) RecordPrice(collection *entity.Collection, book *entity.Book, result entity.CrawledResult) error {
+	if result.Price <= 0 {
+		return nil
+	}
+	now := time.Now()
+	latest, err := s.repo.FindLatestPriceObservation(book.ID, result.Source, result.URL)
+	if err != nil && err != entity.ErrNotFound {
+		s.logger.Error(s.ctx, "failed to find latest price observation", err, map[string]any{
+			"book_id": book.ID,
+			"source":  result.Source,
+		})
+		return err
+	}
+	if latest != nil && latest.Price == result.Price && now.Sub(latest.ObservedAt) < unchangedPriceWindow {
+		return nil
+	}
+	previousLow, err := s.repo.FindLowestPrice(book.ID)
+	hasPrevious := err == nil
+	if err != nil && err != entity.ErrNotFound {
+		s.logger.Error(s.ctx, "failed to find lowest price", err, map[string]any{
+			"book_id": book.ID,
+		})
+		return err
+	}
+	observation := entity.NewPriceObservation(book.UserID, collection.ID, book.ID, result.Source, result.URL, result.Price, now)
+	if err := s.repo.CreatePriceObservation(observation); err != nil {
+		s.logger.Error(s.ctx, "failed to create price observation", err, map[string]any{
+			"book_id": book.ID,
+			"source":  result.Source,
+		})
+		return err
+	}
+	if !collection.CrawlerOptions.TrackPrice || !book.IsMissing() {
+		return nil
+	}
+	belowLow := hasPrevious && result.Price < previousLow
+	reachedTarget := book.TargetPrice > 0 && result.Price <= book.TargetPrice &&
+		(!hasPrevious || previousLow > book.TargetPrice)
+	if !belowLow && !reachedTarget {
+		return nil
+	}
+	s.logger.Info(s.ctx, "price dropped", map[string]any{
+		"collection_id": collection.ID,
+		"book_id":       book.ID,
+		"price":         result.Price,
+		"previous_low":  previousLow,
+		"target_price":  book.TargetPrice,
+		"source":        result.Source,
+	})
+	s.event.Publish(entity.NewEvent(
+		entity.EventCollectionPriceDropped,
+		book.UserID,
+		map[string]any{
+			"collection_id": collection.ID,
+			"book_id":       book.ID,
+			"volume":        book.Volume,
+			"title":         book.Name,
+			"price":         result.Price,
+			"previous_low":  previousLow,
+			"target_price":  book.TargetPrice,
+			"source":        result.Source,
+			"url":           result.URL,
+		},
+	))
+	return nil
+}
+
+func (s *Service) FindBookPriceHistory(bookID string) (*entity.PriceHistory, error) {
+	observations, err := s.repo.FindBookPriceObservations(bookID)
+	if err != nil {
+		s.logger.Error(s.ctx, "failed to find book price observations", err, map[string]any{
+			"book_id": bookID,
+		})
+		return nil, err
+	}
+	return entity.NewPriceHistory(bookID, observations), nil
+}
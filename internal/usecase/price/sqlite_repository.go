@@ -0,0 +1,106 @@
+package price
+
+import (
+	"akira/internal/entity"
+	"database/sql"
+)
+
+var _ entity.PriceRepository = (*PriceSqliteRepository)(nil)
+
+const priceObservationColumns = `
+	id, book_id, collection_id, user_id, source, url, price, observed_at, created_at
+`
+
+type PriceSqliteRepository struct {
+	db *sql.DB
+}
+
+func NewPriceSqliteRepository(db *sql.DB) entity.PriceRepository {
+	return &PriceSqliteRepository{db: db}
+}
+
+func (r *PriceSqliteRepository) scanPriceObservationRow(row entity.Rowscan) (*entity.PriceObservation, error) {
+	var observation entity.PriceObservation
+	err := row.Scan(
+		&observation.ID,
+		&observation.BookID,
+		&observation.CollectionID,
+		&observation.UserID,
+		&observation.Source,
+		&observation.URL,
+		&observation.Price,
+		&observation.ObservedAt,
+		&observation.CreatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, entity.ErrNotFound
+		}
+		return nil, err
+	}
+	return &observation, nil
+}
+
+func (r *PriceSqliteRepository) CreatePriceObservation(observation *entity.PriceObservation) error {
+	_, err := r.db.Exec(`
+		INSERT INTO price_observations (`+priceObservationColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`,
+		observation.ID,
+		observation.BookID,
+		observation.CollectionID,
+		observation.UserID,
+		observation.Source,
+		observation.URL,
+		observation.Price,
+		observation.ObservedAt,
+		observation.CreatedAt,
+	)
+	return err
+}
+
+func (r *PriceSqliteRepository) FindLatestPriceObservation(bookID, source, url string) (*entity.PriceObservation, error) {
+	row := r.db.QueryRow(`
+		SELECT `+priceObservationColumns+` FROM price_observations
+		WHERE book_id = ? AND source = ? AND url = ?
+		ORDER BY observed_at DESC
+		LIMIT 1
+	`, bookID, source, url)
+	return r.scanPriceObservationRow(row)
+}
+
+func (r *PriceSqliteRepository) FindLowestPrice(bookID string) (float64, error) {
+	var lowest sql.NullFloat64
+	err := r.db.QueryRow("SELECT MIN(price) FROM price_observations WHERE book_id = ?", bookID).Scan(&lowest)
+	if err != nil {
+		return 0, err
+	}
+	if !lowest.Valid {
+		return 0, entity.ErrNotFound
+	}
+	return lowest.Float64, nil
+}
+
+func (r *PriceSqliteRepository) FindBookPriceObservations(bookID string) ([]entity.PriceObservation, error) {
+	rows, err := r.db.Query(`
+		SELECT `+priceObservationColumns+` FROM price_observations
+		WHERE book_id = ?
+		ORDER BY observed_at
+	`, bookID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	observations := make([]entity.PriceObservation, 0)
+	for rows.Next() {
+		observation, err := r.scanPriceObservationRow(rows)
+		if err != nil {
+			return nil, err
+		}
+		observations = append(observations, *observation)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return observations, nil
+}
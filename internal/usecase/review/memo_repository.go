@@ -0,0 +1,46 @@
+package review
+
+import (
+	"akira/internal/entity"
+	"sync"
+)
+
+var _ entity.ReviewRepository = (*MemoRepository)(nil)
+
+type MemoRepository struct {
+	mu      sync.RWMutex
+	reviews map[string][]entity.ContentReview
+}
+
+func NewMemoRepository() *MemoRepository {
+	return &MemoRepository{
+		reviews: make(map[string][]entity.ContentReview),
+	}
+}
+
+func (r *MemoRepository) CreateReviews(reviews []*entity.ContentReview) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	created := 0
+	for _, review := range reviews {
+		exists := false
+		for _, stored := range r.reviews[review.VolumeID] {
+			if stored.Fingerprint() == review.Fingerprint() {
+				exists = true
+				break
+			}
+		}
+		if exists {
+			continue
+		}
+		r.reviews[review.VolumeID] = append(r.reviews[review.VolumeID], *review)
+		created++
+	}
+	return created, nil
+}
+
+func (r *MemoRepository) FindBookReviews(bookID string) ([]entity.ContentReview, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]entity.ContentReview(nil), r.reviews[bookID]...), nil
+}
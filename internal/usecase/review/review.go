@@ -0,0 +1,12 @@
+package review
+
+import (
+	"akira/internal/entity"
+	"context"
+	"database/sql"
+)
+
+func Make(ctx context.Context, db *sql.DB, logger entity.Logger) entity.ReviewService {
+	repo := NewReviewSqliteRepository(db)
+	return NewService(ctx, repo, logger)
+}
@@ -0,0 +1,58 @@
+package review
+
+import (
+	"akira/internal/entity"
+	"context"
+)
+
+var _ entity.ReviewService = (*Service)(nil)
+
+type Service struct {
+	ctx    context.Context
+	repo   entity.ReviewRepository
+	logger entity.Logger
+}
+
+func NewService(ctx context.Context, repo entity.ReviewRepository, logger entity.Logger) *Service {
+	return &Service{
+		ctx:    ctx,
+		repo:   repo,
+		logger: logger,
+	}
+}
+
+// SaveReviews stores the reviews crawled for the book and returns how many
+// of them were new. Reviews without any text are skipped.
+func (s *Service) SaveReviews(book *entity.Book, result entity.CrawledResult) (int, error) {
+	reviews := make([]*entity.ContentReview, 0, len(result.Reviews))
+	for _, crawled := range result.Reviews {
+		review := entity.NewContentReview(book.ID, result.Source, crawled)
+		if review.Title == "" && review.Content == "" {
+			continue
+		}
+		reviews = append(reviews, review)
+	}
+	if len(reviews) == 0 {
+		return 0, nil
+	}
+	created, err := s.repo.CreateReviews(reviews)
+	if err != nil {
+		s.logger.Error(s.ctx, "failed to save reviews", err, map[string]any{
+			"book_id": book.ID,
+			"source":  result.Source,
+		})
+		return 0, err
+	}
+	return created, nil
+}
+
+func (s *Service) FindBookReviews(bookID string) (*entity.BookReviews, error) {
+	reviews, err := s.repo.FindBookReviews(bookID)
+	if err != nil {
+		s.logger.Error(s.ctx, "failed to find book reviews", err, map[string]any{
+			"book_id": bookID,
+		})
+		return nil, err
+	}
+	return entity.NewBookReviews(reviews), nil
+}
@@ -0,0 +1,118 @@
+package review
+
+import (
+	"akira/internal/entity"
+	"database/sql"
+	"time"
+)
+
+var _ entity.ReviewRepository = (*ReviewSqliteRepository)(nil)
+
+type ReviewSqliteRepository struct {
+	db *sql.DB
+}
+
+func NewReviewSqliteRepository(db *sql.DB) entity.ReviewRepository {
+	return &ReviewSqliteRepository{db: db}
+}
+
+func (r *ReviewSqliteRepository) CreateReviews(reviews []*entity.ContentReview) (int, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	stmt, err := tx.Prepare(`
+		INSERT OR IGNORE INTO content_reviews (
+			id, book_id, source, fingerprint, author, title, content,
+			rating, reviewed_at, created_at, updated_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+	created := 0
+	for _, review := range reviews {
+		result, err := stmt.Exec(
+			review.ID,
+			review.VolumeID,
+			review.Source,
+			review.Fingerprint(),
+			review.Author,
+			review.Title,
+			review.Content,
+			review.Rating,
+			nullTime(review.Date),
+			review.CreatedAt,
+			review.UpdatedAt,
+		)
+		if err != nil {
+			return 0, err
+		}
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+		created += int(affected)
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return created, nil
+}
+
+func (r *ReviewSqliteRepository) FindBookReviews(bookID string) ([]entity.ContentReview, error) {
+	stmt, err := r.db.Prepare(`
+		SELECT id, book_id, source, author, title, content, rating,
+			reviewed_at, created_at, updated_at
+		FROM content_reviews
+		WHERE book_id = ?
+		ORDER BY reviewed_at DESC, created_at DESC
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+	rows, err := stmt.Query(bookID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	reviews := make([]entity.ContentReview, 0)
+	for rows.Next() {
+		var review entity.ContentReview
+		var nullableAuthor, nullableTitle, nullableContent sql.NullString
+		var nullableRating sql.NullFloat64
+		var nullableReviewedAt sql.NullTime
+		err := rows.Scan(
+			&review.ID,
+			&review.VolumeID,
+			&review.Source,
+			&nullableAuthor,
+			&nullableTitle,
+			&nullableContent,
+			&nullableRating,
+			&nullableReviewedAt,
+			&review.CreatedAt,
+			&review.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		review.Author = nullableAuthor.String
+		review.Title = nullableTitle.String
+		review.Content = nullableContent.String
+		review.Rating = nullableRating.Float64
+		review.Date = nullableReviewedAt.Time
+		reviews = append(reviews, review)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return reviews, nil
+}
+
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: !t.IsZero()}
+}
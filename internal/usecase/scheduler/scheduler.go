@@ -0,0 +1,15 @@
+package scheduler
+
+import (
+	"akira/internal/entity"
+	"context"
+)
+
+func Make(
+	ctx context.Context,
+	collection entity.CollectionService,
+	logger entity.Logger,
+	opts entity.SchedulerOptions,
+) entity.SyncScheduler {
+	return NewService(ctx, collection, logger, opts)
+}
@@ -0,0 +1,124 @@
+package scheduler
+
+import (
+	"akira/internal/entity"
+	"context"
+	"sync"
+	"time"
+)
+
+var _ entity.SyncScheduler = (*Service)(nil)
+
+// dueCandidatesFactor widens the query for due collections, since the
+// per-collection jitter may still hold some of them back.
+const dueCandidatesFactor = 4
+
+type Service struct {
+	collection entity.CollectionService
+	logger     entity.Logger
+	opts       entity.SchedulerOptions
+	ctx        context.Context
+	cancelFunc context.CancelFunc
+	wg         sync.WaitGroup
+}
+
+func NewService(
+	ctx context.Context,
+	collection entity.CollectionService,
+	logger entity.Logger,
+	opts entity.SchedulerOptions,
+) *Service {
+	schedulerCtx, cancel := context.WithCancel(ctx)
+	return &Service{
+		collection: collection,
+		logger:     logger,
+		opts:       opts,
+		ctx:        schedulerCtx,
+		cancelFunc: cancel,
+	}
+}
+
+func (s *Service) Start() error {
+	if s.opts.Interval <= 0 || s.opts.CheckInterval <= 0 || s.opts.MaxConcurrent <= 0 {
+		s.logger.Info(s.ctx, "sync scheduler disabled", map[string]any{
+			"interval":       s.opts.Interval.String(),
+			"check_interval": s.opts.CheckInterval.String(),
+			"max_concurrent": s.opts.MaxConcurrent,
+		})
+		return nil
+	}
+	s.logger.Info(s.ctx, "sync scheduler started", map[string]any{
+		"interval":       s.opts.Interval.String(),
+		"jitter":         s.opts.Jitter.String(),
+		"max_concurrent": s.opts.MaxConcurrent,
+		"check_interval": s.opts.CheckInterval.String(),
+	})
+	s.wg.Add(1)
+	go s.run()
+	return nil
+}
+
+func (s *Service) Shutdown() error {
+	s.cancelFunc()
+	s.wg.Wait()
+	return nil
+}
+
+func (s *Service) run() {
+	defer s.wg.Done()
+	ticker := time.NewTicker(s.opts.CheckInterval)
+	defer ticker.Stop()
+	for {
+		s.scheduleDue(time.Now())
+		select {
+		case <-s.ctx.Done():
+			s.logger.Info(s.ctx, "sync scheduler shutting down", nil)
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// scheduleDue queues the collections whose last sync, or last failed sync,
+// is older than the interval plus their jitter, without going over the
+// concurrency cap.
+func (s *Service) scheduleDue(now time.Time) {
+	syncing, err := s.collection.CountSyncingCollections()
+	if err != nil {
+		return
+	}
+	slots := s.opts.MaxConcurrent - syncing
+	if slots <= 0 {
+		s.logger.Debug(s.ctx, "sync scheduler at capacity", map[string]any{
+			"syncing": syncing,
+		})
+		return
+	}
+	candidates, err := s.collection.FindCollectionsDueForSync(now.Add(-s.opts.Interval), slots*dueCandidatesFactor)
+	if err != nil {
+		return
+	}
+	for _, candidate := range candidates {
+		if slots == 0 || s.ctx.Err() != nil {
+			return
+		}
+		dueAt := candidate.NextSyncFrom().Add(s.opts.Interval + entity.SyncJitter(candidate.ID, s.opts.Jitter))
+		if dueAt.After(now) {
+			continue
+		}
+		if _, err := s.collection.ScheduleSync(candidate.ID); err != nil {
+			if err != entity.ErrCollectionAlreadySyncing && err != entity.ErrCollectionNotFound {
+				s.logger.Error(s.ctx, "failed to schedule collection sync", err, map[string]any{
+					"collection_id": candidate.ID,
+				})
+			}
+			continue
+		}
+		s.logger.Info(s.ctx, "collection sync scheduled", map[string]any{
+			"collection_id": candidate.ID,
+			"last_sync":     candidate.LastSync,
+			"last_attempt":  candidate.LastSyncAttempt,
+		})
+		slots--
+	}
+}
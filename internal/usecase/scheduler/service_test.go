@@ -0,0 +1,94 @@
+package scheduler
+
+import (
+	"akira/internal/entity"
+	"akira/internal/usecase/collection"
+	"akira/internal/usecase/event"
+	"akira/internal/usecase/logger"
+	"context"
+	"testing"
+	"time"
+)
+
+func newTestScheduler(t *testing.T) (*Service, entity.CollectionService, *collection.MemoRepository) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	lg := logger.NewSlogLogger()
+	events := event.Make(ctx, lg)
+	t.Cleanup(func() { events.Shutdown(ctx) })
+	repo := collection.NewMemoRepository()
+	collections := collection.NewService(ctx, repo, events, lg)
+	return NewService(ctx, collections, lg, entity.SchedulerOptions{
+		Interval:      24 * time.Hour,
+		MaxConcurrent: 2,
+		CheckInterval: time.Minute,
+	}), collections, repo
+}
+
+func newAutoSyncCollection(t *testing.T, repo *collection.MemoRepository, lastSync time.Time, status entity.SyncStatus) *entity.Collection {
+	t.Helper()
+	c := &entity.Collection{
+		ID:             entity.NewID(),
+		Name:           "Berserk",
+		Slug:           "berserk",
+		UserID:         entity.NewID(),
+		SyncStatus:     status,
+		SyncSources:    entity.SyncSources{"amazon"},
+		CrawlerOptions: entity.SyncOptions{AutoSync: true},
+		LastSync:       lastSync,
+		CreatedAt:      lastSync,
+		UpdatedAt:      lastSync,
+	}
+	if err := repo.CreateCollection(c); err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func syncStatus(t *testing.T, collections entity.CollectionService, id string) entity.SyncStatus {
+	t.Helper()
+	c, err := collections.FindCollectionByID(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c.SyncStatus
+}
+
+func TestScheduleDueQueuesStaleCollections(t *testing.T) {
+	s, collections, repo := newTestScheduler(t)
+	now := time.Now()
+	stale := newAutoSyncCollection(t, repo, now.Add(-25*time.Hour), entity.SyncStatusSynced)
+	recent := newAutoSyncCollection(t, repo, now.Add(-time.Hour), entity.SyncStatusSynced)
+
+	s.scheduleDue(now)
+
+	if got := syncStatus(t, collections, stale.ID); got != entity.SyncStatusPending {
+		t.Errorf("stale collection status = %q, want %q", got, entity.SyncStatusPending)
+	}
+	if got := syncStatus(t, collections, recent.ID); got != entity.SyncStatusSynced {
+		t.Errorf("recent collection status = %q, want %q", got, entity.SyncStatusSynced)
+	}
+}
+
+// TestScheduleDueWaitsAfterFailedSync checks a collection whose sync failed,
+// as when the store blocks the crawler, is not queued again on the next tick
+// but one interval after the failure.
+func TestScheduleDueWaitsAfterFailedSync(t *testing.T) {
+	s, collections, repo := newTestScheduler(t)
+	now := time.Now()
+	c := newAutoSyncCollection(t, repo, now.Add(-25*time.Hour), entity.SyncStatusFetching)
+	if err := collections.UpdateSyncStatus(c.ID, entity.SyncStatusFailed); err != nil {
+		t.Fatal(err)
+	}
+
+	s.scheduleDue(now.Add(s.opts.CheckInterval))
+	if got := syncStatus(t, collections, c.ID); got != entity.SyncStatusFailed {
+		t.Fatalf("status right after the failure = %q, want %q", got, entity.SyncStatusFailed)
+	}
+
+	s.scheduleDue(now.Add(s.opts.Interval + s.opts.CheckInterval))
+	if got := syncStatus(t, collections, c.ID); got != entity.SyncStatusPending {
+		t.Fatalf("status one interval after the failure = %q, want %q", got, entity.SyncStatusPending)
+	}
+}
@@ -55,3 +55,17 @@ func (s *Service) CreateUser(name, email, password string) (*entity.User, error)
 	}
 	return u, nil
 }
+
+// SetAdmin grants or revokes the admin pages to the user with the email.
+func (s *Service) SetAdmin(email string, admin bool) (*entity.User, error) {
+	u, err := s.FindUserByEmail(email)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.UpdateUserAdmin(u.ID, admin); err != nil {
+		s.logger.Error(s.ctx, "failed to update user admin", err, map[string]any{"email": email})
+		return nil, err
+	}
+	u.Admin = admin
+	return u, nil
+}
@@ -3,6 +3,7 @@ package user
 import (
 	"akira/internal/entity"
 	"database/sql"
+	"time"
 )
 
 type UserSqliteRepository struct {
@@ -22,6 +23,7 @@ func (r *UserSqliteRepository) scanUserRow(row *sql.Row) (*entity.User, error) {
 		&nullableAvatar,
 		&user.Email,
 		&user.Password,
+		&user.Admin,
 		&user.CreatedAt,
 		&user.UpdateAt,
 	)
@@ -36,7 +38,7 @@ func (r *UserSqliteRepository) scanUserRow(row *sql.Row) (*entity.User, error) {
 }
 
 func (r *UserSqliteRepository) FindUserByID(id string) (*entity.User, error) {
-	stmt, err := r.db.Prepare("SELECT id, name, avatar, email, password, created_at, updated_at FROM users WHERE id = ?")
+	stmt, err := r.db.Prepare("SELECT id, name, avatar, email, password, admin, created_at, updated_at FROM users WHERE id = ?")
 	if err != nil {
 		return nil, err
 	}
@@ -46,7 +48,7 @@ func (r *UserSqliteRepository) FindUserByID(id string) (*entity.User, error) {
 }
 
 func (r *UserSqliteRepository) FindUserByEmail(email string) (*entity.User, error) {
-	stmt, err := r.db.Prepare("SELECT id, name, avatar, email, password, created_at, updated_at FROM users WHERE email = ?")
+	stmt, err := r.db.Prepare("SELECT id, name, avatar, email, password, admin, created_at, updated_at FROM users WHERE email = ?")
 	if err != nil {
 		return nil, err
 	}
@@ -64,3 +66,18 @@ func (r *UserSqliteRepository) CreateUser(user *entity.User) error {
 	_, err = stmt.Exec(user.ID, user.Name, user.Avatar, user.Email, user.Password, user.CreatedAt, user.UpdateAt)
 	return err
 }
+
+func (r *UserSqliteRepository) UpdateUserAdmin(id string, admin bool) error {
+	res, err := r.db.Exec("UPDATE users SET admin = ?, updated_at = ? WHERE id = ?", admin, time.Now(), id)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return entity.ErrNotFound
+	}
+	return nil
+}
@@ -1,6 +1,10 @@
 package helper
 
-import "strconv"
+import (
+	"net/url"
+	"strconv"
+	"time"
+)
 
 func String(v any) string {
 	if v == nil {
@@ -26,3 +30,40 @@ func Conditional(v bool, truthy, falsy any) string {
 	}
 	return String(falsy)
 }
+
+func Date(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Format("02/01/2006")
+}
+
+func DateTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Format("02/01/2006 15:04")
+}
+
+func QueryURL(path string, params map[string]string) string {
+	values := url.Values{}
+	for k, v := range params {
+		if v != "" {
+			values.Set(k, v)
+		}
+	}
+	if len(values) == 0 {
+		return path
+	}
+	return path + "?" + values.Encode()
+}
+
+func PageWindow(page, totalPages, size int) []int {
+	start := max(page-size, 1)
+	end := min(page+size, totalPages)
+	pages := make([]int, 0, end-start+1)
+	for p := start; p <= end; p++ {
+		pages = append(pages, p)
+	}
+	return pages
+}
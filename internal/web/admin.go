@@ -0,0 +1,83 @@
+package web
+
+import (
+	"akira/internal/entity"
+	"akira/internal/view/component/admin"
+	"akira/internal/view/page"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const crawlerDebugDefaultPages = 1
+
+func (h *Handler) adminRequiredMiddleware(w http.ResponseWriter, r *http.Request) error {
+	session, err := h.session.GetSession(r.Context())
+	if err != nil {
+		return entity.ErrUserUnauthorized
+	}
+	user, err := h.user.FindUserByID(session.UserID)
+	if err != nil {
+		if err == entity.ErrNotFound {
+			return entity.ErrUserUnauthorized
+		}
+		return err
+	}
+	if !user.Admin {
+		return WebError{code: http.StatusForbidden, msg: entity.ErrUserForbidden.Error()}
+	}
+	return nil
+}
+
+func (h *Handler) handleCrawlerDebugPage(w http.ResponseWriter, r *http.Request) error {
+	return Render(w, r, page.CrawlerDebug(h.crawler.Providers(), admin.CrawlerDebugProps{
+		MaxPages: crawlerDebugDefaultPages,
+	}))
+}
+
+func (h *Handler) handleCrawlerDebugRequest(w http.ResponseWriter, r *http.Request) error {
+	props := admin.CrawlerDebugProps{
+		Site:         r.FormValue("site"),
+		Terms:        r.FormValue("terms"),
+		MaxPages:     crawlerDebugDefaultPages,
+		ForceRefresh: r.FormValue("force") == "on",
+	}
+	req := entity.CrawlDebugRequest{
+		Site:         props.Site,
+		ForceRefresh: props.ForceRefresh,
+	}
+	for _, term := range strings.Split(props.Terms, "\n") {
+		if term = strings.TrimSpace(term); term != "" {
+			req.SearchTerms = append(req.SearchTerms, term)
+		}
+	}
+	var reqErr entity.RequestError
+	if r.FormValue("max_pages") != "" {
+		pages, err := strconv.Atoi(r.FormValue("max_pages"))
+		if err != nil || pages < 1 {
+			reqErr = reqErr.Add("max_pages", "error.crawler.max-pages-invalid")
+		}
+		props.MaxPages = pages
+	}
+	req.MaxPages = props.MaxPages
+	providers := h.crawler.Providers()
+	if reqErr.HasError() {
+		return Render(w, r, admin.CrawlerDebug(providers, props, nil, &reqErr))
+	}
+	report, err := h.crawler.DebugFetch(r.Context(), req)
+	if err != nil {
+		switch err {
+		case entity.ErrCrawlerProviderNotFound:
+			reqErr = reqErr.Add("site", "error.crawler.provider-not-found")
+		case entity.ErrCrawlerNoSearchTerms:
+			reqErr = reqErr.Add("terms", "error.crawler.no-search-terms")
+		default:
+			h.logger.Error(r.Context(), "failed to run crawler debug fetch", err, map[string]any{
+				"site": req.Site,
+			})
+			reqErr = reqErr.Add("general", "error.unexpected-error")
+		}
+		return Render(w, r, admin.CrawlerDebug(providers, props, nil, &reqErr))
+	}
+	return Render(w, r, admin.CrawlerDebug(providers, props, report, nil))
+}
@@ -0,0 +1,303 @@
+package web
+
+import (
+	"akira/internal/entity"
+	"akira/internal/view/component/collection"
+	"akira/internal/view/component/form"
+	"akira/internal/view/page"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func (h *Handler) handleBookStatePartial(w http.ResponseWriter, r *http.Request) error {
+	session, err := h.session.GetSession(r.Context())
+	if err != nil {
+		return WebError{code: http.StatusUnauthorized, msg: entity.ErrUserUnauthorized.Error()}
+	}
+	book, err := h.book.FindBookByID(session.UserID, chi.URLParam(r, "id"))
+	if err != nil {
+		return bookWebError(err)
+	}
+	history, err := h.price.FindBookPriceHistory(book.ID)
+	if err != nil {
+		return err
+	}
+	reviews, err := h.review.FindBookReviews(book.ID)
+	if err != nil {
+		return err
+	}
+	return Render(w, r, collection.BookDetails(chi.URLParam(r, "slug"), book, history, reviews))
+}
+
+func (h *Handler) handleBookStateRequest(w http.ResponseWriter, r *http.Request) error {
+	if err := r.ParseForm(); err != nil {
+		return err
+	}
+	session, err := h.session.GetSession(r.Context())
+	if err != nil {
+		return WebError{code: http.StatusUnauthorized, msg: entity.ErrUserUnauthorized.Error()}
+	}
+	slug := chi.URLParam(r, "slug")
+	book, err := h.book.FindBookByID(session.UserID, chi.URLParam(r, "id"))
+	if err != nil {
+		return bookWebError(err)
+	}
+	props := form.BookStateProps{
+		Name:        book.Name,
+		Ownership:   entity.OwnershipStatus(r.FormValue("ownership")),
+		Reading:     entity.ReadingStatus(r.FormValue("reading_status")),
+		PurchasedAt: r.FormValue("purchased_at"),
+		Store:       r.FormValue("store"),
+		Condition:   entity.BookCondition(r.FormValue("condition")),
+	}
+	var reqErr entity.RequestError
+	req := entity.UpdateBookStateRequest{
+		Ownership: props.Ownership,
+		Reading:   props.Reading,
+		Store:     props.Store,
+		Condition: props.Condition,
+	}
+	if props.PurchasedAt != "" {
+		req.PurchasedAt, err = time.Parse("2006-01-02", props.PurchasedAt)
+		if err != nil {
+			reqErr = reqErr.Add("purchased_at", entity.ErrBookPurchasedAtInvalid.Error())
+		}
+	}
+	if r.FormValue("price_paid") != "" {
+		req.PricePaid, err = strconv.ParseFloat(r.FormValue("price_paid"), 64)
+		if err != nil {
+			reqErr = reqErr.Add("price_paid", entity.ErrBookPricePaidInvalid.Error())
+		}
+		props.PricePaid = req.PricePaid
+	}
+	if r.FormValue("target_price") != "" {
+		req.TargetPrice, err = strconv.ParseFloat(r.FormValue("target_price"), 64)
+		if err != nil {
+			reqErr = reqErr.Add("target_price", entity.ErrBookTargetPriceInvalid.Error())
+		}
+		props.TargetPrice = req.TargetPrice
+	}
+	if reqErr.HasError() {
+		return Render(w, r, form.BookState(slug, book.ID, props, &reqErr))
+	}
+	if _, err := h.book.UpdateBookState(session.UserID, book.ID, req); err != nil {
+		if reqErr, ok := err.(entity.RequestError); ok {
+			return Render(w, r, form.BookState(slug, book.ID, props, &reqErr))
+		}
+		return err
+	}
+	return HxRedirect(w, r, "/collection/"+slug)
+}
+
+func (h *Handler) handleBulkUpdateBooksRequest(w http.ResponseWriter, r *http.Request) error {
+	if err := r.ParseForm(); err != nil {
+		return err
+	}
+	session, err := h.session.GetSession(r.Context())
+	if err != nil {
+		return WebError{code: http.StatusUnauthorized, msg: entity.ErrUserUnauthorized.Error()}
+	}
+	slug := chi.URLParam(r, "slug")
+	c, err := h.collection.FindCollectionBySlug(session.UserID, slug)
+	if err != nil {
+		if err == entity.ErrCollectionNotFound {
+			return WebError{code: http.StatusNotFound, msg: err.Error()}
+		}
+		return err
+	}
+	err = h.book.BulkUpdateBooks(session.UserID, c.ID, entity.BulkUpdateBooksRequest{
+		BookIDs:   r.Form["book_ids[]"],
+		Ownership: entity.OwnershipStatus(r.FormValue("ownership")),
+		Reading:   entity.ReadingStatus(r.FormValue("reading_status")),
+	})
+	if err != nil {
+		if reqErr, ok := err.(entity.RequestError); ok {
+			for _, msgs := range reqErr {
+				return WebError{code: http.StatusBadRequest, msg: msgs[0]}
+			}
+		}
+		return bookWebError(err)
+	}
+	return HxRedirect(w, r, "/collection/"+slug)
+}
+
+func (h *Handler) handleAddBookPage(w http.ResponseWriter, r *http.Request) error {
+	session, err := h.session.GetSession(r.Context())
+	if err != nil {
+		return WebError{code: http.StatusUnauthorized, msg: entity.ErrUserUnauthorized.Error()}
+	}
+	c, err := h.collection.FindCollectionBySlug(session.UserID, chi.URLParam(r, "slug"))
+	if err != nil {
+		if err == entity.ErrCollectionNotFound {
+			return WebError{code: http.StatusNotFound, msg: err.Error()}
+		}
+		return err
+	}
+	// new volumes start with the details shared by the whole collection
+	props := form.BookProps{
+		Name:      c.Name,
+		Publisher: c.Publisher,
+		Author:    strings.Join(c.Author, ", "),
+		Language:  c.Language,
+		Tags:      strings.Join(c.Tags, ", "),
+	}
+	if volume, err := strconv.Atoi(r.URL.Query().Get("volume")); err == nil && volume > 0 {
+		props.Name = fmt.Sprintf("%s Vol. %d", c.Name, volume)
+		props.Volume = strconv.Itoa(volume)
+	}
+	return Render(w, r, page.AddBook(c.Slug, props, nil))
+}
+
+func (h *Handler) handleAddBookRequest(w http.ResponseWriter, r *http.Request) error {
+	if err := r.ParseForm(); err != nil {
+		return err
+	}
+	session, err := h.session.GetSession(r.Context())
+	if err != nil {
+		return WebError{code: http.StatusUnauthorized, msg: entity.ErrUserUnauthorized.Error()}
+	}
+	slug := chi.URLParam(r, "slug")
+	c, err := h.collection.FindCollectionBySlug(session.UserID, slug)
+	if err != nil {
+		if err == entity.ErrCollectionNotFound {
+			return WebError{code: http.StatusNotFound, msg: err.Error()}
+		}
+		return err
+	}
+	action := "/collection/" + slug + "/book/new"
+	props, req, reqErr := parseBookForm(r)
+	if reqErr.HasError() {
+		return Render(w, r, form.Book(slug, action, props, &reqErr))
+	}
+	req.Edition = c.Edition
+	// books added by hand are never updated by the crawlers
+	req.Metadata = map[string]string{"source": "manual"}
+	if _, err := h.book.CreateCollectionBook(session.UserID, c.ID, req); err != nil {
+		if reqErr, ok := err.(entity.RequestError); ok {
+			return Render(w, r, form.Book(slug, action, props, &reqErr))
+		}
+		h.logger.Error(r.Context(), "failed to add book", err, map[string]any{
+			"userID": session.UserID,
+			"slug":   slug,
+		})
+		reqErr := entity.RequestError{}.Add("general", "error.unexpected-error")
+		return Render(w, r, form.Book(slug, action, props, &reqErr))
+	}
+	return HxRedirect(w, r, "/collection/"+slug)
+}
+
+func (h *Handler) handleEditBookPage(w http.ResponseWriter, r *http.Request) error {
+	session, err := h.session.GetSession(r.Context())
+	if err != nil {
+		return WebError{code: http.StatusUnauthorized, msg: entity.ErrUserUnauthorized.Error()}
+	}
+	book, err := h.book.FindBookByID(session.UserID, chi.URLParam(r, "id"))
+	if err != nil {
+		return bookWebError(err)
+	}
+	return Render(w, r, page.EditBook(chi.URLParam(r, "slug"), book.ID, form.NewBookProps(book), nil))
+}
+
+func (h *Handler) handleEditBookRequest(w http.ResponseWriter, r *http.Request) error {
+	if err := r.ParseForm(); err != nil {
+		return err
+	}
+	session, err := h.session.GetSession(r.Context())
+	if err != nil {
+		return WebError{code: http.StatusUnauthorized, msg: entity.ErrUserUnauthorized.Error()}
+	}
+	slug := chi.URLParam(r, "slug")
+	c, err := h.collection.FindCollectionBySlug(session.UserID, slug)
+	if err != nil {
+		if err == entity.ErrCollectionNotFound {
+			return WebError{code: http.StatusNotFound, msg: err.Error()}
+		}
+		return err
+	}
+	book, err := h.book.FindBookByID(session.UserID, chi.URLParam(r, "id"))
+	if err != nil {
+		return bookWebError(err)
+	}
+	action := "/collection/" + slug + "/book/" + book.ID + "/edit"
+	props, req, reqErr := parseBookForm(r)
+	if reqErr.HasError() {
+		return Render(w, r, form.Book(slug, action, props, &reqErr))
+	}
+	req.Edition = book.Edition
+	if _, err := h.book.UpdateCollectionBook(session.UserID, c.ID, book.ID, req); err != nil {
+		if reqErr, ok := err.(entity.RequestError); ok {
+			return Render(w, r, form.Book(slug, action, props, &reqErr))
+		}
+		return bookWebError(err)
+	}
+	return HxRedirect(w, r, "/collection/"+slug)
+}
+
+func (h *Handler) handleDeleteBookRequest(w http.ResponseWriter, r *http.Request) error {
+	session, err := h.session.GetSession(r.Context())
+	if err != nil {
+		return WebError{code: http.StatusUnauthorized, msg: entity.ErrUserUnauthorized.Error()}
+	}
+	if err := h.book.DeleteBook(session.UserID, chi.URLParam(r, "id")); err != nil {
+		return bookWebError(err)
+	}
+	return HxRedirect(w, r, "/collection/"+chi.URLParam(r, "slug"))
+}
+
+// parseBookForm reads the book form, reporting the numbers that couldn't
+// be parsed; everything else is left to CreateBookRequest.Validate.
+func parseBookForm(r *http.Request) (form.BookProps, entity.CreateBookRequest, entity.RequestError) {
+	props := form.BookProps{
+		Name:        strings.TrimSpace(r.FormValue("name")),
+		Volume:      strings.TrimSpace(r.FormValue("volume")),
+		ISBN:        strings.TrimSpace(r.FormValue("isbn")),
+		CoverImage:  strings.TrimSpace(r.FormValue("cover_image")),
+		PageCount:   strings.TrimSpace(r.FormValue("page_count")),
+		Publisher:   strings.TrimSpace(r.FormValue("publisher")),
+		Author:      r.FormValue("author"),
+		Language:    strings.TrimSpace(r.FormValue("language")),
+		Tags:        r.FormValue("tags"),
+		Description: strings.TrimSpace(r.FormValue("description")),
+	}
+	req := entity.CreateBookRequest{
+		Name:        props.Name,
+		Description: props.Description,
+		CoverImage:  props.CoverImage,
+		Publisher:   props.Publisher,
+		Author:      splitList(props.Author),
+		ISBN:        props.ISBN,
+		Tags:        splitList(props.Tags),
+		Language:    props.Language,
+	}
+	var reqErr entity.RequestError
+	if props.Volume != "" {
+		volume, err := strconv.Atoi(props.Volume)
+		if err != nil {
+			reqErr = reqErr.Add("volume", entity.ErrBookVolumeInvalid.Error())
+		}
+		req.Volume = &volume
+	}
+	if props.PageCount != "" {
+		pageCount, err := strconv.Atoi(props.PageCount)
+		if err != nil {
+			reqErr = reqErr.Add("page_count", entity.ErrBookPageCountInvalid.Error())
+		}
+		req.PageCount = pageCount
+	}
+	return props, req, reqErr
+}
+
+func bookWebError(err error) error {
+	switch err {
+	case entity.ErrBookNotFound:
+		return WebError{code: http.StatusNotFound, msg: err.Error()}
+	case entity.ErrBookForbidden:
+		return WebError{code: http.StatusForbidden, msg: err.Error()}
+	}
+	return err
+}
@@ -0,0 +1,108 @@
+package web
+
+import (
+	"akira/internal/entity"
+	"akira/internal/view/component/candidate"
+	"akira/internal/view/page"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func (h *Handler) handleCandidatesPage(w http.ResponseWriter, r *http.Request) error {
+	session, err := h.session.GetSession(r.Context())
+	if err != nil {
+		return WebError{code: http.StatusUnauthorized, msg: entity.ErrUserUnauthorized.Error()}
+	}
+	c, err := h.collection.FindCollectionBySlug(session.UserID, chi.URLParam(r, "slug"))
+	if err != nil {
+		if err == entity.ErrCollectionNotFound {
+			return WebError{code: http.StatusNotFound, msg: err.Error()}
+		}
+		return err
+	}
+	candidates, err := h.candidate.FindPendingCandidates(session.UserID, c.ID)
+	if err != nil {
+		return err
+	}
+	return Render(w, r, page.Candidates(c, candidates))
+}
+
+func (h *Handler) handleAcceptConfidentCandidatesRequest(w http.ResponseWriter, r *http.Request) error {
+	session, err := h.session.GetSession(r.Context())
+	if err != nil {
+		return WebError{code: http.StatusUnauthorized, msg: entity.ErrUserUnauthorized.Error()}
+	}
+	slug := chi.URLParam(r, "slug")
+	c, err := h.collection.FindCollectionBySlug(session.UserID, slug)
+	if err != nil {
+		if err == entity.ErrCollectionNotFound {
+			return WebError{code: http.StatusNotFound, msg: err.Error()}
+		}
+		return err
+	}
+	if _, err := h.candidate.AcceptConfidentCandidates(session.UserID, c.ID); err != nil {
+		return err
+	}
+	return HxRedirect(w, r, "/collection/"+slug+"/candidates")
+}
+
+// handleAcceptCandidateRequest answers with no content, removing the row
+// from the review list.
+func (h *Handler) handleAcceptCandidateRequest(w http.ResponseWriter, r *http.Request) error {
+	session, err := h.session.GetSession(r.Context())
+	if err != nil {
+		return WebError{code: http.StatusUnauthorized, msg: entity.ErrUserUnauthorized.Error()}
+	}
+	if _, err := h.candidate.AcceptCandidate(session.UserID, chi.URLParam(r, "id")); err != nil {
+		return candidateWebError(err)
+	}
+	w.WriteHeader(http.StatusOK)
+	return nil
+}
+
+func (h *Handler) handleRejectCandidateRequest(w http.ResponseWriter, r *http.Request) error {
+	session, err := h.session.GetSession(r.Context())
+	if err != nil {
+		return WebError{code: http.StatusUnauthorized, msg: entity.ErrUserUnauthorized.Error()}
+	}
+	if _, err := h.candidate.RejectCandidate(session.UserID, chi.URLParam(r, "id")); err != nil {
+		return candidateWebError(err)
+	}
+	w.WriteHeader(http.StatusOK)
+	return nil
+}
+
+func (h *Handler) handleCandidateVolumeRequest(w http.ResponseWriter, r *http.Request) error {
+	if err := r.ParseForm(); err != nil {
+		return err
+	}
+	session, err := h.session.GetSession(r.Context())
+	if err != nil {
+		return WebError{code: http.StatusUnauthorized, msg: entity.ErrUserUnauthorized.Error()}
+	}
+	volume, err := strconv.Atoi(r.FormValue("volume"))
+	if err != nil {
+		return WebError{code: http.StatusBadRequest, msg: entity.ErrCandidateVolumeInvalid.Error()}
+	}
+	c, err := h.candidate.AssignVolume(session.UserID, chi.URLParam(r, "id"), volume)
+	if err != nil {
+		return candidateWebError(err)
+	}
+	return Render(w, r, candidate.Row(chi.URLParam(r, "slug"), *c))
+}
+
+func candidateWebError(err error) error {
+	switch err {
+	case entity.ErrCandidateNotFound:
+		return WebError{code: http.StatusNotFound, msg: err.Error()}
+	case entity.ErrCandidateForbidden:
+		return WebError{code: http.StatusForbidden, msg: err.Error()}
+	case entity.ErrCandidateNotPending:
+		return WebError{code: http.StatusConflict, msg: err.Error()}
+	case entity.ErrCandidateVolumeInvalid:
+		return WebError{code: http.StatusBadRequest, msg: err.Error()}
+	}
+	return err
+}
@@ -2,10 +2,16 @@ package web
 
 import (
 	"akira/internal/entity"
+	"akira/internal/view/component/collection"
 	"akira/internal/view/component/form"
 	"akira/internal/view/page"
+	"encoding/json"
 	"net/http"
+	"slices"
 	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
 )
 
 func (h *Handler) handleCreateCollectionRequest(w http.ResponseWriter, r *http.Request) error {
@@ -17,59 +23,394 @@ func (h *Handler) handleCreateCollectionRequest(w http.ResponseWriter, r *http.R
 		err := entity.RequestError{}.Add("general", "unauthorized")
 		return Render(w, r, page.SignIn(form.SignInProps{}, &err))
 	}
-	var volumesNum int
+	providers := h.crawler.Providers()
+	props := form.CreateCollectionProps{
+		Name:         r.FormValue("name"),
+		Edition:      r.FormValue("edition"),
+		Author:       r.FormValue("author"),
+		Publisher:    r.FormValue("publisher"),
+		Language:     r.FormValue("language"),
+		Tags:         r.FormValue("tags"),
+		SyncSources:  r.Form["sync_sources[]"],
+		SearchTerms:  r.Form["search_terms[]"],
+		AutoSync:     r.FormValue("auto_sync") == "on",
+		TrackPrices:  r.FormValue("track_prices") == "on",
+		TrackVolumes: r.FormValue("track_volumes") == "on",
+		TrackReviews: r.FormValue("track_reviews") == "on",
+	}
+	reqErr := validateSyncSources(props.SyncSources, providers)
 	if r.FormValue("total_volumes") != "" {
-		var err error
-		volumesNum, err = strconv.Atoi(r.FormValue("total_volumes"))
+		props.TotalVolumes, err = strconv.Atoi(r.FormValue("total_volumes"))
 		if err != nil {
-			var reqErr entity.RequestError
-			reqErr.Add("total_volumes", "value invalid, must be a number")
-			return Render(w, r, form.CreateCollection(form.CreateCollectionProps{
-				Name:         r.FormValue("name"),
-				TotalVolumes: 0,
-				AutoSync:     r.FormValue("auto_sync") == "on",
-				TrackPrices:  r.FormValue("track_price") == "on",
-				TrackVolumes: r.FormValue("track_volumes") == "on",
-				TrackReviews: r.FormValue("track_reviews") == "on",
-			}, &reqErr))
+			reqErr = reqErr.Add("total_volumes", entity.ErrCollectionTotalVolumesInvalid.Error())
 		}
 	}
+	if reqErr.HasError() {
+		return Render(w, r, form.CreateCollection(props, providers, &reqErr))
+	}
 	req := entity.CreateCollectionRequest{
-		Name:         r.FormValue("name"),
-		TotalVolumes: volumesNum,
+		Name:         props.Name,
+		TotalVolumes: props.TotalVolumes,
+		Edition:      props.Edition,
+		Author:       splitList(props.Author),
+		Publisher:    props.Publisher,
+		Tags:         splitList(props.Tags),
+		SearchTerms:  props.SearchTerms,
+		SyncSources:  props.SyncSources,
+		Language:     props.Language,
 		CrawlerOptions: entity.SyncOptions{
-			AutoSync:        r.FormValue("auto_sync") == "on",
-			TrackPrice:      r.FormValue("track_price") == "on",
-			TrackNewVolumes: r.FormValue("track_volumes") == "on",
-			TrackReviews:    r.FormValue("track_reviews") == "on",
+			AutoSync:        props.AutoSync,
+			TrackPrice:      props.TrackPrices,
+			TrackNewVolumes: props.TrackVolumes,
+			TrackReviews:    props.TrackReviews,
 		},
 	}
 	collection, err := h.collection.CreateCollection(session.UserID, req)
 	if err != nil {
-		if _, ok := err.(entity.RequestError); ok {
-			err := err.(entity.RequestError)
-			return Render(w, r, form.CreateCollection(form.CreateCollectionProps{
-				Name:         req.Name,
-				TotalVolumes: req.TotalVolumes,
-				AutoSync:     req.CrawlerOptions.AutoSync,
-				TrackPrices:  req.CrawlerOptions.TrackPrice,
-				TrackVolumes: req.CrawlerOptions.TrackNewVolumes,
-				TrackReviews: req.CrawlerOptions.TrackReviews,
-			}, &err))
-		}
-		reqerr := entity.RequestError{}.Add("general", err.Error())
+		if reqErr, ok := err.(entity.RequestError); ok {
+			return Render(w, r, form.CreateCollection(props, providers, &reqErr))
+		}
+		reqErr := entity.RequestError{}.Add("general", "error.unexpected-error")
 		h.logger.Error(r.Context(), "failed to create collection", err, map[string]any{
 			"userID": session.UserID,
 			"req":    req,
 		})
-		return Render(w, r, form.CreateCollection(form.CreateCollectionProps{
-			Name:         req.Name,
-			TotalVolumes: req.TotalVolumes,
-			AutoSync:     req.CrawlerOptions.AutoSync,
-			TrackPrices:  req.CrawlerOptions.TrackPrice,
-			TrackVolumes: req.CrawlerOptions.TrackNewVolumes,
-			TrackReviews: req.CrawlerOptions.TrackReviews,
-		}, &reqerr))
+		return Render(w, r, form.CreateCollection(props, providers, &reqErr))
 	}
 	return HxRedirect(w, r, "/collection/"+collection.Slug)
 }
+
+func (h *Handler) handleSyncCollectionRequest(w http.ResponseWriter, r *http.Request) error {
+	session, err := h.session.GetSession(r.Context())
+	if err != nil {
+		return WebError{code: http.StatusUnauthorized, msg: entity.ErrUserUnauthorized.Error()}
+	}
+	slug := chi.URLParam(r, "slug")
+	force := r.FormValue("force") == "on"
+	c, err := h.collection.SyncCollection(session.UserID, slug, force)
+	if err != nil {
+		switch err {
+		case entity.ErrCollectionNotFound:
+			return WebError{code: http.StatusNotFound, msg: err.Error()}
+		case entity.ErrCollectionWithoutSources, entity.ErrCollectionAlreadySyncing, entity.ErrCollectionArchived:
+			c, findErr := h.collection.FindCollectionBySlug(session.UserID, slug)
+			if findErr != nil {
+				return findErr
+			}
+			reqErr := entity.RequestError{}.Add("general", err.Error())
+			return Render(w, r, collection.SyncPanel(c, h.latestRun(c.ID), &reqErr))
+		}
+		return err
+	}
+	return Render(w, r, collection.SyncPanel(c, h.latestRun(c.ID), nil))
+}
+
+func (h *Handler) handleCancelSyncRequest(w http.ResponseWriter, r *http.Request) error {
+	session, err := h.session.GetSession(r.Context())
+	if err != nil {
+		return WebError{code: http.StatusUnauthorized, msg: entity.ErrUserUnauthorized.Error()}
+	}
+	c, err := h.collection.FindCollectionBySlug(session.UserID, chi.URLParam(r, "slug"))
+	if err != nil {
+		if err == entity.ErrCollectionNotFound {
+			return WebError{code: http.StatusNotFound, msg: err.Error()}
+		}
+		return err
+	}
+	if err := h.crawler.CancelFetch(c.ID); err != nil {
+		if err != entity.ErrCrawlerNotRunning {
+			return err
+		}
+		if !c.IsSyncing() {
+			reqErr := entity.RequestError{}.Add("general", "error.crawler.not-running")
+			return Render(w, r, collection.SyncPanel(c, h.latestRun(c.ID), &reqErr))
+		}
+		// no run is left for a collection still marked as syncing, so
+		// release it instead of waiting for a crawler event
+		if err := h.collection.UpdateSyncStatus(c.ID, entity.SyncStatusFailed); err != nil {
+			return err
+		}
+		c.SyncStatus = entity.SyncStatusFailed
+		return Render(w, r, collection.SyncPanel(c, h.latestRun(c.ID), nil))
+	}
+	// the live sync stream renders the panel again once the run stops
+	return Render(w, r, collection.SyncPanel(c, h.latestRun(c.ID), nil))
+}
+
+func (h *Handler) handleRetrySyncRequest(w http.ResponseWriter, r *http.Request) error {
+	session, err := h.session.GetSession(r.Context())
+	if err != nil {
+		return WebError{code: http.StatusUnauthorized, msg: entity.ErrUserUnauthorized.Error()}
+	}
+	c, err := h.collection.FindCollectionBySlug(session.UserID, chi.URLParam(r, "slug"))
+	if err != nil {
+		if err == entity.ErrCollectionNotFound {
+			return WebError{code: http.StatusNotFound, msg: err.Error()}
+		}
+		return err
+	}
+	if c.IsArchived() {
+		reqErr := entity.RequestError{}.Add("general", entity.ErrCollectionArchived.Error())
+		return Render(w, r, collection.SyncPanel(c, h.latestRun(c.ID), &reqErr))
+	}
+	// the status is written first, a worker may finish the run before
+	// RetryFetch returns
+	previous := c.SyncStatus
+	if err := h.collection.UpdateSyncStatus(c.ID, entity.SyncStatusPending); err != nil {
+		return err
+	}
+	if err := h.crawler.RetryFetch(r.Context(), c.ID); err != nil {
+		if restoreErr := h.collection.RestoreSyncStatus(c.ID, previous); restoreErr != nil {
+			return restoreErr
+		}
+		var msg string
+		switch err {
+		case entity.ErrCrawlerAlreadyRunning:
+			msg = entity.ErrCollectionAlreadySyncing.Error()
+		case entity.ErrCrawlerNotRetryable:
+			msg = "error.crawler.not-retryable"
+		default:
+			return err
+		}
+		reqErr := entity.RequestError{}.Add("general", msg)
+		return Render(w, r, collection.SyncPanel(c, h.latestRun(c.ID), &reqErr))
+	}
+	c.SyncStatus = entity.SyncStatusPending
+	return Render(w, r, collection.SyncPanel(c, h.latestRun(c.ID), nil))
+}
+
+// handleSyncStatusRequest reports the sync status of the collection and the
+// outcome of its last run per provider as JSON.
+func (h *Handler) handleSyncStatusRequest(w http.ResponseWriter, r *http.Request) error {
+	session, err := h.session.GetSession(r.Context())
+	if err != nil {
+		return WebError{code: http.StatusUnauthorized, msg: entity.ErrUserUnauthorized.Error()}
+	}
+	c, err := h.collection.FindCollectionBySlug(session.UserID, chi.URLParam(r, "slug"))
+	if err != nil {
+		if err == entity.ErrCollectionNotFound {
+			return WebError{code: http.StatusNotFound, msg: err.Error()}
+		}
+		return err
+	}
+	status, err := h.crawler.GetStatus(c.ID)
+	if err != nil {
+		return err
+	}
+	payload := map[string]any{
+		"collection": c.SyncStatus,
+		"status":     status,
+		"last_sync":  c.LastSync,
+	}
+	if run := h.latestRun(c.ID); run != nil {
+		payload["run"] = map[string]any{
+			"id":           run.ID,
+			"status":       run.Status,
+			"queued_at":    run.QueuedAt,
+			"started_at":   run.StartedAt,
+			"finished_at":  run.FinishedAt,
+			"result_count": run.ResultCount,
+			"error":        run.Error,
+			"providers":    run.Providers,
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	return json.NewEncoder(w).Encode(payload)
+}
+
+func (h *Handler) handleRunLogRequest(w http.ResponseWriter, r *http.Request) error {
+	session, err := h.session.GetSession(r.Context())
+	if err != nil {
+		return WebError{code: http.StatusUnauthorized, msg: entity.ErrUserUnauthorized.Error()}
+	}
+	c, err := h.collection.FindCollectionBySlug(session.UserID, chi.URLParam(r, "slug"))
+	if err != nil {
+		if err == entity.ErrCollectionNotFound {
+			return WebError{code: http.StatusNotFound, msg: err.Error()}
+		}
+		return err
+	}
+	entries, err := h.crawler.RunLog(c.ID, chi.URLParam(r, "id"))
+	if err != nil {
+		return err
+	}
+	return Render(w, r, collection.RunLog(entries))
+}
+
+// latestRun returns the last crawl run of the collection, or nil when it
+// was never synced or the run can't be loaded.
+func (h *Handler) latestRun(collectionID string) *entity.CrawlRun {
+	run, err := h.crawler.LatestRun(collectionID)
+	if err != nil {
+		return nil
+	}
+	return run
+}
+
+func (h *Handler) handleCollectionSettingsRequest(w http.ResponseWriter, r *http.Request) error {
+	if err := r.ParseForm(); err != nil {
+		return err
+	}
+	session, err := h.session.GetSession(r.Context())
+	if err != nil {
+		return WebError{code: http.StatusUnauthorized, msg: entity.ErrUserUnauthorized.Error()}
+	}
+	slug := chi.URLParam(r, "slug")
+	opts := entity.SyncOptions{
+		AutoSync:        r.FormValue("auto_sync") == "on",
+		TrackPrice:      r.FormValue("track_prices") == "on",
+		TrackNewVolumes: r.FormValue("track_volumes") == "on",
+		TrackReviews:    r.FormValue("track_reviews") == "on",
+	}
+	c, err := h.collection.UpdateCollectionSettings(session.UserID, slug, opts)
+	if err != nil {
+		if err == entity.ErrCollectionNotFound {
+			return WebError{code: http.StatusNotFound, msg: err.Error()}
+		}
+		reqErr := entity.RequestError{}.Add("general", "error.unexpected-error")
+		return Render(w, r, form.CollectionSettings(slug, opts, &reqErr))
+	}
+	return HxRedirect(w, r, "/collection/"+c.Slug)
+}
+
+func (h *Handler) handleEditCollectionRequest(w http.ResponseWriter, r *http.Request) error {
+	if err := r.ParseForm(); err != nil {
+		return err
+	}
+	session, err := h.session.GetSession(r.Context())
+	if err != nil {
+		return WebError{code: http.StatusUnauthorized, msg: entity.ErrUserUnauthorized.Error()}
+	}
+	slug := chi.URLParam(r, "slug")
+	c, err := h.collection.FindCollectionBySlug(session.UserID, slug)
+	if err != nil {
+		if err == entity.ErrCollectionNotFound {
+			return WebError{code: http.StatusNotFound, msg: err.Error()}
+		}
+		return err
+	}
+	props := form.EditCollectionProps{
+		Name:        r.FormValue("name"),
+		Edition:     r.FormValue("edition"),
+		Author:      r.FormValue("author"),
+		Publisher:   r.FormValue("publisher"),
+		Language:    r.FormValue("language"),
+		Tags:        r.FormValue("tags"),
+		SyncSources: r.Form["sync_sources[]"],
+		SearchTerms: r.Form["search_terms[]"],
+	}
+	providers := h.crawler.Providers()
+	reqErr := validateSyncSources(props.SyncSources, providers)
+	if r.FormValue("total_volumes") != "" {
+		props.TotalVolumes, err = strconv.Atoi(r.FormValue("total_volumes"))
+		if err != nil {
+			reqErr = reqErr.Add("total_volumes", entity.ErrCollectionTotalVolumesInvalid.Error())
+		}
+	}
+	if reqErr.HasError() {
+		return Render(w, r, form.EditCollection(slug, props, providers, &reqErr))
+	}
+	req := entity.UpdateCollectionRequest{
+		Name:           props.Name,
+		TotalVolumes:   props.TotalVolumes,
+		Edition:        props.Edition,
+		Author:         splitList(props.Author),
+		Publisher:      props.Publisher,
+		Tags:           splitList(props.Tags),
+		SearchTerms:    props.SearchTerms,
+		SyncSources:    props.SyncSources,
+		CrawlerOptions: c.CrawlerOptions,
+		Language:       props.Language,
+	}
+	c, err = h.collection.UpdateCollection(session.UserID, c.ID, req)
+	if err != nil {
+		if reqErr, ok := err.(entity.RequestError); ok {
+			return Render(w, r, form.EditCollection(slug, props, providers, &reqErr))
+		}
+		h.logger.Error(r.Context(), "failed to update collection", err, map[string]any{
+			"userID": session.UserID,
+			"slug":   slug,
+		})
+		reqErr := entity.RequestError{}.Add("general", "error.unexpected-error")
+		return Render(w, r, form.EditCollection(slug, props, providers, &reqErr))
+	}
+	return HxRedirect(w, r, "/collection/"+c.Slug)
+}
+
+func (h *Handler) handleArchiveCollectionRequest(w http.ResponseWriter, r *http.Request) error {
+	return h.changeArchiveState(w, r, h.collection.ArchiveCollection)
+}
+
+func (h *Handler) handleUnarchiveCollectionRequest(w http.ResponseWriter, r *http.Request) error {
+	return h.changeArchiveState(w, r, h.collection.UnarchiveCollection)
+}
+
+func (h *Handler) changeArchiveState(
+	w http.ResponseWriter,
+	r *http.Request,
+	change func(userID, collectionID string) (*entity.Collection, error),
+) error {
+	session, err := h.session.GetSession(r.Context())
+	if err != nil {
+		return WebError{code: http.StatusUnauthorized, msg: entity.ErrUserUnauthorized.Error()}
+	}
+	c, err := h.collection.FindCollectionBySlug(session.UserID, chi.URLParam(r, "slug"))
+	if err != nil {
+		if err == entity.ErrCollectionNotFound {
+			return WebError{code: http.StatusNotFound, msg: err.Error()}
+		}
+		return err
+	}
+	c, err = change(session.UserID, c.ID)
+	if err != nil {
+		if err == entity.ErrCollectionForbidden {
+			return WebError{code: http.StatusForbidden, msg: err.Error()}
+		}
+		return err
+	}
+	return HxRedirect(w, r, "/collection/"+c.Slug)
+}
+
+func (h *Handler) handleDeleteCollectionRequest(w http.ResponseWriter, r *http.Request) error {
+	session, err := h.session.GetSession(r.Context())
+	if err != nil {
+		return WebError{code: http.StatusUnauthorized, msg: entity.ErrUserUnauthorized.Error()}
+	}
+	c, err := h.collection.FindCollectionBySlug(session.UserID, chi.URLParam(r, "slug"))
+	if err != nil {
+		if err == entity.ErrCollectionNotFound {
+			return WebError{code: http.StatusNotFound, msg: err.Error()}
+		}
+		return err
+	}
+	if err := h.collection.DeleteCollection(session.UserID, c.ID); err != nil {
+		if err == entity.ErrCollectionForbidden {
+			return WebError{code: http.StatusForbidden, msg: err.Error()}
+		}
+		return err
+	}
+	return HxRedirect(w, r, "/")
+}
+
+// validateSyncSources rejects sources that are not registered as providers in
+// the crawler service.
+func validateSyncSources(sources, providers []string) entity.RequestError {
+	var reqErr entity.RequestError
+	for _, source := range sources {
+		if !slices.Contains(providers, source) {
+			reqErr = reqErr.Add("sync_sources", entity.ErrCollectionSyncSourceInvalid.Error())
+			break
+		}
+	}
+	return reqErr
+}
+
+func splitList(value string) []string {
+	items := make([]string, 0)
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
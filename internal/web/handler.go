@@ -32,15 +32,22 @@ type Options struct {
 }
 
 type Handler struct {
-	r          *chi.Mux
-	mu         *sync.Mutex
-	user       entity.UserService
-	session    entity.SessionService
-	auth       entity.AuthService
-	logger     entity.Logger
-	i18n       entity.I18nService
-	theme      entity.ThemeService
-	collection entity.CollectionService
+	r            *chi.Mux
+	mu           *sync.Mutex
+	user         entity.UserService
+	session      entity.SessionService
+	auth         entity.AuthService
+	logger       entity.Logger
+	i18n         entity.I18nService
+	theme        entity.ThemeService
+	collection   entity.CollectionService
+	book         entity.BookService
+	price        entity.PriceService
+	review       entity.ReviewService
+	candidate    entity.CandidateService
+	notification entity.NotificationService
+	crawler      entity.CrawlerService
+	event        entity.EventService
 }
 
 func (h Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
@@ -121,18 +128,32 @@ func NewHandler(
 	i18n entity.I18nService,
 	theme entity.ThemeService,
 	collection entity.CollectionService,
+	book entity.BookService,
+	price entity.PriceService,
+	review entity.ReviewService,
+	candidate entity.CandidateService,
+	notification entity.NotificationService,
+	crawler entity.CrawlerService,
+	event entity.EventService,
 	opts Options,
 ) *Handler {
 	h := &Handler{
-		r:          r,
-		mu:         &sync.Mutex{},
-		user:       user,
-		session:    session,
-		auth:       auth,
-		logger:     logger,
-		i18n:       i18n,
-		theme:      theme,
-		collection: collection,
+		r:            r,
+		mu:           &sync.Mutex{},
+		user:         user,
+		session:      session,
+		auth:         auth,
+		logger:       logger,
+		i18n:         i18n,
+		theme:        theme,
+		collection:   collection,
+		book:         book,
+		price:        price,
+		review:       review,
+		candidate:    candidate,
+		notification: notification,
+		crawler:      crawler,
+		event:        event,
 	}
 	h.r.Use(chi_middleware.Logger)
 	h.r.Use(chi_middleware.RequestID, chi_middleware.Recoverer)
@@ -159,8 +180,45 @@ func (h *Handler) MakeRoutes() {
 	h.r.Route("/", func(r chi.Router) {
 		r.Use(MakeMiddleware(h.session.AuthenticationRequiredMiddleware, h.logger))
 		r.Get("/", MakeHandler(h.handleIndexPage, h.logger))
+		r.Get("/collections", MakeHandler(h.handleCollectionListPartial, h.logger))
 		r.Get("/collection/create", MakeHandler(h.handleCreateCollectionPage, h.logger))
 		r.Post("/collection/create", MakeHandler(h.handleCreateCollectionRequest, h.logger))
+		r.Get("/collection/{slug}", MakeHandler(h.handleCollectionPage, h.logger))
+		r.Post("/collection/{slug}/sync", MakeHandler(h.handleSyncCollectionRequest, h.logger))
+		r.Post("/collection/{slug}/sync/cancel", MakeHandler(h.handleCancelSyncRequest, h.logger))
+		r.Post("/collection/{slug}/sync/retry", MakeHandler(h.handleRetrySyncRequest, h.logger))
+		r.Get("/collection/{slug}/sync/status", MakeHandler(h.handleSyncStatusRequest, h.logger))
+		r.Get("/collection/{slug}/run/{id}/log", MakeHandler(h.handleRunLogRequest, h.logger))
+		r.Post("/collection/{slug}/settings", MakeHandler(h.handleCollectionSettingsRequest, h.logger))
+		r.Get("/collection/{slug}/edit", MakeHandler(h.handleEditCollectionPage, h.logger))
+		r.Post("/collection/{slug}/edit", MakeHandler(h.handleEditCollectionRequest, h.logger))
+		r.Post("/collection/{slug}/archive", MakeHandler(h.handleArchiveCollectionRequest, h.logger))
+		r.Post("/collection/{slug}/unarchive", MakeHandler(h.handleUnarchiveCollectionRequest, h.logger))
+		r.Delete("/collection/{slug}", MakeHandler(h.handleDeleteCollectionRequest, h.logger))
+		r.Get("/collection/{slug}/live", MakeHandler(h.handleCollectionLiveSync, h.logger))
+		r.Get("/collection/{slug}/volumes", MakeHandler(h.handleCollectionVolumesPartial, h.logger))
+		r.Post("/collection/{slug}/books", MakeHandler(h.handleBulkUpdateBooksRequest, h.logger))
+		r.Get("/collection/{slug}/book/new", MakeHandler(h.handleAddBookPage, h.logger))
+		r.Post("/collection/{slug}/book/new", MakeHandler(h.handleAddBookRequest, h.logger))
+		r.Get("/collection/{slug}/book/{id}", MakeHandler(h.handleBookStatePartial, h.logger))
+		r.Post("/collection/{slug}/book/{id}", MakeHandler(h.handleBookStateRequest, h.logger))
+		r.Delete("/collection/{slug}/book/{id}", MakeHandler(h.handleDeleteBookRequest, h.logger))
+		r.Get("/collection/{slug}/book/{id}/edit", MakeHandler(h.handleEditBookPage, h.logger))
+		r.Post("/collection/{slug}/book/{id}/edit", MakeHandler(h.handleEditBookRequest, h.logger))
+		r.Get("/collection/{slug}/candidates", MakeHandler(h.handleCandidatesPage, h.logger))
+		r.Post("/collection/{slug}/candidates/accept", MakeHandler(h.handleAcceptConfidentCandidatesRequest, h.logger))
+		r.Post("/collection/{slug}/candidate/{id}/accept", MakeHandler(h.handleAcceptCandidateRequest, h.logger))
+		r.Post("/collection/{slug}/candidate/{id}/reject", MakeHandler(h.handleRejectCandidateRequest, h.logger))
+		r.Post("/collection/{slug}/candidate/{id}/volume", MakeHandler(h.handleCandidateVolumeRequest, h.logger))
+		r.Get("/notifications", MakeHandler(h.handleNotificationsPage, h.logger))
+		r.Get("/notifications/badge", MakeHandler(h.handleNotificationBadgePartial, h.logger))
+		r.Post("/notifications/read-all", MakeHandler(h.handleReadAllNotificationsRequest, h.logger))
+		r.Post("/notifications/{id}/read", MakeHandler(h.handleReadNotificationRequest, h.logger))
+		r.Group(func(r chi.Router) {
+			r.Use(MakeMiddleware(h.adminRequiredMiddleware, h.logger))
+			r.Get("/admin/crawler", MakeHandler(h.handleCrawlerDebugPage, h.logger))
+			r.Post("/admin/crawler", MakeHandler(h.handleCrawlerDebugRequest, h.logger))
+		})
 	})
 	h.r.Get("/error", func(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, i18n.T(r.Context(), "error.unexpected-error"), http.StatusInternalServerError)
@@ -0,0 +1,116 @@
+package web
+
+import (
+	"akira/internal/entity"
+	"akira/internal/view/component/collection"
+	"bytes"
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/a-h/templ"
+	"github.com/go-chi/chi/v5"
+	"golang.org/x/net/websocket"
+)
+
+var liveSyncEvents = []entity.EventType{
+	entity.EventCrawlerStarted,
+	entity.EventCrawlerItemFounded,
+	entity.EventCrawlerCompleted,
+	entity.EventCrawlerFailed,
+}
+
+func (h *Handler) handleCollectionLiveSync(w http.ResponseWriter, r *http.Request) error {
+	session, err := h.session.GetSession(r.Context())
+	if err != nil {
+		return WebError{code: http.StatusUnauthorized, msg: entity.ErrUserUnauthorized.Error()}
+	}
+	c, err := h.collection.FindCollectionBySlug(session.UserID, chi.URLParam(r, "slug"))
+	if err != nil {
+		if err == entity.ErrCollectionNotFound {
+			return WebError{code: http.StatusNotFound, msg: err.Error()}
+		}
+		return err
+	}
+	// the stream changes the sync status it renders, so it works on a copy
+	live := *c
+	websocket.Handler(func(ws *websocket.Conn) {
+		h.streamCollectionSync(r.Context(), ws, session.UserID, &live)
+	}).ServeHTTP(w, r)
+	return nil
+}
+
+// streamCollectionSync pushes htmx fragments for the crawler events of the
+// collection until the client goes away or the event bus shuts down.
+func (h *Handler) streamCollectionSync(ctx context.Context, ws *websocket.Conn, userID string, c *entity.Collection) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	subID := "live-sync:" + entity.NewID()
+	sub := h.event.Subscribe(subID, cancel, liveSyncEvents...)
+	defer h.event.Unsubscribe(subID)
+	go func() {
+		// the client never sends messages, reading only detects a closed connection
+		var msg string
+		for websocket.Message.Receive(ws, &msg) == nil {
+		}
+		cancel()
+	}()
+	found := 0
+	if c.IsSyncing() {
+		if err := sendFragments(ctx, ws, collection.LiveSyncStarted()); err != nil {
+			return
+		}
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-sub.Ch:
+			if !ok {
+				return
+			}
+			data, ok := event.Data.(map[string]any)
+			if !ok || event.UserID != userID || data["collection_id"] != c.ID {
+				continue
+			}
+			var fragments []templ.Component
+			switch event.Type {
+			case entity.EventCrawlerStarted:
+				found = 0
+				c.SyncStatus = entity.SyncStatusFetching
+				fragments = append(fragments, collection.SyncPanel(c, nil, nil), collection.LiveSyncStarted())
+			case entity.EventCrawlerItemFounded:
+				result, ok := data["result"].(entity.CrawledResult)
+				if !ok {
+					continue
+				}
+				found++
+				fragments = append(fragments, collection.LiveSyncItem(result, found))
+			case entity.EventCrawlerCompleted, entity.EventCrawlerFailed:
+				c.SyncStatus = entity.SyncStatusFailed
+				if event.Type == entity.EventCrawlerCompleted {
+					c.SyncStatus = entity.SyncStatusSynced
+					c.LastSync = time.Now()
+				}
+				fragments = append(fragments, collection.SyncPanel(c, h.latestRun(c.ID), nil), collection.LiveSyncFinished(c.Slug, c.SyncStatus, found))
+			}
+			if err := sendFragments(ctx, ws, fragments...); err != nil {
+				h.logger.Debug(ctx, "live sync connection closed", map[string]any{
+					"collection_id": c.ID,
+					"error":         err.Error(),
+				})
+				return
+			}
+		}
+	}
+}
+
+func sendFragments(ctx context.Context, ws *websocket.Conn, fragments ...templ.Component) error {
+	var buf bytes.Buffer
+	for _, fragment := range fragments {
+		if err := fragment.Render(ctx, &buf); err != nil {
+			return err
+		}
+	}
+	return websocket.Message.Send(ws, buf.String())
+}
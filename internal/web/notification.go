@@ -0,0 +1,67 @@
+package web
+
+import (
+	"akira/internal/entity"
+	"akira/internal/view/component/notification"
+	"akira/internal/view/page"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func (h *Handler) handleNotificationsPage(w http.ResponseWriter, r *http.Request) error {
+	session, err := h.session.GetSession(r.Context())
+	if err != nil {
+		return WebError{code: http.StatusUnauthorized, msg: entity.ErrUserUnauthorized.Error()}
+	}
+	notifications, err := h.notification.ListNotifications(session.UserID)
+	if err != nil {
+		return err
+	}
+	unread, err := h.notification.CountUnread(session.UserID)
+	if err != nil {
+		return err
+	}
+	return Render(w, r, page.Notifications(notifications, unread))
+}
+
+func (h *Handler) handleNotificationBadgePartial(w http.ResponseWriter, r *http.Request) error {
+	session, err := h.session.GetSession(r.Context())
+	if err != nil {
+		return WebError{code: http.StatusUnauthorized, msg: entity.ErrUserUnauthorized.Error()}
+	}
+	unread, err := h.notification.CountUnread(session.UserID)
+	if err != nil {
+		return err
+	}
+	return Render(w, r, notification.Badge(unread))
+}
+
+func (h *Handler) handleReadNotificationRequest(w http.ResponseWriter, r *http.Request) error {
+	session, err := h.session.GetSession(r.Context())
+	if err != nil {
+		return WebError{code: http.StatusUnauthorized, msg: entity.ErrUserUnauthorized.Error()}
+	}
+	n, err := h.notification.MarkRead(session.UserID, chi.URLParam(r, "id"))
+	if err != nil {
+		switch err {
+		case entity.ErrNotificationNotFound:
+			return WebError{code: http.StatusNotFound, msg: err.Error()}
+		case entity.ErrNotificationForbidden:
+			return WebError{code: http.StatusForbidden, msg: err.Error()}
+		}
+		return err
+	}
+	return HxRedirect(w, r, n.Link())
+}
+
+func (h *Handler) handleReadAllNotificationsRequest(w http.ResponseWriter, r *http.Request) error {
+	session, err := h.session.GetSession(r.Context())
+	if err != nil {
+		return WebError{code: http.StatusUnauthorized, msg: entity.ErrUserUnauthorized.Error()}
+	}
+	if err := h.notification.MarkAllRead(session.UserID); err != nil {
+		return err
+	}
+	return HxRedirect(w, r, "/notifications")
+}
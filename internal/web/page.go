@@ -1,13 +1,47 @@
 package web
 
 import (
+	"akira/internal/entity"
+	"akira/internal/view/component/collection"
 	"akira/internal/view/component/form"
 	"akira/internal/view/page"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
 )
 
 func (h *Handler) handleIndexPage(w http.ResponseWriter, r *http.Request) error {
-	return Render(w, r, page.Dashboard())
+	result, err := h.listCollections(r)
+	if err != nil {
+		return err
+	}
+	return Render(w, r, page.Dashboard(result))
+}
+
+func (h *Handler) handleCollectionListPartial(w http.ResponseWriter, r *http.Request) error {
+	result, err := h.listCollections(r)
+	if err != nil {
+		return err
+	}
+	return Render(w, r, page.CollectionList(result))
+}
+
+func (h *Handler) listCollections(r *http.Request) (*entity.CollectionPage, error) {
+	session, err := h.session.GetSession(r.Context())
+	if err != nil {
+		return nil, WebError{code: http.StatusUnauthorized, msg: entity.ErrUserUnauthorized.Error()}
+	}
+	query := r.URL.Query()
+	pageNum, _ := strconv.Atoi(query.Get("page"))
+	return h.collection.ListCollections(
+		session.UserID,
+		entity.CollectionFilter(query.Get("filter")),
+		entity.CollectionSort(query.Get("sort")),
+		pageNum,
+	)
 }
 
 func (h *Handler) handleSignUpPage(w http.ResponseWriter, r *http.Request) error {
@@ -35,5 +69,76 @@ func (h *Handler) handleCreateCollectionPage(w http.ResponseWriter, r *http.Requ
 	if err := r.ParseForm(); err != nil {
 		return err
 	}
-	return Render(w, r, page.CreateCollection(form.CreateCollectionProps{}, nil))
+	return Render(w, r, page.CreateCollection(form.CreateCollectionProps{}, h.crawler.Providers(), nil))
+}
+
+func (h *Handler) handleCollectionPage(w http.ResponseWriter, r *http.Request) error {
+	session, err := h.session.GetSession(r.Context())
+	if err != nil {
+		return WebError{code: http.StatusUnauthorized, msg: entity.ErrUserUnauthorized.Error()}
+	}
+	collection, err := h.collection.FindCollectionBySlug(session.UserID, chi.URLParam(r, "slug"))
+	if err != nil {
+		if err == entity.ErrCollectionNotFound {
+			return WebError{code: http.StatusNotFound, msg: err.Error()}
+		}
+		return err
+	}
+	books, err := h.book.FindCollectionBooks(session.UserID, collection.ID)
+	if err != nil {
+		return err
+	}
+	pending, err := h.candidate.CountPendingCandidates(collection.ID)
+	if err != nil {
+		return err
+	}
+	return Render(w, r, page.Collection(collection, h.latestRun(collection.ID), collection.Volumes(books, time.Now()), pending))
+}
+
+func (h *Handler) handleCollectionVolumesPartial(w http.ResponseWriter, r *http.Request) error {
+	session, err := h.session.GetSession(r.Context())
+	if err != nil {
+		return WebError{code: http.StatusUnauthorized, msg: entity.ErrUserUnauthorized.Error()}
+	}
+	c, err := h.collection.FindCollectionBySlug(session.UserID, chi.URLParam(r, "slug"))
+	if err != nil {
+		if err == entity.ErrCollectionNotFound {
+			return WebError{code: http.StatusNotFound, msg: err.Error()}
+		}
+		return err
+	}
+	books, err := h.book.FindCollectionBooks(session.UserID, c.ID)
+	if err != nil {
+		return err
+	}
+	pending, err := h.candidate.CountPendingCandidates(c.ID)
+	if err != nil {
+		return err
+	}
+	return Render(w, r, collection.VolumesSection(c, c.Volumes(books, time.Now()), pending))
+}
+
+func (h *Handler) handleEditCollectionPage(w http.ResponseWriter, r *http.Request) error {
+	session, err := h.session.GetSession(r.Context())
+	if err != nil {
+		return WebError{code: http.StatusUnauthorized, msg: entity.ErrUserUnauthorized.Error()}
+	}
+	collection, err := h.collection.FindCollectionBySlug(session.UserID, chi.URLParam(r, "slug"))
+	if err != nil {
+		if err == entity.ErrCollectionNotFound {
+			return WebError{code: http.StatusNotFound, msg: err.Error()}
+		}
+		return err
+	}
+	return Render(w, r, page.EditCollection(collection.Slug, form.EditCollectionProps{
+		Name:         collection.Name,
+		Edition:      collection.Edition,
+		TotalVolumes: collection.TotalVolumes,
+		Author:       strings.Join(collection.Author, ", "),
+		Publisher:    collection.Publisher,
+		Language:     collection.Language,
+		Tags:         strings.Join(collection.Tags, ", "),
+		SyncSources:  collection.SyncSources,
+		SearchTerms:  collection.SearchTerms(),
+	}, h.crawler.Providers(), nil))
 }